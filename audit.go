@@ -0,0 +1,378 @@
+package openfga
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Querier checks entitlements and lists the objects on which a user has an entitlement. It is implemented by
+// Authorizer, and by the decorators that wrap it.
+type Querier interface {
+	Checker
+	ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error)
+}
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request being authorized, for use in audit events.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID of the context, or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// AuditDecision is the outcome of an audited query.
+type AuditDecision string
+
+// Audit decisions.
+const (
+	AuditDecisionAllow AuditDecision = "allow"
+	AuditDecisionDeny  AuditDecision = "deny"
+	AuditDecisionError AuditDecision = "error"
+)
+
+// AuditEvent records an authorization decision.
+type AuditEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Principal string    `json:"principal"`
+
+	// Action is `check` or `list_objects`.
+	Action   string `json:"action"`
+	Relation string `json:"relation"`
+
+	// Object is the checked object, or the listed type followed by a colon (e.g. `instance:`).
+	Object string `json:"object"`
+
+	// Objects are the objects returned by ListObjects. The decision of a ListObjects query is allow if any object was
+	// returned.
+	Objects  []string      `json:"objects,omitempty"`
+	Decision AuditDecision `json:"decision"`
+	Error    string        `json:"error,omitempty"`
+	ModelID  string        `json:"model_id,omitempty"`
+
+	// Latency is the duration of the query in nanoseconds.
+	Latency   time.Duration `json:"latency_ns"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// AuditSink receives audit events. Sinks are called from a single goroutine each, so they need not be safe for
+// concurrent use.
+type AuditSink interface {
+	Write(event AuditEvent) error
+	Close() error
+}
+
+// AuditOptions configures an Auditor.
+type AuditOptions struct {
+	// ModelID is the ID of the authorization model that is recorded in events.
+	ModelID string
+
+	Sinks []AuditSink
+
+	// Redact is applied to every event before it is passed to the sinks. See RedactPrincipal.
+	Redact func(event AuditEvent) AuditEvent
+
+	// BufferSize is the number of events that are queued for each sink. Events for a sink whose queue is full are
+	// dropped. Defaults to 1024.
+	BufferSize int
+
+	// OnError is called with the errors returned by sinks.
+	OnError func(err error)
+}
+
+// Auditor is a Querier that records every decision of the wrapped Querier as an AuditEvent. Events are queued for
+// each sink and written in the background, so that a slow sink never blocks a query. If a sink falls behind, its
+// events are dropped and counted.
+type Auditor struct {
+	querier Querier
+	options AuditOptions
+
+	mu      sync.RWMutex
+	closed  bool
+	queues  []chan AuditEvent
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
+}
+
+// NewAuditor returns an Auditor for the querier. Close must be called to flush the queues and close the sinks.
+func NewAuditor(querier Querier, options AuditOptions) *Auditor {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 1024
+	}
+
+	a := &Auditor{querier: querier, options: options}
+	for _, sink := range options.Sinks {
+		queue := make(chan AuditEvent, options.BufferSize)
+		a.queues = append(a.queues, queue)
+		a.wg.Add(1)
+		go a.run(sink, queue)
+	}
+
+	return a
+}
+
+// run writes the events of a queue to its sink until the queue is closed.
+func (a *Auditor) run(sink AuditSink, queue chan AuditEvent) {
+	defer a.wg.Done()
+	for event := range queue {
+		err := sink.Write(event)
+		if err != nil && a.options.OnError != nil {
+			a.options.OnError(fmt.Errorf("Failed to write audit event: %w", err))
+		}
+	}
+}
+
+// Check implements Checker.
+func (a *Auditor) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	start := time.Now()
+	allowed, err := a.querier.Check(ctx, user, entitlement, object)
+	event := a.newEvent(ctx, start, "check", user, entitlement, object.String(), err)
+	if err == nil && allowed {
+		event.Decision = AuditDecisionAllow
+	}
+
+	a.emit(event)
+	return allowed, err
+}
+
+// ListObjects implements Querier.
+func (a *Auditor) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	start := time.Now()
+	objects, err := a.querier.ListObjects(ctx, user, entitlement, objectType)
+	event := a.newEvent(ctx, start, "list_objects", user, entitlement, string(objectType)+":", err)
+	for _, object := range objects {
+		event.Objects = append(event.Objects, object.String())
+	}
+
+	if err == nil && len(objects) > 0 {
+		event.Decision = AuditDecisionAllow
+	}
+
+	a.emit(event)
+	return objects, err
+}
+
+// newEvent returns an event for a query that started at the given time, with a deny or error decision.
+func (a *Auditor) newEvent(ctx context.Context, start time.Time, action string, user string, entitlement Entitlement, object string, err error) AuditEvent {
+	event := AuditEvent{
+		Timestamp: start.UTC(),
+		Principal: user,
+		Action:    action,
+		Relation:  string(entitlement),
+		Object:    object,
+		Decision:  AuditDecisionDeny,
+		ModelID:   a.options.ModelID,
+		Latency:   time.Since(start),
+		RequestID: RequestID(ctx),
+	}
+
+	if err != nil {
+		event.Decision = AuditDecisionError
+		event.Error = err.Error()
+	}
+
+	return event
+}
+
+// emit queues the event for every sink without blocking.
+func (a *Auditor) emit(event AuditEvent) {
+	if a.options.Redact != nil {
+		event = a.options.Redact(event)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(uint64(len(a.queues)))
+		return
+	}
+
+	for _, queue := range a.queues {
+		select {
+		case queue <- event:
+		default:
+			a.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events that were dropped because a sink fell behind or the auditor was closed. An
+// event that is dropped by several sinks is counted once for each.
+func (a *Auditor) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close writes the queued events and closes the sinks. Later queries are still passed to the wrapped Querier but are
+// not audited.
+func (a *Auditor) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+
+	a.closed = true
+	for _, queue := range a.queues {
+		close(queue)
+	}
+
+	a.mu.Unlock()
+	a.wg.Wait()
+
+	var errs []error
+	for _, sink := range a.options.Sinks {
+		err := sink.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to close audit sinks: %w", errs[0])
+	}
+
+	return nil
+}
+
+// RedactPrincipal returns a redaction that replaces the name of the principal by a salted SHA-256 hash, keeping its
+// type (e.g. `user:9f86d0…`), so that the decisions of a principal can be correlated without recording who they are.
+func RedactPrincipal(salt string) func(event AuditEvent) AuditEvent {
+	return func(event AuditEvent) AuditEvent {
+		principalType, name, ok := strings.Cut(event.Principal, ":")
+		if !ok || name == "*" {
+			return event
+		}
+
+		hash := sha256.Sum256([]byte(salt + name))
+		event.Principal = principalType + ":" + hex.EncodeToString(hash[:])
+		return event
+	}
+}
+
+// ChannelAuditSink sends events to a channel. Sends block until the event is received, which delays only the sink's
+// own queue.
+type ChannelAuditSink chan<- AuditEvent
+
+// Write implements AuditSink.
+func (s ChannelAuditSink) Write(event AuditEvent) error {
+	s <- event
+	return nil
+}
+
+// Close implements AuditSink. The channel is not closed, as it is owned by the caller.
+func (s ChannelAuditSink) Close() error {
+	return nil
+}
+
+// FileAuditSink writes events to a file as JSON lines. When the file reaches its maximum size it is rotated: the
+// file is renamed with the suffix `.1`, existing backups are shifted up and the oldest backup is removed.
+type FileAuditSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewFileAuditSink opens the file for appending. If maxSize is zero the file is never rotated.
+func NewFileAuditSink(path string, maxSize int64, maxBackups int) (*FileAuditSink, error) {
+	s := &FileAuditSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// open opens the file for appending.
+func (s *FileAuditSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to open audit log %q: %w", s.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("Failed to stat audit log %q: %w", s.path, err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Write implements AuditSink.
+func (s *FileAuditSink) Write(event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Failed to encode audit event: %w", err)
+	}
+
+	line = append(line, '\n')
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		err := s.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("Failed to write audit log %q: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// rotate closes the file, shifts the backups and opens a new file.
+func (s *FileAuditSink) rotate() error {
+	err := s.file.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to close audit log %q: %w", s.path, err)
+	}
+
+	if s.maxBackups <= 0 {
+		err = os.Remove(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove audit log %q: %w", s.path, err)
+		}
+
+		return s.open()
+	}
+
+	for i := s.maxBackups - 1; i >= 0; i-- {
+		from := s.path
+		if i > 0 {
+			from = fmt.Sprintf("%s.%d", s.path, i)
+		}
+
+		err = os.Rename(from, fmt.Sprintf("%s.%d", s.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to rotate audit log %q: %w", from, err)
+		}
+	}
+
+	return s.open()
+}
+
+// Close implements AuditSink.
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
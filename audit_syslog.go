@@ -0,0 +1,44 @@
+//go:build !windows && !plan9
+
+package openfga
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+)
+
+// SyslogAuditSink writes events to syslog as JSON. Denials and errors are logged as warnings, all other events as
+// info.
+type SyslogAuditSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogAuditSink connects to the local syslog daemon, logging to the auth facility with the given tag.
+func NewSyslogAuditSink(tag string) (*SyslogAuditSink, error) {
+	writer, err := syslog.New(syslog.LOG_AUTH|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to syslog: %w", err)
+	}
+
+	return &SyslogAuditSink{writer: writer}, nil
+}
+
+// Write implements AuditSink.
+func (s *SyslogAuditSink) Write(event AuditEvent) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Failed to encode audit event: %w", err)
+	}
+
+	if event.Decision == AuditDecisionAllow {
+		return s.writer.Info(string(message))
+	}
+
+	return s.writer.Warning(string(message))
+}
+
+// Close implements AuditSink.
+func (s *SyslogAuditSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,181 @@
+package openfga
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingAuditSink is an AuditSink that blocks until it is released.
+type blockingAuditSink struct {
+	release chan struct{}
+}
+
+// Write implements AuditSink.
+func (s blockingAuditSink) Write(event AuditEvent) error {
+	<-s.release
+	return nil
+}
+
+// Close implements AuditSink.
+func (s blockingAuditSink) Close() error {
+	return nil
+}
+
+// newAuditTestQuerier returns a Querier over a server with one project and instance, operated by alice.
+func newAuditTestQuerier(t *testing.T) Querier {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	return evaluatorChecker{evaluator: NewEvaluator(model, NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:p"},
+		client.ClientTupleKey{User: "project:p", Relation: "project", Object: "instance:p/c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:p/c1"},
+	))}
+}
+
+func TestAuditor(t *testing.T) {
+	events := make(chan AuditEvent, 10)
+	auditor := NewAuditor(newAuditTestQuerier(t), AuditOptions{ModelID: "01HMODEL", Sinks: []AuditSink{ChannelAuditSink(events)}})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	allowed, err := auditor.Check(ctx, "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, err = auditor.Check(ctx, "user:bob", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	_, err = auditor.Check(ctx, "user:bob", "can_fly", ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.Error(t, err)
+
+	objects, err := auditor.ListObjects(context.Background(), "user:alice", EntitlementCanView, ObjectTypeInstance)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	require.NoError(t, auditor.Close())
+
+	event := <-events
+	require.False(t, event.Timestamp.IsZero())
+	require.Greater(t, event.Latency, time.Duration(0))
+	event.Timestamp = time.Time{}
+	event.Latency = 0
+	require.Equal(t, AuditEvent{Principal: "user:alice", Action: "check", Relation: "can_exec", Object: "instance:p/c1", Decision: AuditDecisionAllow, ModelID: "01HMODEL", RequestID: "req-1"}, event)
+
+	event = <-events
+	require.Equal(t, "user:bob", event.Principal)
+	require.Equal(t, AuditDecisionDeny, event.Decision)
+
+	event = <-events
+	require.Equal(t, AuditDecisionError, event.Decision)
+	require.NotEmpty(t, event.Error)
+
+	event = <-events
+	require.Equal(t, "list_objects", event.Action)
+	require.Equal(t, "instance:", event.Object)
+	require.Equal(t, []string{"instance:p/c1"}, event.Objects)
+	require.Equal(t, AuditDecisionAllow, event.Decision)
+	require.Empty(t, event.RequestID)
+
+	encoded, err := json.Marshal(AuditEvent{Principal: "user:alice", Decision: AuditDecisionDeny, Latency: time.Millisecond})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"timestamp":"0001-01-01T00:00:00Z","principal":"user:alice","action":"","relation":"","object":"","decision":"deny","latency_ns":1000000}`, string(encoded))
+}
+
+func TestAuditorRedact(t *testing.T) {
+	events := make(chan AuditEvent, 10)
+	auditor := NewAuditor(newAuditTestQuerier(t), AuditOptions{Sinks: []AuditSink{ChannelAuditSink(events)}, Redact: RedactPrincipal("salt")})
+
+	_, err := auditor.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	_, err = auditor.Check(context.Background(), "user:alice", EntitlementCanView, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.NoError(t, auditor.Close())
+
+	first, second := <-events, <-events
+	require.NotContains(t, first.Principal, "alice")
+	require.Regexp(t, "^user:[0-9a-f]{64}$", first.Principal)
+	require.Equal(t, first.Principal, second.Principal)
+}
+
+func TestAuditorSlowSink(t *testing.T) {
+	slow := blockingAuditSink{release: make(chan struct{})}
+	events := make(chan AuditEvent, 100)
+	auditor := NewAuditor(newAuditTestQuerier(t), AuditOptions{Sinks: []AuditSink{slow, ChannelAuditSink(events)}, BufferSize: 2})
+
+	// The slow sink takes one event and queues two more; the rest are dropped without blocking the checks or the
+	// other sink.
+	done := make(chan error)
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_, err := auditor.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+			if err != nil {
+				done <- err
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Checks blocked on a slow audit sink")
+	}
+
+	for i := 0; i < 10; i++ {
+		<-events
+	}
+
+	require.GreaterOrEqual(t, auditor.Dropped(), uint64(7))
+	close(slow.release)
+	require.NoError(t, auditor.Close())
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(path, 400, 2)
+	require.NoError(t, err)
+
+	var sinkErrs []error
+	auditor := NewAuditor(newAuditTestQuerier(t), AuditOptions{Sinks: []AuditSink{sink}, OnError: func(err error) { sinkErrs = append(sinkErrs, err) }})
+	for i := 0; i < 20; i++ {
+		_, err := auditor.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, auditor.Close())
+	require.Empty(t, sinkErrs)
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(400))
+
+		file, err := os.Open(name)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var event AuditEvent
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+			require.Equal(t, "user:alice", event.Principal)
+		}
+
+		require.NoError(t, file.Close())
+	}
+
+	_, err = os.Stat(path + ".3")
+	require.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,237 @@
+package openfga
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+)
+
+// Model is a parsed OpenFGA authorization model.
+type Model struct {
+	SchemaVersion   string
+	TypeDefinitions []openfgaSDK.TypeDefinition
+
+	types map[string]openfgaSDK.TypeDefinition
+
+	// positions are the positions of types and relations in the DSL, keyed by `type` or `type#relation`.
+	positions map[string]Position
+}
+
+// ParseModel parses an authorization model in the JSON format produced by `make update-openfga`.
+func ParseModel(data []byte) (*Model, error) {
+	var request client.ClientWriteAuthorizationModelRequest
+	err := json.Unmarshal(data, &request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse authorization model: %w", err)
+	}
+
+	return NewModel(request.SchemaVersion, request.TypeDefinitions)
+}
+
+// NewModel returns a Model with the given type definitions, e.g. as read from an OpenFGA store.
+func NewModel(schemaVersion string, typeDefinitions []openfgaSDK.TypeDefinition) (*Model, error) {
+	m := &Model{
+		SchemaVersion:   schemaVersion,
+		TypeDefinitions: typeDefinitions,
+		types:           make(map[string]openfgaSDK.TypeDefinition, len(typeDefinitions)),
+	}
+
+	for _, typeDefinition := range typeDefinitions {
+		_, ok := m.types[typeDefinition.Type]
+		if ok {
+			return nil, fmt.Errorf("Duplicate type %q in authorization model", typeDefinition.Type)
+		}
+
+		m.types[typeDefinition.Type] = typeDefinition
+	}
+
+	return m, nil
+}
+
+// DefaultModel returns the embedded LXD authorization model.
+func DefaultModel() (*Model, error) {
+	return ParseModel([]byte(authModel))
+}
+
+// WriteRequest returns the model as a request body for WriteAuthorizationModel.
+func (m *Model) WriteRequest() client.ClientWriteAuthorizationModelRequest {
+	return client.ClientWriteAuthorizationModelRequest{
+		SchemaVersion:   m.SchemaVersion,
+		TypeDefinitions: m.TypeDefinitions,
+	}
+}
+
+// Equal returns true if both models have the same schema version and type definitions. Fields that are null or an
+// empty list in one model and absent in the other (as returned by some OpenFGA versions) are not considered a
+// difference.
+func (m *Model) Equal(other *Model) bool {
+	if m.SchemaVersion != other.SchemaVersion {
+		return false
+	}
+
+	this, err := canonicalJSON(m.TypeDefinitions)
+	if err != nil {
+		return false
+	}
+
+	that, err := canonicalJSON(other.TypeDefinitions)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(this, that)
+}
+
+// canonicalJSON returns the JSON representation of the value decoded into maps and slices, with null values and empty
+// arrays removed. Empty objects are kept because `this: {}` is significant.
+func canonicalJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded any
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneJSON(decoded), nil
+}
+
+// pruneJSON removes null values and empty arrays from a decoded JSON value.
+func pruneJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			pruned := pruneJSON(child)
+			if pruned == nil {
+				delete(v, key)
+				continue
+			}
+
+			v[key] = pruned
+		}
+
+	case []any:
+		for i, child := range v {
+			v[i] = pruneJSON(child)
+		}
+
+		if len(v) == 0 {
+			return nil
+		}
+	}
+
+	return value
+}
+
+// Types returns the names of all types in the order they are defined. The type that records the migration version of
+// the store is left out, as it is not an LXD object.
+func (m *Model) Types() []string {
+	types := make([]string, 0, len(m.TypeDefinitions))
+	for _, typeDefinition := range m.TypeDefinitions {
+		if typeDefinition.Type == migrationObjectType {
+			continue
+		}
+
+		types = append(types, typeDefinition.Type)
+	}
+
+	return types
+}
+
+// HasType returns true if the model defines the given type.
+func (m *Model) HasType(objectType string) bool {
+	_, ok := m.types[objectType]
+	return ok
+}
+
+// Relations returns the sorted names of all relations defined on the given type.
+func (m *Model) Relations(objectType string) []string {
+	typeDefinition, ok := m.types[objectType]
+	if !ok || typeDefinition.Relations == nil {
+		return nil
+	}
+
+	relations := make([]string, 0, len(*typeDefinition.Relations))
+	for relation := range *typeDefinition.Relations {
+		relations = append(relations, relation)
+	}
+
+	sort.Strings(relations)
+	return relations
+}
+
+// HasRelation returns true if the given relation is defined on the given type.
+func (m *Model) HasRelation(objectType string, relation string) bool {
+	_, ok := m.Userset(objectType, relation)
+	return ok
+}
+
+// Userset returns the rewrite rule of a relation.
+func (m *Model) Userset(objectType string, relation string) (openfgaSDK.Userset, bool) {
+	typeDefinition, ok := m.types[objectType]
+	if !ok || typeDefinition.Relations == nil {
+		return openfgaSDK.Userset{}, false
+	}
+
+	userset, ok := (*typeDefinition.Relations)[relation]
+	return userset, ok
+}
+
+// DirectlyRelatedUserTypes returns the user types that may be written directly against a relation.
+func (m *Model) DirectlyRelatedUserTypes(objectType string, relation string) []openfgaSDK.RelationReference {
+	typeDefinition, ok := m.types[objectType]
+	if !ok || typeDefinition.Metadata == nil || typeDefinition.Metadata.Relations == nil {
+		return nil
+	}
+
+	relationMetadata, ok := (*typeDefinition.Metadata.Relations)[relation]
+	if !ok || relationMetadata.DirectlyRelatedUserTypes == nil {
+		return nil
+	}
+
+	return *relationMetadata.DirectlyRelatedUserTypes
+}
+
+// parentTypes are the types that can be the parent of an object, in the order in which ParentRelation looks for them.
+var parentTypes = []ObjectType{ObjectTypeProject, ObjectTypeServer}
+
+// ParentRelation returns the relation linking objects of the given type to their parent (e.g. `project` on `instance`)
+// and the type of the parent. The parent relation is named after the type of the parent and relates to that type only.
+// Other relations to a project or the server, such as `target_project` on `storage_pool`, are not parent relations.
+func (m *Model) ParentRelation(objectType string) (relation string, parentType string, ok bool) {
+	for _, parentType := range parentTypes {
+		relation := string(parentType)
+		relatedTypes := m.DirectlyRelatedUserTypes(objectType, relation)
+		if len(relatedTypes) == 1 && relatedTypes[0].Type == relation && relatedTypes[0].Relation == nil && relatedTypes[0].Wildcard == nil {
+			return relation, relation, true
+		}
+	}
+
+	return "", "", false
+}
+
+// walkUserset calls f for the userset and each of its descendants.
+func walkUserset(userset openfgaSDK.Userset, f func(openfgaSDK.Userset)) {
+	f(userset)
+
+	var children []openfgaSDK.Userset
+	switch {
+	case userset.Union != nil && userset.Union.Child != nil:
+		children = *userset.Union.Child
+	case userset.Intersection != nil && userset.Intersection.Child != nil:
+		children = *userset.Intersection.Child
+	case userset.Difference != nil:
+		children = []openfgaSDK.Userset{userset.Difference.Base, userset.Difference.Subtract}
+	}
+
+	for _, child := range children {
+		walkUserset(child, f)
+	}
+}
@@ -0,0 +1,131 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// Authorizer checks and manages the entitlements of LXD users in an OpenFGA store.
+type Authorizer struct {
+	fga         *client.OpenFgaClient
+	model       *Model
+	authModelID *string
+	store       TupleStore
+}
+
+// NewAuthorizer returns an Authorizer for the store configured on the given client. If authModelID is nil, queries use
+// the latest authorization model of the store.
+func NewAuthorizer(fga *client.OpenFgaClient, model *Model, authModelID *string) *Authorizer {
+	return &Authorizer{
+		fga:         fga,
+		model:       model,
+		authModelID: authModelID,
+		store:       NewOpenFGAStore(fga, model, authModelID),
+	}
+}
+
+// Store returns the TupleStore of the authorizer.
+func (a *Authorizer) Store() TupleStore {
+	return a.store
+}
+
+// Check returns true if the user has the entitlement on the object.
+func (a *Authorizer) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	if !a.model.HasRelation(string(object.Type), string(entitlement)) {
+		return false, fmt.Errorf("Entitlement %q is not defined on type %q", entitlement, object.Type)
+	}
+
+	checkResponse, err := a.fga.Check(ctx).Options(client.ClientCheckOptions{AuthorizationModelId: a.authModelID}).Body(client.ClientCheckRequest{
+		User:     user,
+		Relation: string(entitlement),
+		Object:   object.String(),
+	}).Execute()
+	if err != nil {
+		return false, fmt.Errorf("Failed to check %q on %q: %w", entitlement, object, err)
+	}
+
+	return checkResponse.GetAllowed(), nil
+}
+
+// ListObjects returns the objects of the given type on which the user has the entitlement.
+func (a *Authorizer) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	if !a.model.HasRelation(string(objectType), string(entitlement)) {
+		return nil, fmt.Errorf("Entitlement %q is not defined on type %q", entitlement, objectType)
+	}
+
+	listObjectsResponse, err := a.fga.ListObjects(ctx).Options(client.ClientListObjectsOptions{AuthorizationModelId: a.authModelID}).Body(client.ClientListObjectsRequest{
+		User:     user,
+		Relation: string(entitlement),
+		Type:     string(objectType),
+	}).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list %s objects: %w", objectType, err)
+	}
+
+	objects := make([]Object, 0, len(listObjectsResponse.GetObjects()))
+	for _, name := range listObjectsResponse.GetObjects() {
+		object, err := ParseObject(name)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// GrantRole grants the user a role on the object.
+func (a *Authorizer) GrantRole(ctx context.Context, user string, role Role, object Object) error {
+	return a.store.WriteTuples(ctx, []client.ClientTupleKey{{User: user, Relation: string(role), Object: object.String()}}, nil)
+}
+
+// RevokeRole revokes a role granted to the user on the object.
+func (a *Authorizer) RevokeRole(ctx context.Context, user string, role Role, object Object) error {
+	return a.store.WriteTuples(ctx, nil, []client.ClientTupleKey{{User: user, Relation: string(role), Object: object.String()}})
+}
+
+// GrantEntitlement grants the user an entitlement on the object. Only entitlements that the model allows to be
+// assigned directly (such as `can_edit` on a storage pool) can be granted.
+func (a *Authorizer) GrantEntitlement(ctx context.Context, user string, entitlement Entitlement, object Object) error {
+	return a.store.WriteTuples(ctx, []client.ClientTupleKey{{User: user, Relation: string(entitlement), Object: object.String()}}, nil)
+}
+
+// RevokeEntitlement revokes an entitlement granted directly to the user on the object.
+func (a *Authorizer) RevokeEntitlement(ctx context.Context, user string, entitlement Entitlement, object Object) error {
+	return a.store.WriteTuples(ctx, nil, []client.ClientTupleKey{{User: user, Relation: string(entitlement), Object: object.String()}})
+}
+
+// ListUsers returns the users of the given type that have the relation with the object. See Evaluator.ListUsers.
+// OpenFGA has no equivalent query, so the tuples of the store are read and evaluated in process.
+func (a *Authorizer) ListUsers(ctx context.Context, object Object, relation string, userType string) ([]string, error) {
+	evaluator, err := a.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluator.ListUsers(ctx, object.String(), relation, userType)
+}
+
+// Explain returns the relationships that grant the user the relation with the object. See Evaluator.Explain. The
+// tuples of the store are read and evaluated in process.
+func (a *Authorizer) Explain(ctx context.Context, user string, relation string, object Object) (*Explanation, error) {
+	evaluator, err := a.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluator.Explain(ctx, client.ClientCheckRequest{User: user, Relation: relation, Object: object.String()})
+}
+
+// snapshot returns an Evaluator over a copy of all tuples in the store.
+func (a *Authorizer) snapshot(ctx context.Context) (*Evaluator, error) {
+	tuples, err := a.store.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEvaluator(a.model, NewMemoryStore(tuples...)), nil
+}
@@ -0,0 +1,260 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BootstrapResult is the result of BootstrapStore.
+type BootstrapResult struct {
+	// StoreID is the ID of the store, which is also set on the client.
+	StoreID string
+
+	// AuthorizationModelID is the ID of the model that must be used for all queries and writes.
+	AuthorizationModelID string
+
+	// StoreCreated is true if the store did not exist.
+	StoreCreated bool
+
+	// ModelWritten is true if the model was written as a new version.
+	ModelWritten bool
+
+	// Outdated is true if the model is an earlier version of the latest model in the store, e.g. because another
+	// cluster member has already been upgraded. The latest model is left in place and the ID of the earlier version is
+	// returned.
+	Outdated bool
+
+	// Migrations is the report of the migrations applied to the tuples of the store, or nil if none were pending.
+	Migrations *MigrationReport
+}
+
+// BootstrapStore finds or creates the named store and makes sure that it contains the model. A new model version is
+// written only if the model differs from the latest model in the store, so running it again is a no-op.
+//
+// It is safe for several LXD cluster members to bootstrap the same store at the same time. OpenFGA does not enforce
+// unique store names, so every member that creates the store lists the stores again and they all settle on the one
+// with the lowest ID (store IDs are ULIDs, so this is the oldest). A member whose store lost deletes it. Likewise, if
+// concurrent members write the same model, the oldest of the identical latest versions is used so that all members
+// record the same model ID.
+//
+// The migrations that have not been applied to the tuples of the cluster yet, according to MigrationVersion, are then
+// applied with Migrate. A new store is recorded at the latest version, and an outdated member leaves the tuples to the
+// members running the latest model.
+//
+// The store ID of the client is changed to the bootstrapped store.
+func BootstrapStore(ctx context.Context, fga *client.OpenFgaClient, storeName string, model *Model, cluster Cluster, migrations []Migration) (*BootstrapResult, error) {
+	result := &BootstrapResult{}
+
+	storeIDs, err := findStores(ctx, fga, storeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(storeIDs) == 0 {
+		createStoreResponse, err := fga.CreateStore(ctx).Body(client.ClientCreateStoreRequest{Name: storeName}).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create store %q: %w", storeName, err)
+		}
+
+		createdID := createStoreResponse.GetId()
+		storeIDs, err = findStores(ctx, fga, storeName)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(storeIDs) == 0 || storeIDs[0] == createdID {
+			storeIDs = []string{createdID}
+			result.StoreCreated = true
+		} else {
+			// Another member created the store first. Nothing has been written to ours, so it can be deleted.
+			fga.SetStoreId(createdID)
+			_, err = fga.DeleteStore(ctx).Execute()
+			if err != nil {
+				return nil, fmt.Errorf("Failed to delete duplicate store %q: %w", createdID, err)
+			}
+		}
+	}
+
+	result.StoreID = storeIDs[0]
+	fga.SetStoreId(result.StoreID)
+
+	models, err := readModels(ctx, fga)
+	if err != nil {
+		return nil, err
+	}
+
+	modelID, outdated, err := findModel(models, model)
+	if err != nil {
+		return nil, err
+	}
+
+	if modelID == "" {
+		writeAuthorizationModelResponse, err := fga.WriteAuthorizationModel(ctx).Body(model.WriteRequest()).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to write authorization model: %w", err)
+		}
+
+		result.ModelWritten = true
+		modelID = writeAuthorizationModelResponse.GetAuthorizationModelId()
+
+		// Use the oldest identical version in case another member wrote the model at the same time.
+		models, err = readModels(ctx, fga)
+		if err != nil {
+			return nil, err
+		}
+
+		latestID, _, err := findModel(models, model)
+		if err != nil {
+			return nil, err
+		}
+
+		if latestID != "" {
+			modelID = latestID
+		}
+	}
+
+	result.AuthorizationModelID = modelID
+	result.Outdated = outdated
+	if outdated || len(migrations) == 0 {
+		return result, nil
+	}
+
+	store := NewOpenFGAStore(fga, model, &result.AuthorizationModelID)
+	latest := migrations[len(migrations)-1].Version
+	if result.StoreCreated {
+		err = RecordMigrationVersion(ctx, store, cluster, 0, latest)
+	} else {
+		result.Migrations, err = migratePending(ctx, store, model, cluster, migrations)
+	}
+
+	if err != nil {
+		// Another member may have migrated the store at the same time, so that the tuples it wrote already exist.
+		version, versionErr := MigrationVersion(ctx, store, cluster)
+		if versionErr != nil || version < latest {
+			return nil, fmt.Errorf("Failed to migrate store %q: %w", result.StoreID, err)
+		}
+
+		result.Migrations = nil
+	}
+
+	return result, nil
+}
+
+// migratePending applies the migrations that have not been applied to the store yet, and returns nil if there are none.
+func migratePending(ctx context.Context, store TupleStore, model *Model, cluster Cluster, migrations []Migration) (*MigrationReport, error) {
+	version, err := MigrationVersion(ctx, store, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if version >= migrations[len(migrations)-1].Version {
+		return nil, nil
+	}
+
+	return Migrate(ctx, store, model, cluster, migrations, version, MigrateOptions{})
+}
+
+// findStores returns the IDs of all stores with the given name, sorted.
+//
+// The API client is used directly because the SDK client drops the continuation token of ListStores and
+// ReadAuthorizationModels, which would request the first page forever.
+func findStores(ctx context.Context, fga *client.OpenFgaClient, storeName string) ([]string, error) {
+	var storeIDs []string
+	var continuationToken string
+	for {
+		request := fga.OpenFgaApi.ListStores(ctx)
+		if continuationToken != "" {
+			request = request.ContinuationToken(continuationToken)
+		}
+
+		listStoresResponse, _, err := request.Execute()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list stores: %w", err)
+		}
+
+		for _, store := range listStoresResponse.GetStores() {
+			if store.GetName() == storeName && store.DeletedAt == nil {
+				storeIDs = append(storeIDs, store.GetId())
+			}
+		}
+
+		if listStoresResponse.GetContinuationToken() == "" {
+			break
+		}
+
+		continuationToken = listStoresResponse.GetContinuationToken()
+	}
+
+	sort.Strings(storeIDs)
+	return storeIDs, nil
+}
+
+// readModels returns all authorization models of the store configured on the client, latest first.
+func readModels(ctx context.Context, fga *client.OpenFgaClient) (models []openfgaSDK.AuthorizationModel, err error) {
+	// Model reads are only traced if the caller is, with the caller's tracer provider.
+	options := TracingOptions{TracerProvider: trace.SpanFromContext(ctx).TracerProvider(), StoreID: fga.GetConfig().StoreId}
+	ctx, span := options.start(ctx, "openfga.ReadAuthorizationModels")
+	defer func() {
+		if len(models) > 0 {
+			span.SetAttributes(attributeModelID.String(models[0].GetId()))
+		}
+
+		endSpan(span, err)
+	}()
+
+	var continuationToken string
+	for {
+		request := fga.OpenFgaApi.ReadAuthorizationModels(ctx)
+		if continuationToken != "" {
+			request = request.ContinuationToken(continuationToken)
+		}
+
+		readAuthorizationModelsResponse, _, err := request.Execute()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read authorization models: %w", err)
+		}
+
+		models = append(models, readAuthorizationModelsResponse.GetAuthorizationModels()...)
+		if readAuthorizationModelsResponse.GetContinuationToken() == "" {
+			break
+		}
+
+		continuationToken = readAuthorizationModelsResponse.GetContinuationToken()
+	}
+
+	return models, nil
+}
+
+// findModel returns the ID of the model in the list of models (latest first). If the latest models are identical to
+// the model, the ID of the oldest of them is returned. Otherwise the ID of the latest earlier version identical to the
+// model is returned and outdated is true. An empty ID is returned if the store does not contain the model.
+func findModel(models []openfgaSDK.AuthorizationModel, model *Model) (id string, outdated bool, err error) {
+	for i, stored := range models {
+		storedModel, err := NewModel(stored.SchemaVersion, stored.GetTypeDefinitions())
+		if err != nil {
+			return "", false, err
+		}
+
+		if !storedModel.Equal(model) {
+			if id != "" {
+				// End of the run of identical latest models.
+				return id, false, nil
+			}
+
+			continue
+		}
+
+		if i > 0 && id == "" {
+			return stored.GetId(), true, nil
+		}
+
+		id = stored.GetId()
+	}
+
+	return id, false, nil
+}
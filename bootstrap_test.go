@@ -0,0 +1,126 @@
+package openfga_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+
+	openfga "github.com/markylaing/lxd-openfga"
+	"github.com/markylaing/lxd-openfga/internal/openfgatest"
+)
+
+func TestBootstrapStore(t *testing.T) {
+	model, err := openfga.DefaultModel()
+	require.NoError(t, err)
+
+	fake := openfgatest.NewServer(t)
+
+	// Another application's store and an unrelated model do not interfere.
+	_, err = fake.NewClient(t).CreateStore(context.Background()).Body(client.ClientCreateStoreRequest{Name: "other"}).Execute()
+	require.NoError(t, err)
+
+	fga := fake.NewClient(t)
+	result, err := openfga.BootstrapStore(context.Background(), fga, "lxd", model, "", openfga.Migrations)
+	require.NoError(t, err)
+	require.True(t, result.StoreCreated)
+	require.True(t, result.ModelWritten)
+	require.False(t, result.Outdated)
+	require.Nil(t, result.Migrations)
+	require.Equal(t, result.StoreID, fga.GetStoreId())
+
+	readAuthorizationModelResponse, err := fga.ReadAuthorizationModel(context.Background()).Options(client.ClientReadAuthorizationModelOptions{AuthorizationModelId: &result.AuthorizationModelID}).Execute()
+	require.NoError(t, err)
+	stored, err := openfga.NewModel(readAuthorizationModelResponse.AuthorizationModel.SchemaVersion, readAuthorizationModelResponse.AuthorizationModel.GetTypeDefinitions())
+	require.NoError(t, err)
+	require.True(t, stored.Equal(model))
+
+	// A new store has nothing to migrate and is recorded at the latest version.
+	version, err := openfga.MigrationVersion(context.Background(), fake.Tuples(result.StoreID), "")
+	require.NoError(t, err)
+	require.Equal(t, openfga.Migrations[len(openfga.Migrations)-1].Version, version)
+
+	// Bootstrapping again is a no-op.
+	again, err := openfga.BootstrapStore(context.Background(), fake.NewClient(t), "lxd", model, "", openfga.Migrations)
+	require.NoError(t, err)
+	require.Equal(t, openfga.BootstrapResult{StoreID: result.StoreID, AuthorizationModelID: result.AuthorizationModelID}, *again)
+	require.Len(t, fake.Models(result.StoreID), 1)
+
+	// A changed model is written as a new version.
+	upgraded, err := openfga.NewModel(model.SchemaVersion, append(model.TypeDefinitions, openfgaSDK.TypeDefinition{Type: "robot"}))
+	require.NoError(t, err)
+
+	// The pending migrations are applied to the tuples of the store.
+	tuple := client.ClientTupleKey{User: "user:alice", Relation: "user", Object: "instance:default/c1"}
+	require.NoError(t, fake.Tuples(result.StoreID).WriteTuples(context.Background(), []client.ClientTupleKey{tuple}, nil))
+	upgradedMigrations := append(append([]openfga.Migration(nil), openfga.Migrations...), openfga.Migration{
+		Version:     openfga.Migrations[len(openfga.Migrations)-1].Version + 1,
+		Description: "Merge instance users into operators",
+		Steps:       []openfga.MigrationStep{openfga.RenameRelation{Type: openfga.ObjectTypeInstance, From: "user", To: "operator"}},
+	})
+
+	upgradeResult, err := openfga.BootstrapStore(context.Background(), fake.NewClient(t), "lxd", upgraded, "", upgradedMigrations)
+	require.NoError(t, err)
+	require.True(t, upgradeResult.ModelWritten)
+	require.NotEqual(t, result.AuthorizationModelID, upgradeResult.AuthorizationModelID)
+	require.Equal(t, upgradedMigrations[len(upgradedMigrations)-1].Version, upgradeResult.Migrations.Version)
+	require.Equal(t, []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:default/c1"}}, upgradeResult.Migrations.Diff.Writes)
+
+	instanceTuples, err := fake.Tuples(result.StoreID).ReadTuples(context.Background(), client.ClientTupleKey{Object: "instance:"})
+	require.NoError(t, err)
+	require.Equal(t, upgradeResult.Migrations.Diff.Writes, instanceTuples)
+
+	version, err = openfga.MigrationVersion(context.Background(), fake.Tuples(result.StoreID), "")
+	require.NoError(t, err)
+	require.Equal(t, upgradeResult.Migrations.Version, version)
+
+	// A member that has not been upgraded yet uses its own version without downgrading the store or its tuples.
+	outdatedResult, err := openfga.BootstrapStore(context.Background(), fake.NewClient(t), "lxd", model, "", openfga.Migrations)
+	require.NoError(t, err)
+	require.Equal(t, openfga.BootstrapResult{StoreID: result.StoreID, AuthorizationModelID: result.AuthorizationModelID, Outdated: true}, *outdatedResult)
+	require.Len(t, fake.Models(result.StoreID), 2)
+
+	// Migrations are not run again.
+	upgradeAgain, err := openfga.BootstrapStore(context.Background(), fake.NewClient(t), "lxd", upgraded, "", upgradedMigrations)
+	require.NoError(t, err)
+	require.Nil(t, upgradeAgain.Migrations)
+}
+
+func TestBootstrapStoreConcurrent(t *testing.T) {
+	model, err := openfga.DefaultModel()
+	require.NoError(t, err)
+
+	fake := openfgatest.NewServer(t)
+
+	const members = 5
+	results := make([]*openfga.BootstrapResult, members)
+	errs := make([]error, members)
+	var wg sync.WaitGroup
+	for i := 0; i < members; i++ {
+		i := i
+		fga := fake.NewClient(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = openfga.BootstrapStore(context.Background(), fga, "lxd", model, "", openfga.Migrations)
+		}()
+	}
+
+	wg.Wait()
+
+	for i := 0; i < members; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, results[0].StoreID, results[i].StoreID, "All members must use the same store")
+		require.Equal(t, results[0].AuthorizationModelID, results[i].AuthorizationModelID, "All members must use the same model")
+	}
+
+	require.Len(t, fake.Stores(), 1, "Duplicate stores must be deleted")
+
+	records, err := fake.Tuples(results[0].StoreID).ReadTuples(context.Background(), client.ClientTupleKey{Object: "migration:"})
+	require.NoError(t, err)
+	require.Equal(t, []client.ClientTupleKey{{User: "server:lxd", Relation: "applied", Object: fmt.Sprintf("migration:%d", openfga.Migrations[len(openfga.Migrations)-1].Version)}}, records)
+}
@@ -0,0 +1,43 @@
+package openfga
+
+import (
+	"context"
+)
+
+// clusterQuerier is a Querier that qualifies the objects of its queries with a cluster.
+type clusterQuerier struct {
+	querier Querier
+	cluster Cluster
+}
+
+// NewClusterQuerier returns a Querier for one of several clusters sharing a store. Objects are given without a
+// cluster, as extracted from LXD requests, and are qualified with the cluster before they are checked. ListObjects only
+// returns the objects of the cluster, without the cluster.
+func NewClusterQuerier(querier Querier, cluster Cluster) Querier {
+	return &clusterQuerier{querier: querier, cluster: cluster}
+}
+
+// Check implements Checker.
+func (q *clusterQuerier) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	return q.querier.Check(ctx, user, entitlement, object.InCluster(q.cluster))
+}
+
+// ListObjects implements Querier.
+func (q *clusterQuerier) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	objects, err := q.querier.ListObjects(ctx, user, entitlement, objectType)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterObjects := make([]Object, 0, len(objects))
+	for _, object := range objects {
+		if object.Cluster != q.cluster {
+			continue
+		}
+
+		object.Cluster = ""
+		clusterObjects = append(clusterObjects, object)
+	}
+
+	return clusterObjects, nil
+}
@@ -0,0 +1,271 @@
+package openfga
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjectCluster(t *testing.T) {
+	tests := []struct {
+		description string
+		object      string
+		expected    Object
+		err         string
+	}{
+		{
+			description: "Unqualified server",
+			object:      "server:lxd",
+			expected:    ServerObject(),
+		},
+		{
+			description: "Server of a cluster",
+			object:      "server:lxd@9b2c5f7e-6c3a-4f5e-8a21-3d1c2b4a5e6f",
+			expected:    Cluster("9b2c5f7e-6c3a-4f5e-8a21-3d1c2b4a5e6f").Server(),
+		},
+		{
+			description: "Project of a cluster",
+			object:      "project:default@a",
+			expected:    Cluster("a").Project("default"),
+		},
+		{
+			description: "Resource of a cluster",
+			object:      "storage_pool:pool01@a",
+			expected:    Cluster("a").Resource(ObjectTypeStoragePool, "pool01"),
+		},
+		{
+			description: "Project resource of a cluster",
+			object:      "instance:p/c1@a",
+			expected:    Cluster("a").ProjectResource(ObjectTypeInstance, "p", "c1"),
+		},
+		{
+			description: "Project resource with slashes in its name",
+			object:      "storage_pool_volume:p/pool01/custom/v1@a",
+			expected:    Cluster("a").ProjectResource(ObjectTypeStoragePoolVolume, "p", "pool01/custom/v1"),
+		},
+		{
+			description: "Users are not qualified by a cluster",
+			object:      "user:alice@example.com",
+			expected:    Object{Type: ObjectTypeUser, Name: "alice@example.com"},
+		},
+		{
+			description: "Empty cluster",
+			object:      "instance:p/c1@",
+			err:         `Invalid object "instance:p/c1@": ID and cluster must not be empty`,
+		},
+		{
+			description: "Empty ID",
+			object:      "server:@a",
+			err:         `Invalid object "server:@a": ID and cluster must not be empty`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		object, err := ParseObject(test.object)
+		if test.err != "" {
+			require.EqualError(t, err, test.err)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, test.expected, object)
+		require.Equal(t, test.object, object.String())
+	}
+
+	require.Equal(t, Cluster("a").ProjectResource(ObjectTypeInstance, "p", "c1"), ProjectResourceObject(ObjectTypeInstance, "p", "c1").InCluster("a"))
+	require.Equal(t, Object{Type: ObjectTypeGroup, Name: "admins"}, Object{Type: ObjectTypeGroup, Name: "admins"}.InCluster("a"))
+}
+
+// newClusterTestEvaluator returns an evaluator for a store shared by clusters a and b, which both have a project p
+// with an instance c1 and a storage pool pool01. Alice is an admin of cluster a, the admins group is an admin of
+// cluster b, and bob is a member of the group.
+func newClusterTestEvaluator(t *testing.T) *Evaluator {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "user:alice", Relation: "admin", Object: "server:lxd@a"},
+		client.ClientTupleKey{User: "group:admins#member", Relation: "admin", Object: "server:lxd@b"},
+		client.ClientTupleKey{User: "user:bob", Relation: "member", Object: "group:admins"},
+	)
+
+	for _, cluster := range []Cluster{"a", "b"} {
+		server := cluster.Server().String()
+		project := cluster.Project("p").String()
+		require.NoError(t, store.WriteTuples(context.Background(), []client.ClientTupleKey{
+			{User: "user:*", Relation: "user", Object: server},
+			{User: server, Relation: "server", Object: project},
+			{User: server, Relation: "server", Object: cluster.Resource(ObjectTypeStoragePool, "pool01").String()},
+			{User: project, Relation: "project", Object: cluster.ProjectResource(ObjectTypeInstance, "p", "c1").String()},
+		}, nil))
+	}
+
+	return NewEvaluator(model, store)
+}
+
+func TestClusterIsolation(t *testing.T) {
+	evaluator := newClusterTestEvaluator(t)
+	querier := evaluatorChecker{evaluator: evaluator}
+	clusterA := NewClusterQuerier(querier, "a")
+	clusterB := NewClusterQuerier(querier, "b")
+
+	tests := []struct {
+		description string
+		user        string
+		entitlement Entitlement
+		object      Object
+		allowedA    bool
+		allowedB    bool
+	}{
+		{
+			description: "A server admin can edit only their own server",
+			user:        "user:alice",
+			entitlement: EntitlementCanEditServer,
+			object:      ServerObject(),
+			allowedA:    true,
+		},
+		{
+			description: "A server admin can edit only the project of their own cluster",
+			user:        "user:alice",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectObject("p"),
+			allowedA:    true,
+		},
+		{
+			description: "A server admin can exec only in the instance of their own cluster",
+			user:        "user:alice",
+			entitlement: EntitlementCanExec,
+			object:      ProjectResourceObject(ObjectTypeInstance, "p", "c1"),
+			allowedA:    true,
+		},
+		{
+			description: "A server admin can edit only the storage pool of their own cluster",
+			user:        "user:alice",
+			entitlement: EntitlementCanEdit,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "pool01"},
+			allowedA:    true,
+		},
+		{
+			description: "Groups are shared, but their grants are per cluster",
+			user:        "user:bob",
+			entitlement: EntitlementCanExec,
+			object:      ProjectResourceObject(ObjectTypeInstance, "p", "c1"),
+			allowedB:    true,
+		},
+		{
+			description: "Public access is per cluster",
+			user:        "user:carol",
+			entitlement: EntitlementCanViewServer,
+			object:      ServerObject(),
+			allowedA:    true,
+			allowedB:    true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		allowed, err := clusterA.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.Equal(t, test.allowedA, allowed)
+
+		allowed, err = clusterB.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.Equal(t, test.allowedB, allowed)
+
+		// Unqualified objects are not the objects of any cluster.
+		allowed, err = querier.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.False(t, allowed)
+	}
+
+	objects, err := clusterA.ListObjects(context.Background(), "user:alice", EntitlementCanExec, ObjectTypeInstance)
+	require.NoError(t, err)
+	require.Equal(t, []Object{ProjectResourceObject(ObjectTypeInstance, "p", "c1")}, objects)
+
+	objects, err = clusterB.ListObjects(context.Background(), "user:alice", EntitlementCanExec, ObjectTypeInstance)
+	require.NoError(t, err)
+	require.Empty(t, objects)
+}
+
+func TestClusterMiddleware(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	routes, err := LXDRoutes(model)
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	querier := evaluatorChecker{evaluator: newClusterTestEvaluator(t)}
+	clusterA := httptest.NewServer(Middleware(NewClusterQuerier(querier, "a"), routes, headerUser)(handler))
+	defer clusterA.Close()
+
+	clusterB := httptest.NewServer(Middleware(NewClusterQuerier(querier, "b"), routes, headerUser)(handler))
+	defer clusterB.Close()
+
+	tests := []struct {
+		description string
+		url         string
+		method      string
+		path        string
+		status      int
+	}{
+		{
+			description: "A server admin can edit a storage pool of their own cluster",
+			url:         clusterA.URL,
+			method:      http.MethodPut,
+			path:        "/1.0/storage-pools/pool01",
+			status:      http.StatusOK,
+		},
+		{
+			description: "A server admin cannot edit a storage pool with the same name in another cluster",
+			url:         clusterB.URL,
+			method:      http.MethodPut,
+			path:        "/1.0/storage-pools/pool01",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "A server admin can exec in an instance of their own cluster",
+			url:         clusterA.URL,
+			method:      http.MethodPost,
+			path:        "/1.0/instances/c1/exec?project=p",
+			status:      http.StatusOK,
+		},
+		{
+			description: "A server admin cannot exec in an instance with the same project and name in another cluster",
+			url:         clusterB.URL,
+			method:      http.MethodPost,
+			path:        "/1.0/instances/c1/exec?project=p",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "Requests cannot name the cluster of an object",
+			url:         clusterA.URL,
+			method:      http.MethodDelete,
+			path:        "/1.0/storage-pools/pool01/volumes/custom/v1@b?project=p",
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		request, err := http.NewRequest(test.method, test.url+test.path, nil)
+		require.NoError(t, err)
+		request.Header.Set("X-User", "user:alice")
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, test.status, response.StatusCode)
+	}
+}
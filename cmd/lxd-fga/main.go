@@ -0,0 +1,264 @@
+// Command lxd-fga checks and manages the permissions of LXD users and groups in an OpenFGA store.
+//
+// The OpenFGA endpoint is configured with global flags or the environment variables FGA_API_URL, FGA_STORE_ID,
+// FGA_MODEL_ID and FGA_API_TOKEN. Objects are given as `<type>:<name>`. Resources that belong to a project may also be
+// given in full as `<type>:<project>/<name>`, otherwise they are qualified with the project of the `--project` flag.
+// In a store shared by several clusters, objects are qualified with the cluster of the global `--cluster` flag
+// (FGA_CLUSTER) unless they are given as `<type>:<id>@<cluster>`.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/openfga/go-sdk/credentials"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+// command is a subcommand of lxd-fga.
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, env *environment, args []string) error
+
+	// noStore is true for commands that do not require a store ID.
+	noStore bool
+}
+
+// commands are the subcommands of lxd-fga, keyed by name.
+var commands = map[string]command{
+	"check":           {usage: "check <subject> <entitlement> <object>", description: "Check whether a user or group has an entitlement", run: runCheck},
+	"grant":           {usage: "grant <subject> <relation> <object>", description: "Grant a role or entitlement", run: runGrant},
+	"revoke":          {usage: "revoke <subject> <relation> <object>", description: "Revoke a role or entitlement", run: runRevoke},
+	"list-objects":    {usage: "list-objects <subject> <entitlement> <type>", description: "List the objects of a type on which a user or group has an entitlement", run: runListObjects},
+	"list-users":      {usage: "list-users <object> <relation>", description: "List the users or groups that have a relation with an object", run: runListUsers},
+	"explain":         {usage: "explain <subject> <relation> <object>", description: "Show the relationships that grant a user or group a relation", run: runExplain},
+	"model write":     {usage: "model write", description: "Write the authorization model to the store", run: runModelWrite},
+	"model compare":   {usage: "model compare <from.json>", description: "Report changes between two versions of the authorization model", run: runModelCompare, noStore: true},
+	"model graph":     {usage: "model graph", description: "Print the authorization model as a graph", run: runModelGraph, noStore: true},
+	"model lint":      {usage: "model lint <model.openfga>", description: "Check an authorization model in the DSL against the LXD conventions", run: runModelLint, noStore: true},
+	"model transform": {usage: "model transform <model.openfga>", description: "Print an authorization model in the DSL as JSON", run: runModelTransform, noStore: true},
+	"matrix":          {usage: "matrix", description: "Print the entitlements granted by each role of the authorization model", run: runMatrix, noStore: true},
+	"store init":      {usage: "store init <name>", description: "Create or upgrade a store and allow all users to access the server", run: runStoreInit, noStore: true},
+}
+
+// commandOrder is the order in which commands are listed in the usage message.
+var commandOrder = []string{"check", "grant", "revoke", "list-objects", "list-users", "explain", "model write", "model compare", "model graph", "model lint", "model transform", "matrix", "store init"}
+
+// environment is the configuration shared by all commands.
+type environment struct {
+	fga         *client.OpenFgaClient
+	model       *openfga.Model
+	authModelID *string
+	cluster     openfga.Cluster
+	stdout      io.Writer
+	stderr      io.Writer
+
+	// usage is the usage line of the command being run.
+	usage string
+}
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags, configures the OpenFGA client and runs the requested command.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("lxd-fga", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	apiURL := flags.String("api-url", envOrDefault("FGA_API_URL", "http://localhost:8080"), "OpenFGA API URL (FGA_API_URL)")
+	storeID := flags.String("store-id", os.Getenv("FGA_STORE_ID"), "OpenFGA store ID (FGA_STORE_ID)")
+	modelID := flags.String("model-id", os.Getenv("FGA_MODEL_ID"), "Authorization model ID, defaults to the latest model of the store (FGA_MODEL_ID)")
+	apiToken := flags.String("api-token", os.Getenv("FGA_API_TOKEN"), "OpenFGA API token (FGA_API_TOKEN)")
+	cluster := flags.String("cluster", os.Getenv("FGA_CLUSTER"), "Cluster ID, such as the cluster UUID, in a store shared by several clusters (FGA_CLUSTER)")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: lxd-fga [flags] <command> [command flags] [args]\n\nCommands:\n")
+		for _, name := range commandOrder {
+			fmt.Fprintf(stderr, "  %-45s %s\n", commands[name].usage, commands[name].description)
+		}
+
+		fmt.Fprintf(stderr, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	name := args[0]
+	args = args[1:]
+	if (name == "model" || name == "store") && len(args) > 0 {
+		name += " " + args[0]
+		args = args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("Unknown command %q", name)
+	}
+
+	if *storeID == "" && !cmd.noStore {
+		return fmt.Errorf("A store ID is required: Set --store-id or FGA_STORE_ID")
+	}
+
+	u, err := url.Parse(*apiURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("Invalid API URL %q: Must be of the form <scheme>://<host>[:<port>]", *apiURL)
+	}
+
+	config := &client.ClientConfiguration{
+		ApiScheme: u.Scheme,
+		ApiHost:   u.Host,
+		StoreId:   *storeID,
+	}
+
+	if *apiToken != "" {
+		config.Credentials = &credentials.Credentials{
+			Method: credentials.CredentialsMethodApiToken,
+			Config: &credentials.Config{ApiToken: *apiToken},
+		}
+	}
+
+	env := &environment{stdout: stdout, stderr: stderr, usage: cmd.usage, cluster: openfga.Cluster(*cluster)}
+	if *modelID != "" {
+		env.authModelID = modelID
+		config.AuthorizationModelId = modelID
+	}
+
+	env.fga, err = client.NewSdkClient(config)
+	if err != nil {
+		return fmt.Errorf("Failed to create OpenFGA client: %w", err)
+	}
+
+	env.model, err = openfga.DefaultModel()
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(ctx, env, args)
+}
+
+// envOrDefault returns the value of the environment variable, or the default if it is not set.
+func envOrDefault(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// outputFlags are the flags common to all commands.
+type outputFlags struct {
+	format  string
+	project string
+}
+
+// newFlagSet returns the flag set of a command with the common flags registered. Usage and parse errors are written to
+// the stderr of the environment.
+func newFlagSet(env *environment, name string) (*flag.FlagSet, *outputFlags) {
+	output := &outputFlags{}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	flags.StringVar(&output.format, "format", "table", "Output format (table or json)")
+	flags.StringVar(&output.project, "project", "default", "Project of objects that belong to a project")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lxd-fga %s\n\nFlags:\n", env.usage)
+		flags.PrintDefaults()
+	}
+
+	return flags, output
+}
+
+// parseArgs parses the flags of a command and checks the number of positional arguments.
+func parseArgs(flags *flag.FlagSet, output *outputFlags, args []string, count int) ([]string, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if output.format != "table" && output.format != "json" {
+		return nil, fmt.Errorf("Invalid format %q: Must be table or json", output.format)
+	}
+
+	if flags.NArg() != count {
+		flags.Usage()
+		return nil, fmt.Errorf("Expected %d arguments, got %d", count, flags.NArg())
+	}
+
+	return flags.Args(), nil
+}
+
+// parseObject parses an object argument. Resources that belong to a project are qualified with the given project
+// unless a project is already part of the name, and objects with the cluster of the environment unless a cluster is
+// already part of the name.
+func parseObject(env *environment, arg string, project string) (openfga.Object, error) {
+	object, err := openfga.ParseObject(arg)
+	if err != nil {
+		return openfga.Object{}, err
+	}
+
+	if !env.model.HasType(string(object.Type)) {
+		return openfga.Object{}, fmt.Errorf("Unknown object type %q", object.Type)
+	}
+
+	if object.Project == "" && isProjectResource(env.model, object.Type) {
+		object.Project = project
+	}
+
+	if object.Cluster == "" {
+		object = object.InCluster(env.cluster)
+	}
+
+	return object, nil
+}
+
+// isProjectResource returns true if objects of the type belong to a project.
+func isProjectResource(model *openfga.Model, objectType openfga.ObjectType) bool {
+	_, parentType, ok := model.ParentRelation(string(objectType))
+	return ok && openfga.ObjectType(parentType) == openfga.ObjectTypeProject
+}
+
+// printJSON writes the value as indented JSON.
+func printJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printTable writes the rows as aligned columns under the header.
+func printTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
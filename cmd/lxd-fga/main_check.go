@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+// checkResult is the JSON output of the check command.
+type checkResult struct {
+	User        string `json:"user"`
+	Entitlement string `json:"entitlement"`
+	Object      string `json:"object"`
+	Allowed     bool   `json:"allowed"`
+}
+
+func runCheck(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "check")
+	args, err := parseArgs(flags, output, args, 3)
+	if err != nil {
+		return err
+	}
+
+	user, err := openfga.ParseSubject(args[0])
+	if err != nil {
+		return err
+	}
+
+	object, err := parseObject(env, args[2], output.project)
+	if err != nil {
+		return err
+	}
+
+	authorizer := openfga.NewAuthorizer(env.fga, env.model, env.authModelID)
+	allowed, err := authorizer.Check(ctx, user, openfga.Entitlement(args[1]), object)
+	if err != nil {
+		return err
+	}
+
+	result := checkResult{User: user, Entitlement: args[1], Object: object.String(), Allowed: allowed}
+	if output.format == "json" {
+		return printJSON(env.stdout, result)
+	}
+
+	return printTable(env.stdout, []string{"USER", "ENTITLEMENT", "OBJECT", "ALLOWED"}, [][]string{
+		{result.User, result.Entitlement, result.Object, strconv.FormatBool(result.Allowed)},
+	})
+}
+
+func runListObjects(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "list-objects")
+	allProjects := flags.Bool("all-projects", false, "List objects in all projects")
+	args, err := parseArgs(flags, output, args, 3)
+	if err != nil {
+		return err
+	}
+
+	user, err := openfga.ParseSubject(args[0])
+	if err != nil {
+		return err
+	}
+
+	objectType := openfga.ObjectType(args[2])
+	authorizer := openfga.NewAuthorizer(env.fga, env.model, env.authModelID)
+	objects, err := authorizer.ListObjects(ctx, user, openfga.Entitlement(args[1]), objectType)
+	if err != nil {
+		return err
+	}
+
+	filterProject := isProjectResource(env.model, objectType) && !*allProjects
+	names := []string{}
+	rows := [][]string{}
+	for _, object := range objects {
+		if filterProject && object.Project != output.project {
+			continue
+		}
+
+		if object.Cluster != env.cluster {
+			continue
+		}
+
+		names = append(names, object.String())
+		rows = append(rows, []string{string(object.Type), object.Project, object.Name})
+	}
+
+	if output.format == "json" {
+		return printJSON(env.stdout, names)
+	}
+
+	return printTable(env.stdout, []string{"TYPE", "PROJECT", "NAME"}, rows)
+}
+
+func runListUsers(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "list-users")
+	userType := flags.String("type", string(openfga.ObjectTypeUser), "Type of users to list, e.g. user or group#member")
+	args, err := parseArgs(flags, output, args, 2)
+	if err != nil {
+		return err
+	}
+
+	object, err := parseObject(env, args[0], output.project)
+	if err != nil {
+		return err
+	}
+
+	authorizer := openfga.NewAuthorizer(env.fga, env.model, env.authModelID)
+	users, err := authorizer.ListUsers(ctx, object, args[1], *userType)
+	if err != nil {
+		return err
+	}
+
+	if output.format == "json" {
+		return printJSON(env.stdout, users)
+	}
+
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, []string{user})
+	}
+
+	return printTable(env.stdout, []string{"USER"}, rows)
+}
+
+func runExplain(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "explain")
+	args, err := parseArgs(flags, output, args, 3)
+	if err != nil {
+		return err
+	}
+
+	user, err := openfga.ParseSubject(args[0])
+	if err != nil {
+		return err
+	}
+
+	object, err := parseObject(env, args[2], output.project)
+	if err != nil {
+		return err
+	}
+
+	authorizer := openfga.NewAuthorizer(env.fga, env.model, env.authModelID)
+	explanation, err := authorizer.Explain(ctx, user, args[1], object)
+	if err != nil {
+		return err
+	}
+
+	if output.format == "json" {
+		return printJSON(env.stdout, explanation)
+	}
+
+	if !explanation.Allowed {
+		return printTable(env.stdout, []string{"USER", "RELATION", "OBJECT", "ALLOWED"}, [][]string{
+			{user, args[1], object.String(), "false"},
+		})
+	}
+
+	rows := make([][]string, 0, len(explanation.Path))
+	for _, step := range explanation.Path {
+		source := "derived"
+		if step.Stored {
+			source = "tuple"
+		}
+
+		rows = append(rows, []string{step.User, step.Relation, step.Object, source})
+	}
+
+	return printTable(env.stdout, []string{"USER", "RELATION", "OBJECT", "SOURCE"}, rows)
+}
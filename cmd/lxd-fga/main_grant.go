@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+func runGrant(ctx context.Context, env *environment, args []string) error {
+	return grantOrRevoke(ctx, env, "grant", args, true)
+}
+
+func runRevoke(ctx context.Context, env *environment, args []string) error {
+	return grantOrRevoke(ctx, env, "revoke", args, false)
+}
+
+// grantOrRevoke grants or revokes a relation. Relations starting with `can_` are entitlements, all others are roles.
+func grantOrRevoke(ctx context.Context, env *environment, name string, args []string, grant bool) error {
+	flags, output := newFlagSet(env, name)
+	args, err := parseArgs(flags, output, args, 3)
+	if err != nil {
+		return err
+	}
+
+	user, err := openfga.ParseSubject(args[0])
+	if err != nil {
+		return err
+	}
+
+	object, err := parseObject(env, args[2], output.project)
+	if err != nil {
+		return err
+	}
+
+	authorizer := openfga.NewAuthorizer(env.fga, env.model, env.authModelID)
+	relation := args[1]
+	switch {
+	case strings.HasPrefix(relation, "can_") && grant:
+		err = authorizer.GrantEntitlement(ctx, user, openfga.Entitlement(relation), object)
+	case strings.HasPrefix(relation, "can_"):
+		err = authorizer.RevokeEntitlement(ctx, user, openfga.Entitlement(relation), object)
+	case grant:
+		err = authorizer.GrantRole(ctx, user, openfga.Role(relation), object)
+	default:
+		err = authorizer.RevokeRole(ctx, user, openfga.Role(relation), object)
+	}
+
+	return err
+}
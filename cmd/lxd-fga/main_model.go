@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+// modelResult is the JSON output of the model write and store init commands.
+type modelResult struct {
+	StoreID              string `json:"store_id,omitempty"`
+	AuthorizationModelID string `json:"authorization_model_id"`
+}
+
+func runModelWrite(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "model write")
+	file := flags.String("file", "", "Authorization model in JSON, defaults to the embedded LXD model")
+	_, err := parseArgs(flags, output, args, 0)
+	if err != nil {
+		return err
+	}
+
+	model := env.model
+	if *file != "" {
+		model, err = readModel(*file)
+		if err != nil {
+			return err
+		}
+	}
+
+	authModelID, err := writeModel(ctx, env, model)
+	if err != nil {
+		return err
+	}
+
+	return printModelResult(env, output.format, modelResult{AuthorizationModelID: authModelID})
+}
+
+func runModelCompare(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "model compare")
+	toFile := flags.String("to", "", "New version of the authorization model in JSON, defaults to the embedded LXD model")
+	args, err := parseArgs(flags, output, args, 1)
+	if err != nil {
+		return err
+	}
+
+	from, err := readModel(args[0])
+	if err != nil {
+		return err
+	}
+
+	to := env.model
+	if *toFile != "" {
+		to, err = readModel(*toFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes := openfga.CompareModels(from, to)
+	if output.format == "json" {
+		err = printJSON(env.stdout, changes)
+	} else {
+		rows := make([][]string, 0, len(changes))
+		for _, change := range changes {
+			rows = append(rows, []string{string(change.Level), change.Type, change.Relation, change.Description})
+		}
+
+		err = printTable(env.stdout, []string{"LEVEL", "TYPE", "RELATION", "DESCRIPTION"}, rows)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Level == openfga.CompatibilityBreaking {
+			return fmt.Errorf("The authorization model has breaking changes")
+		}
+	}
+
+	return nil
+}
+
+func runModelGraph(ctx context.Context, env *environment, args []string) error {
+	flags := flag.NewFlagSet("model graph", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	format := flags.String("format", string(openfga.GraphDOT), "Output format (dot or mermaid)")
+	highlight := flags.String("highlight", "", "Relation whose incoming paths are highlighted, e.g. instance#can_exec")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("Expected 0 arguments, got %d", flags.NArg())
+	}
+
+	return env.model.EncodeGraph(env.stdout, openfga.GraphFormat(*format), openfga.GraphOptions{Highlight: *highlight})
+}
+
+func runModelLint(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "model lint")
+	args, err := parseArgs(flags, output, args, 1)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("Failed to read authorization model: %w", err)
+	}
+
+	model, err := openfga.ParseDSL(data)
+	if err != nil {
+		return fmt.Errorf("Failed to parse %q: %w", args[0], err)
+	}
+
+	violations := openfga.Lint(model, openfga.LXDLintRules)
+	if output.format == "json" {
+		err = printJSON(env.stdout, violations)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, violation := range violations {
+			fmt.Fprintf(env.stdout, "%s:%s\n", args[0], violation)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("Found %d violations", len(violations))
+	}
+
+	return nil
+}
+
+// runModelTransform prints a model in the DSL as compact JSON, as embedded in the package by `make update-openfga`.
+func runModelTransform(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "model transform")
+	args, err := parseArgs(flags, output, args, 1)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("Failed to read authorization model: %w", err)
+	}
+
+	model, err := openfga.ParseDSL(data)
+	if err != nil {
+		return fmt.Errorf("Failed to parse %q: %w", args[0], err)
+	}
+
+	// The schema version comes first, as in the output of the OpenFGA syntax transformer.
+	data, err = json.Marshal(struct {
+		SchemaVersion   string                      `json:"schema_version"`
+		TypeDefinitions []openfgaSDK.TypeDefinition `json:"type_definitions"`
+	}{SchemaVersion: model.SchemaVersion, TypeDefinitions: model.TypeDefinitions})
+	if err != nil {
+		return fmt.Errorf("Failed to encode authorization model: %w", err)
+	}
+
+	_, err = fmt.Fprintf(env.stdout, "%s\n", data)
+	return err
+}
+
+func runMatrix(ctx context.Context, env *environment, args []string) error {
+	flags := flag.NewFlagSet("matrix", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	format := flags.String("format", string(openfga.MatrixMarkdown), "Output format (markdown, html or csv)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("Expected 0 arguments, got %d", flags.NArg())
+	}
+
+	matrix, err := openfga.NewRoleMatrix(ctx, env.model)
+	if err != nil {
+		return err
+	}
+
+	return matrix.Encode(env.stdout, openfga.MatrixFormat(*format))
+}
+
+// readModel reads an authorization model in JSON from a file.
+func readModel(path string) (*openfga.Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read authorization model: %w", err)
+	}
+
+	return openfga.ParseModel(data)
+}
+
+// writeModel writes the model to the store configured on the client and returns its ID.
+func writeModel(ctx context.Context, env *environment, model *openfga.Model) (string, error) {
+	writeAuthorizationModelResponse, err := env.fga.WriteAuthorizationModel(ctx).Body(model.WriteRequest()).Execute()
+	if err != nil {
+		return "", fmt.Errorf("Failed to write authorization model: %w", err)
+	}
+
+	return writeAuthorizationModelResponse.GetAuthorizationModelId(), nil
+}
+
+// printModelResult prints the IDs of a store and model.
+func printModelResult(env *environment, format string, result modelResult) error {
+	if format == "json" {
+		return printJSON(env.stdout, result)
+	}
+
+	if result.StoreID == "" {
+		return printTable(env.stdout, []string{"AUTHORIZATION MODEL ID"}, [][]string{{result.AuthorizationModelID}})
+	}
+
+	return printTable(env.stdout, []string{"STORE ID", "AUTHORIZATION MODEL ID"}, [][]string{{result.StoreID, result.AuthorizationModelID}})
+}
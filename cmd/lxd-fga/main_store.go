@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+
+	"github.com/openfga/go-sdk/client"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+func runStoreInit(ctx context.Context, env *environment, args []string) error {
+	flags, output := newFlagSet(env, "store init")
+	args, err := parseArgs(flags, output, args, 1)
+	if err != nil {
+		return err
+	}
+
+	result, err := openfga.BootstrapStore(ctx, env.fga, args[0], env.model, env.cluster, openfga.Migrations)
+	if err != nil {
+		return err
+	}
+
+	// All authenticated users can access the server of the cluster.
+	publicAccess := client.ClientTupleKey{
+		User:     openfga.UserSubject("*"),
+		Relation: string(openfga.RoleUser),
+		Object:   env.cluster.Server().String(),
+	}
+
+	store := openfga.NewOpenFGAStore(env.fga, env.model, &result.AuthorizationModelID)
+	tuples, err := store.ReadTuples(ctx, publicAccess)
+	if err != nil {
+		return err
+	}
+
+	if len(tuples) == 0 {
+		err = store.WriteTuples(ctx, []client.ClientTupleKey{publicAccess}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return printModelResult(env, output.format, modelResult{StoreID: result.StoreID, AuthorizationModelID: result.AuthorizationModelID})
+}
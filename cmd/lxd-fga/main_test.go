@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	openfga "github.com/markylaing/lxd-openfga"
+	"github.com/markylaing/lxd-openfga/internal/openfgatest"
+)
+
+// clearEnvironment unsets the environment variables that configure lxd-fga.
+func clearEnvironment(t *testing.T) {
+	for _, key := range []string{"FGA_API_URL", "FGA_STORE_ID", "FGA_MODEL_ID", "FGA_API_TOKEN", "FGA_CLUSTER"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestRunArguments(t *testing.T) {
+	clearEnvironment(t)
+
+	tests := []struct {
+		description string
+		args        []string
+		expectedErr string
+	}{
+		{
+			description: "Unknown command",
+			args:        []string{"frobnicate"},
+			expectedErr: `Unknown command "frobnicate"`,
+		},
+		{
+			description: "Unknown model subcommand",
+			args:        []string{"model", "frobnicate"},
+			expectedErr: `Unknown command "model frobnicate"`,
+		},
+		{
+			description: "Commands that query the store require a store ID",
+			args:        []string{"check", "user:alice", "can_view", "project:default"},
+			expectedErr: "A store ID is required: Set --store-id or FGA_STORE_ID",
+		},
+		{
+			description: "Invalid API URL",
+			args:        []string{"--api-url", "localhost:8080", "--store-id", "01HSTORE", "check", "user:alice", "can_view", "project:default"},
+			expectedErr: `Invalid API URL "localhost:8080": Must be of the form <scheme>://<host>[:<port>]`,
+		},
+		{
+			description: "Missing argument",
+			args:        []string{"--store-id", "01HSTORE", "check", "user:alice", "can_view"},
+			expectedErr: "Expected 3 arguments, got 2",
+		},
+		{
+			description: "Flags of a command come before its arguments",
+			args:        []string{"--store-id", "01HSTORE", "grant", "user:alice", "operator", "project:default", "--project", "p"},
+			expectedErr: "Expected 3 arguments, got 5",
+		},
+		{
+			description: "Invalid output format",
+			args:        []string{"--store-id", "01HSTORE", "check", "--format", "yaml", "user:alice", "can_view", "project:default"},
+			expectedErr: `Invalid format "yaml": Must be table or json`,
+		},
+		{
+			description: "Invalid matrix format",
+			args:        []string{"matrix", "--format", "yaml"},
+			expectedErr: `Unknown matrix format "yaml"`,
+		},
+		{
+			description: "Invalid subject",
+			args:        []string{"--store-id", "01HSTORE", "grant", "alice", "operator", "project:default"},
+			expectedErr: `Invalid subject "alice": Must be of the form <type>:<name> or <type>:<name>#<relation>`,
+		},
+		{
+			description: "Unknown object type",
+			args:        []string{"--store-id", "01HSTORE", "grant", "user:alice", "operator", "robot:r2"},
+			expectedErr: `Unknown object type "robot"`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		var stdout, stderr bytes.Buffer
+		err := run(context.Background(), test.args, &stdout, &stderr)
+		require.EqualError(t, err, test.expectedErr)
+		require.Empty(t, stdout.String())
+	}
+
+	// The usage of a command is printed when its arguments are wrong.
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"--store-id", "01HSTORE", "check", "user:alice"}, &stdout, &stderr)
+	require.EqualError(t, err, "Expected 3 arguments, got 1")
+	require.True(t, strings.HasPrefix(stderr.String(), "Usage: lxd-fga check <subject> <entitlement> <object>\n"))
+
+	// Without a command, the usage is printed.
+	stderr.Reset()
+	err = run(context.Background(), nil, &stdout, &stderr)
+	require.ErrorIs(t, err, flag.ErrHelp)
+	require.Contains(t, stderr.String(), "Usage: lxd-fga [flags] <command> [command flags] [args]")
+	for _, name := range commandOrder {
+		require.Contains(t, stderr.String(), commands[name].usage)
+	}
+}
+
+func TestParseObject(t *testing.T) {
+	model, err := openfga.DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		cluster     openfga.Cluster
+		arg         string
+		project     string
+		expected    string
+		expectedErr string
+	}{
+		{
+			description: "Resources of a project are qualified with the project",
+			arg:         "instance:c1",
+			project:     "p",
+			expected:    "instance:p/c1",
+		},
+		{
+			description: "Resources given with a project keep it",
+			arg:         "instance:q/c1",
+			project:     "p",
+			expected:    "instance:q/c1",
+		},
+		{
+			description: "Resources of the server are not qualified with the project",
+			arg:         "storage_pool:default",
+			project:     "p",
+			expected:    "storage_pool:default",
+		},
+		{
+			description: "Objects are qualified with the cluster",
+			cluster:     "c2",
+			arg:         "instance:c1",
+			project:     "default",
+			expected:    "instance:default/c1@c2",
+		},
+		{
+			description: "Objects given with a cluster keep it",
+			cluster:     "c2",
+			arg:         "project:p@c3",
+			project:     "default",
+			expected:    "project:p@c3",
+		},
+		{
+			description: "Unknown type",
+			arg:         "robot:r2",
+			project:     "default",
+			expectedErr: `Unknown object type "robot"`,
+		},
+		{
+			description: "Missing name",
+			arg:         "instance",
+			project:     "default",
+			expectedErr: `Invalid object "instance": Must be of the form <type>:<id>`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		object, err := parseObject(&environment{model: model, cluster: test.cluster}, test.arg, test.project)
+		if test.expectedErr != "" {
+			require.EqualError(t, err, test.expectedErr)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, test.expected, object.String())
+	}
+}
+
+func TestGrantRevoke(t *testing.T) {
+	clearEnvironment(t)
+	server := openfgatest.NewServer(t)
+
+	// lxdFGA runs lxd-fga against the fake server and returns its output.
+	lxdFGA := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(context.Background(), append([]string{"--api-url", server.URL.String()}, args...), &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	output, err := lxdFGA("store", "init", "--format", "json", "lxd")
+	require.NoError(t, err)
+
+	var result modelResult
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	require.NotEmpty(t, result.StoreID)
+	require.NotEmpty(t, result.AuthorizationModelID)
+
+	inStore := func(args ...string) (string, error) {
+		return lxdFGA(append([]string{"--store-id", result.StoreID}, args...)...)
+	}
+
+	output, err = inStore("grant", "user:alice", "operator", "project:default")
+	require.NoError(t, err)
+	require.Empty(t, output)
+
+	output, err = inStore("check", "user:alice", "can_create_instances", "project:default")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"USER        ENTITLEMENT           OBJECT           ALLOWED\n"+
+		"user:alice  can_create_instances  project:default  true\n", output)
+
+	output, err = inStore("check", "--format", "json", "user:alice", "can_edit", "project:default")
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "user": "user:alice",
+  "entitlement": "can_edit",
+  "object": "project:default",
+  "allowed": false
+}
+`, output)
+
+	// Groups are granted to their members, and entitlements are granted directly.
+	output, err = inStore("grant", "--project", "p", "group:ops", "can_edit", "network_acl:acl1")
+	require.NoError(t, err)
+	require.Empty(t, output)
+
+	output, err = inStore("list-users", "--project", "p", "--type", "group#member", "network_acl:acl1", "can_edit")
+	require.NoError(t, err)
+	require.Equal(t, "USER\ngroup:ops#member\n", output)
+
+	output, err = inStore("list-users", "--format", "json", "--project", "p", "--type", "group#member", "network_acl:acl1", "can_view")
+	require.NoError(t, err)
+	require.Equal(t, "[\n  \"group:ops#member\"\n]\n", output)
+
+	// Objects are listed in the project of the --project flag unless --all-projects is set.
+	_, err = inStore("grant", "--project", "p", "user:bob", "user", "instance:c1")
+	require.NoError(t, err)
+	_, err = inStore("grant", "user:bob", "user", "instance:c2")
+	require.NoError(t, err)
+
+	output, err = inStore("list-objects", "--project", "p", "user:bob", "can_exec", "instance")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"TYPE      PROJECT  NAME\n"+
+		"instance  p        c1\n", output)
+
+	output, err = inStore("list-objects", "--all-projects", "--format", "json", "user:bob", "can_exec", "instance")
+	require.NoError(t, err)
+	var objects []string
+	require.NoError(t, json.Unmarshal([]byte(output), &objects))
+	require.ElementsMatch(t, []string{"instance:p/c1", "instance:default/c2"}, objects)
+
+	output, err = inStore("explain", "user:alice", "can_create_instances", "project:default")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"USER        RELATION              OBJECT           SOURCE\n"+
+		"user:alice  can_create_instances  project:default  derived\n"+
+		"user:alice  operator              project:default  tuple\n", output)
+
+	// Revoked roles no longer grant their entitlements.
+	output, err = inStore("revoke", "user:alice", "operator", "project:default")
+	require.NoError(t, err)
+	require.Empty(t, output)
+
+	output, err = inStore("check", "user:alice", "can_create_instances", "project:default")
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"USER        ENTITLEMENT           OBJECT           ALLOWED\n"+
+		"user:alice  can_create_instances  project:default  false\n", output)
+
+	_, err = inStore("revoke", "user:alice", "operator", "project:default")
+	require.ErrorContains(t, err, "Failed to write tuples")
+
+	// Entitlements that cannot be granted directly are rejected before they are written.
+	_, err = inStore("grant", "user:alice", "can_edit", "project:default")
+	require.Error(t, err)
+	require.Len(t, server.Tuples(result.StoreID).Tuples(), 5)
+}
@@ -0,0 +1,262 @@
+package openfga
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+)
+
+// CompatibilityLevel classifies a change between two versions of a model.
+type CompatibilityLevel string
+
+const (
+	// CompatibilityBreaking is a change that invalidates existing tuples or checks, such as a removed relation.
+	CompatibilityBreaking CompatibilityLevel = "breaking"
+
+	// CompatibilityWidening is a change that grants a relation to more users than before.
+	CompatibilityWidening CompatibilityLevel = "widening"
+
+	// CompatibilityNarrowing is a change that grants a relation to fewer users than before.
+	CompatibilityNarrowing CompatibilityLevel = "narrowing"
+)
+
+// CompatibilityChange is a single change between two versions of a model.
+type CompatibilityChange struct {
+	Level       CompatibilityLevel `json:"level"`
+	Type        string             `json:"type"`
+	Relation    string             `json:"relation,omitempty"`
+	Description string             `json:"description"`
+}
+
+// String implements fmt.Stringer.
+func (c CompatibilityChange) String() string {
+	if c.Relation == "" {
+		return fmt.Sprintf("%s: %s: %s", c.Level, c.Type, c.Description)
+	}
+
+	return fmt.Sprintf("%s: %s#%s: %s", c.Level, c.Type, c.Relation, c.Description)
+}
+
+// CompareModels reports the changes from one version of a model to the next that change the meaning of existing
+// tuples. It reports removed types and relations and changes to the user types that can be written directly to a
+// relation. For each relation in both versions, it also compares the directly assignable relations that grant it (its
+// grantors, see Model.Grantors), and the intersections and exclusions it is subject to (see Model.Restrictions). A
+// new or changed intersection or exclusion is narrowing, and one that is only removed is widening. Changes are sorted
+// by type and relation.
+func CompareModels(from *Model, to *Model) []CompatibilityChange {
+	var changes []CompatibilityChange
+	add := func(level CompatibilityLevel, objectType string, relation string, format string, args ...any) {
+		changes = append(changes, CompatibilityChange{Level: level, Type: objectType, Relation: relation, Description: fmt.Sprintf(format, args...)})
+	}
+
+	for _, objectType := range from.Types() {
+		if !to.HasType(objectType) {
+			add(CompatibilityBreaking, objectType, "", "Type removed")
+			continue
+		}
+
+		for _, relation := range from.Relations(objectType) {
+			if !to.HasRelation(objectType, relation) {
+				add(CompatibilityBreaking, objectType, relation, "Relation removed")
+				continue
+			}
+
+			fromUserTypes := relationReferences(from.DirectlyRelatedUserTypes(objectType, relation))
+			toUserTypes := relationReferences(to.DirectlyRelatedUserTypes(objectType, relation))
+			removed, added := compareSets(fromUserTypes, toUserTypes)
+			if len(removed) > 0 {
+				add(CompatibilityBreaking, objectType, relation, "No longer allows %s to be written directly", strings.Join(removed, ", "))
+			}
+
+			if len(added) > 0 {
+				add(CompatibilityWidening, objectType, relation, "Allows %s to be written directly", strings.Join(added, ", "))
+			}
+
+			removed, added = compareSets(from.Grantors(objectType, relation), to.Grantors(objectType, relation))
+			if len(removed) > 0 {
+				add(CompatibilityNarrowing, objectType, relation, "No longer granted by %s", strings.Join(removed, ", "))
+			}
+
+			if len(added) > 0 {
+				add(CompatibilityWidening, objectType, relation, "Now granted by %s", strings.Join(added, ", "))
+			}
+
+			removed, added = compareSets(from.Restrictions(objectType, relation), to.Restrictions(objectType, relation))
+			if len(added) > 0 {
+				add(CompatibilityNarrowing, objectType, relation, "Now restricted by %s", strings.Join(added, "; "))
+			}
+
+			if len(removed) > 0 {
+				add(CompatibilityWidening, objectType, relation, "No longer restricted by %s", strings.Join(removed, "; "))
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+
+		return changes[i].Relation < changes[j].Relation
+	})
+
+	return changes
+}
+
+// Grantors returns the directly assignable relations (e.g. `project#operator`) whose tuples grant the relation on an
+// object of the given type, following computed relations and parent relations. Relations that are only subtracted in
+// an exclusion are not grantors. Intersections are treated like unions, so the result may include relations that only
+// grant the relation together with another one. Such conditions are reported by Restrictions.
+func (m *Model) Grantors(objectType string, relation string) []string {
+	grantors := make(map[string]struct{})
+	m.walkRewrites(objectType, relation, make(map[string]struct{}), func(objectType string, relation string, userset openfgaSDK.Userset) {
+		if userset.This != nil {
+			grantors[objectType+"#"+relation] = struct{}{}
+		}
+	})
+
+	return sortedKeys(grantors)
+}
+
+// Restrictions returns the intersections and exclusions that the relation on an object of the given type is subject
+// to, following computed relations and parent relations as Grantors does. Each is formatted as in the DSL, after the
+// relation whose rewrite contains it, e.g. `project#can_view: viewer but not banned`.
+func (m *Model) Restrictions(objectType string, relation string) []string {
+	restrictions := make(map[string]struct{})
+	m.walkRewrites(objectType, relation, make(map[string]struct{}), func(objectType string, relation string, userset openfgaSDK.Userset) {
+		if userset.Intersection != nil || userset.Difference != nil {
+			restrictions[objectType+"#"+relation+": "+m.formatRewrite(objectType, relation, userset, false)] = struct{}{}
+		}
+	})
+
+	return sortedKeys(restrictions)
+}
+
+// walkRewrites calls visit with every node of the rewrite of the relation, and of the rewrites of the relations it
+// refers to through computed relations and parent relations. The subtracted side of exclusions is not followed.
+func (m *Model) walkRewrites(objectType string, relation string, visited map[string]struct{}, visit func(objectType string, relation string, userset openfgaSDK.Userset)) {
+	key := objectType + "#" + relation
+	_, ok := visited[key]
+	if ok {
+		return
+	}
+
+	visited[key] = struct{}{}
+
+	userset, ok := m.Userset(objectType, relation)
+	if !ok {
+		return
+	}
+
+	var walk func(userset openfgaSDK.Userset)
+	walk = func(userset openfgaSDK.Userset) {
+		visit(objectType, relation, userset)
+		switch {
+		case userset.ComputedUserset != nil:
+			m.walkRewrites(objectType, userset.ComputedUserset.GetRelation(), visited, visit)
+		case userset.TupleToUserset != nil:
+			computed := userset.TupleToUserset.ComputedUserset.GetRelation()
+			for _, parent := range m.DirectlyRelatedUserTypes(objectType, userset.TupleToUserset.Tupleset.GetRelation()) {
+				if parent.Relation == nil && parent.Wildcard == nil {
+					m.walkRewrites(parent.Type, computed, visited, visit)
+				}
+			}
+
+		case userset.Union != nil:
+			for _, child := range userset.Union.GetChild() {
+				walk(child)
+			}
+
+		case userset.Intersection != nil:
+			for _, child := range userset.Intersection.GetChild() {
+				walk(child)
+			}
+
+		case userset.Difference != nil:
+			walk(userset.Difference.Base)
+		}
+	}
+
+	walk(userset)
+}
+
+// formatRewrite formats a node of the rewrite of a relation as in the DSL. The operands of unions and intersections
+// are sorted, so that reordering them does not change the result. Operations nested in another one are parenthesized.
+func (m *Model) formatRewrite(objectType string, relation string, userset openfgaSDK.Userset, nested bool) string {
+	operation := func(operator string, children []openfgaSDK.Userset) string {
+		operands := make([]string, 0, len(children))
+		for _, child := range children {
+			operands = append(operands, m.formatRewrite(objectType, relation, child, true))
+		}
+
+		sort.Strings(operands)
+		if nested {
+			return "(" + strings.Join(operands, operator) + ")"
+		}
+
+		return strings.Join(operands, operator)
+	}
+
+	switch {
+	case userset.This != nil:
+		return "[" + strings.Join(relationReferences(m.DirectlyRelatedUserTypes(objectType, relation)), ", ") + "]"
+	case userset.ComputedUserset != nil:
+		return userset.ComputedUserset.GetRelation()
+	case userset.TupleToUserset != nil:
+		return userset.TupleToUserset.ComputedUserset.GetRelation() + " from " + userset.TupleToUserset.Tupleset.GetRelation()
+	case userset.Union != nil:
+		return operation(" or ", userset.Union.GetChild())
+	case userset.Intersection != nil:
+		return operation(" and ", userset.Intersection.GetChild())
+	case userset.Difference != nil:
+		base := m.formatRewrite(objectType, relation, userset.Difference.Base, true)
+		subtract := m.formatRewrite(objectType, relation, userset.Difference.Subtract, true)
+		if nested {
+			return "(" + base + " but not " + subtract + ")"
+		}
+
+		return base + " but not " + subtract
+	}
+
+	return ""
+}
+
+// relationReferences formats directly related user types as in the DSL.
+func relationReferences(references []openfgaSDK.RelationReference) []string {
+	result := make([]string, 0, len(references))
+	for _, reference := range references {
+		result = append(result, formatRelationReference(reference.Type, reference.GetRelation(), reference.HasWildcard()))
+	}
+
+	return result
+}
+
+// compareSets returns the sorted elements only in from and only in to.
+func compareSets(from []string, to []string) (removed []string, added []string) {
+	toSet := make(map[string]struct{}, len(to))
+	for _, element := range to {
+		toSet[element] = struct{}{}
+	}
+
+	fromSet := make(map[string]struct{}, len(from))
+	for _, element := range from {
+		fromSet[element] = struct{}{}
+		_, ok := toSet[element]
+		if !ok {
+			removed = append(removed, element)
+		}
+	}
+
+	for _, element := range to {
+		_, ok := fromSet[element]
+		if !ok {
+			added = append(added, element)
+		}
+	}
+
+	sort.Strings(removed)
+	sort.Strings(added)
+	return removed, added
+}
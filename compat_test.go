@@ -0,0 +1,153 @@
+package openfga
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareModels(t *testing.T) {
+	from, err := ParseModel([]byte(`{
+  "schema_version": "1.1",
+  "type_definitions": [
+    {"type": "user"},
+    {"type": "group", "relations": {"member": {"this": {}}}, "metadata": {"relations": {"member": {"directly_related_user_types": [{"type": "user"}]}}}},
+    {"type": "server",
+     "relations": {
+       "admin": {"this": {}},
+       "operator": {"union": {"child": [{"this": {}}, {"computedUserset": {"relation": "admin"}}]}}
+     },
+     "metadata": {"relations": {
+       "admin": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]},
+       "operator": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]}
+     }}},
+    {"type": "project",
+     "relations": {
+       "server": {"this": {}},
+       "manager": {"this": {}},
+       "viewer": {"this": {}},
+       "can_edit": {"union": {"child": [{"computedUserset": {"relation": "manager"}}, {"tupleToUserset": {"tupleset": {"relation": "server"}, "computedUserset": {"relation": "operator"}}}]}}
+     },
+     "metadata": {"relations": {
+       "server": {"directly_related_user_types": [{"type": "server"}]},
+       "manager": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]},
+       "viewer": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}, {"type": "user", "wildcard": {}}]},
+       "can_edit": {"directly_related_user_types": []}
+     }}},
+    {"type": "certificate", "relations": {"server": {"this": {}}}, "metadata": {"relations": {"server": {"directly_related_user_types": [{"type": "server"}]}}}}
+  ]
+}`))
+	require.NoError(t, err)
+
+	to, err := ParseModel([]byte(`{
+  "schema_version": "1.1",
+  "type_definitions": [
+    {"type": "user"},
+    {"type": "group", "relations": {"member": {"this": {}}}, "metadata": {"relations": {"member": {"directly_related_user_types": [{"type": "user"}]}}}},
+    {"type": "server",
+     "relations": {
+       "admin": {"this": {}}
+     },
+     "metadata": {"relations": {
+       "admin": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]}
+     }}},
+    {"type": "project",
+     "relations": {
+       "server": {"this": {}},
+       "manager": {"union": {"child": [{"this": {}}, {"tupleToUserset": {"tupleset": {"relation": "server"}, "computedUserset": {"relation": "admin"}}}]}},
+       "viewer": {"union": {"child": [{"this": {}}, {"computedUserset": {"relation": "manager"}}]}},
+       "can_edit": {"computedUserset": {"relation": "manager"}}
+     },
+     "metadata": {"relations": {
+       "server": {"directly_related_user_types": [{"type": "server"}]},
+       "manager": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}, {"type": "user", "wildcard": {}}]},
+       "viewer": {"directly_related_user_types": [{"type": "user"}]},
+       "can_edit": {"directly_related_user_types": []}
+     }}}
+  ]
+}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"project#manager", "server#admin", "server#operator"}, from.Grantors("project", "can_edit"))
+	require.Equal(t, []string{"project#manager", "server#admin"}, to.Grantors("project", "can_edit"))
+
+	changes := CompareModels(from, to)
+	formatted := make([]string, 0, len(changes))
+	for _, change := range changes {
+		formatted = append(formatted, change.String())
+	}
+
+	require.Equal(t, []string{
+		"breaking: certificate: Type removed",
+		"narrowing: project#can_edit: No longer granted by server#operator",
+		"widening: project#manager: Allows user:* to be written directly",
+		"widening: project#manager: Now granted by server#admin",
+		"breaking: project#viewer: No longer allows group#member, user:* to be written directly",
+		"widening: project#viewer: Now granted by project#manager, server#admin",
+		"breaking: server#operator: Relation removed",
+	}, formatted)
+
+	require.Empty(t, CompareModels(from, from))
+}
+
+func TestCompareModelsRestrictions(t *testing.T) {
+	from, err := ParseDSL([]byte(`model
+  schema 1.1
+type user
+type server
+  relations
+    define admin: [user]
+type project
+  relations
+    define server: [server]
+    define banned: [user]
+    define manager: [user]
+    define operator: [user]
+    define viewer: [user] or operator
+    define can_edit: manager or operator
+    define can_view: viewer or admin from server
+    define can_exec: (operator and manager) but not banned
+`))
+	require.NoError(t, err)
+
+	to, err := ParseDSL([]byte(`model
+  schema 1.1
+type user
+type server
+  relations
+    define admin: [user]
+type project
+  relations
+    define server: [server]
+    define banned: [user]
+    define manager: [user]
+    define operator: [user]
+    define viewer: ([user] or operator) but not banned
+    define can_edit: manager and operator
+    define can_view: admin from server or viewer
+    define can_exec: manager and operator
+`))
+	require.NoError(t, err)
+
+	require.Empty(t, from.Restrictions("project", "can_edit"))
+	require.Equal(t, []string{"project#can_edit: manager and operator"}, to.Restrictions("project", "can_edit"))
+	require.Equal(t, []string{"project#viewer: ([user] or operator) but not banned"}, to.Restrictions("project", "can_view"))
+	require.Equal(t, []string{"project#can_exec: (manager and operator) but not banned", "project#can_exec: manager and operator"}, from.Restrictions("project", "can_exec"))
+
+	// Grantors are unchanged by intersections and exclusions, so only the restrictions tell these models apart.
+	require.Equal(t, from.Grantors("project", "can_edit"), to.Grantors("project", "can_edit"))
+	require.Equal(t, from.Grantors("project", "can_view"), to.Grantors("project", "can_view"))
+
+	changes := CompareModels(from, to)
+	formatted := make([]string, 0, len(changes))
+	for _, change := range changes {
+		formatted = append(formatted, change.String())
+	}
+
+	require.Equal(t, []string{
+		"narrowing: project#can_edit: Now restricted by project#can_edit: manager and operator",
+		"widening: project#can_exec: No longer restricted by project#can_exec: (manager and operator) but not banned",
+		"narrowing: project#can_view: Now restricted by project#viewer: ([user] or operator) but not banned",
+		"narrowing: project#viewer: Now restricted by project#viewer: ([user] or operator) but not banned",
+	}, formatted)
+}
@@ -0,0 +1,399 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// differentialEngine answers Check and ListObjects queries over a tuple set.
+type differentialEngine interface {
+	load(ctx context.Context, tuples []client.ClientTupleKey) error
+	check(ctx context.Context, user string, relation string, object string) (bool, error)
+	listObjects(ctx context.Context, user string, relation string, objectType string) ([]string, error)
+}
+
+// evaluatorEngine answers queries with the in-process Evaluator.
+type evaluatorEngine struct {
+	model     *Model
+	evaluator *Evaluator
+}
+
+func (e *evaluatorEngine) load(ctx context.Context, tuples []client.ClientTupleKey) error {
+	e.evaluator = NewEvaluator(e.model, NewMemoryStore(tuples...))
+	return nil
+}
+
+func (e *evaluatorEngine) check(ctx context.Context, user string, relation string, object string) (bool, error) {
+	return e.evaluator.Check(ctx, client.ClientCheckRequest{User: user, Relation: relation, Object: object})
+}
+
+func (e *evaluatorEngine) listObjects(ctx context.Context, user string, relation string, objectType string) ([]string, error) {
+	return e.evaluator.ListObjects(ctx, client.ClientListObjectsRequest{User: user, Relation: relation, Type: objectType})
+}
+
+// openFGAEngine answers queries with an OpenFGA server. Each tuple set is loaded into a new store.
+type openFGAEngine struct {
+	fga         *client.OpenFgaClient
+	model       *Model
+	authModelID *string
+	storeID     string
+}
+
+func (e *openFGAEngine) load(ctx context.Context, tuples []client.ClientTupleKey) error {
+	if e.storeID != "" {
+		_, err := e.fga.DeleteStore(ctx).Execute()
+		if err != nil {
+			return err
+		}
+	}
+
+	createStoreResponse, err := e.fga.CreateStore(ctx).Body(client.ClientCreateStoreRequest{Name: "differential"}).Execute()
+	if err != nil {
+		return err
+	}
+
+	e.storeID = createStoreResponse.GetId()
+	e.fga.SetStoreId(e.storeID)
+
+	writeAuthorizationModelResponse, err := e.fga.WriteAuthorizationModel(ctx).Body(e.model.WriteRequest()).Execute()
+	if err != nil {
+		return err
+	}
+
+	e.authModelID = writeAuthorizationModelResponse.AuthorizationModelId
+	return writeTuplesInChunks(ctx, NewOpenFGAStore(e.fga, e.model, e.authModelID), e.model, tuples, nil)
+}
+
+func (e *openFGAEngine) check(ctx context.Context, user string, relation string, object string) (bool, error) {
+	checkResponse, err := e.fga.Check(ctx).Options(client.ClientCheckOptions{AuthorizationModelId: e.authModelID}).Body(client.ClientCheckRequest{User: user, Relation: relation, Object: object}).Execute()
+	if err != nil {
+		return false, err
+	}
+
+	return checkResponse.GetAllowed(), nil
+}
+
+func (e *openFGAEngine) listObjects(ctx context.Context, user string, relation string, objectType string) ([]string, error) {
+	listObjectsResponse, err := e.fga.ListObjects(ctx).Options(client.ClientListObjectsOptions{AuthorizationModelId: e.authModelID}).Body(client.ClientListObjectsRequest{User: user, Relation: relation, Type: objectType}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return listObjectsResponse.GetObjects(), nil
+}
+
+// differentialQuery is a Check (if object is set) or ListObjects query.
+type differentialQuery struct {
+	user       string
+	relation   string
+	object     string
+	objectType string
+}
+
+// String implements fmt.Stringer.
+func (q differentialQuery) String() string {
+	if q.object != "" {
+		return fmt.Sprintf("Check %s %s %s", q.user, q.relation, q.object)
+	}
+
+	return fmt.Sprintf("ListObjects %s %s %s", q.user, q.relation, q.objectType)
+}
+
+// run returns the result of the query as a string so that results of both kinds can be compared.
+func (q differentialQuery) run(ctx context.Context, engine differentialEngine) (string, error) {
+	if q.object != "" {
+		allowed, err := engine.check(ctx, q.user, q.relation, q.object)
+		return fmt.Sprint(allowed), err
+	}
+
+	objects, err := engine.listObjects(ctx, q.user, q.relation, q.objectType)
+	sort.Strings(objects)
+	return fmt.Sprint(objects), err
+}
+
+// differentialQueries returns a Check query for every user and entitlement of every object of the universe, and a
+// ListObjects query for every user and entitlement of every type.
+func differentialQueries(u *propertyUniverse) []differentialQuery {
+	var queries []differentialQuery
+	for _, user := range u.users {
+		for _, objectType := range u.model.Types() {
+			for _, relation := range u.model.Relations(objectType) {
+				if strings.HasPrefix(relation, "can_") {
+					queries = append(queries, differentialQuery{user: user, relation: relation, objectType: objectType})
+				}
+			}
+		}
+
+		for _, object := range u.objects {
+			objectType, _, _ := strings.Cut(object, ":")
+			for _, relation := range u.model.Relations(objectType) {
+				if strings.HasPrefix(relation, "can_") {
+					queries = append(queries, differentialQuery{user: user, relation: relation, object: object})
+				}
+			}
+		}
+	}
+
+	return queries
+}
+
+// disagreement is a query on which two engines return different results.
+type disagreement struct {
+	query    differentialQuery
+	expected string
+	actual   string
+}
+
+// compareEngines loads the tuples into both engines and returns the queries on which they disagree.
+func compareEngines(ctx context.Context, expected differentialEngine, actual differentialEngine, tuples []client.ClientTupleKey, queries []differentialQuery) ([]disagreement, error) {
+	for _, engine := range []differentialEngine{expected, actual} {
+		err := engine.load(ctx, tuples)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load tuples: %w", err)
+		}
+	}
+
+	var disagreements []disagreement
+	for _, query := range queries {
+		expectedResult, err := query.run(ctx, expected)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query, err)
+		}
+
+		actualResult, err := query.run(ctx, actual)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query, err)
+		}
+
+		if expectedResult != actualResult {
+			disagreements = append(disagreements, disagreement{query: query, expected: expectedResult, actual: actualResult})
+		}
+	}
+
+	return disagreements, nil
+}
+
+// minimiseDisagreement returns a subset of the tuples on which the engines still disagree on the query, such that
+// removing any single tuple makes them agree. It removes chunks of tuples of decreasing size (delta debugging).
+func minimiseDisagreement(ctx context.Context, expected differentialEngine, actual differentialEngine, tuples []client.ClientTupleKey, query differentialQuery) ([]client.ClientTupleKey, error) {
+	disagrees := func(tuples []client.ClientTupleKey) (bool, error) {
+		disagreements, err := compareEngines(ctx, expected, actual, tuples, []differentialQuery{query})
+		return len(disagreements) > 0, err
+	}
+
+	chunks := 2
+	for len(tuples) > 1 {
+		size := (len(tuples) + chunks - 1) / chunks
+		reduced := false
+		for start := 0; start < len(tuples); start += size {
+			end := start + size
+			if end > len(tuples) {
+				end = len(tuples)
+			}
+
+			candidate := append(append([]client.ClientTupleKey(nil), tuples[:start]...), tuples[end:]...)
+			ok, err := disagrees(candidate)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				tuples = candidate
+				reduced = true
+				if chunks > 2 {
+					chunks--
+				}
+
+				break
+			}
+		}
+
+		if !reduced {
+			if size == 1 {
+				break
+			}
+
+			chunks *= 2
+		}
+	}
+
+	return tuples, nil
+}
+
+// reportDisagreements minimises and reports the first disagreement for each query relation.
+func reportDisagreements(t *testing.T, ctx context.Context, expected differentialEngine, actual differentialEngine, description string, tuples []client.ClientTupleKey, disagreements []disagreement) {
+	reported := make(map[string]struct{})
+	for _, d := range disagreements {
+		key := d.query.relation + " " + d.query.objectType
+		_, ok := reported[key]
+		if ok {
+			continue
+		}
+
+		reported[key] = struct{}{}
+		minimal, err := minimiseDisagreement(ctx, expected, actual, tuples, d.query)
+		require.NoError(t, err)
+
+		formatted := make([]string, 0, len(minimal))
+		for _, tuple := range minimal {
+			formatted = append(formatted, formatTuple(tuple))
+		}
+
+		t.Errorf("%s: %s: Expected %s, got %s\nMinimal tuples:\n  %s", description, d.query, d.expected, d.actual, strings.Join(formatted, "\n  "))
+	}
+}
+
+func TestDifferentialMinimise(t *testing.T) {
+	ctx := context.Background()
+	u := newPropertyUniverse(t)
+
+	// A broken engine that forgets that project operators can exec into instances.
+	broken, err := DefaultModel()
+	require.NoError(t, err)
+	for _, typeDefinition := range broken.TypeDefinitions {
+		if typeDefinition.Type == string(ObjectTypeInstance) {
+			relation := "user"
+			(*typeDefinition.Relations)["can_exec"] = openfgaSDK.Userset{ComputedUserset: &openfgaSDK.ObjectRelation{Relation: &relation}}
+		}
+	}
+
+	expected := &evaluatorEngine{model: u.model}
+	actual := &evaluatorEngine{model: broken}
+	queries := differentialQueries(u)
+
+	for seed := int64(0); seed < propertySeeds; seed++ {
+		tuples := u.randomTuples(rand.New(rand.NewSource(seed)), 15)
+		disagreements, err := compareEngines(ctx, expected, actual, tuples, queries)
+		require.NoError(t, err)
+		if len(disagreements) == 0 {
+			continue
+		}
+
+		query := disagreements[0].query
+		require.Equal(t, "can_exec", query.relation)
+
+		minimal, err := minimiseDisagreement(ctx, expected, actual, tuples, query)
+		require.NoError(t, err)
+		require.Less(t, len(minimal), len(tuples))
+
+		// The result still disagrees and every tuple is needed.
+		disagreements, err = compareEngines(ctx, expected, actual, minimal, []differentialQuery{query})
+		require.NoError(t, err)
+		require.Len(t, disagreements, 1)
+		for i := range minimal {
+			without := append(append([]client.ClientTupleKey(nil), minimal[:i]...), minimal[i+1:]...)
+			disagreements, err = compareEngines(ctx, expected, actual, without, []differentialQuery{query})
+			require.NoError(t, err)
+			require.Empty(t, disagreements, "Seed %d: %q is not needed", seed, formatTuple(minimal[i]))
+		}
+
+		return
+	}
+
+	t.Fatal("No disagreement found")
+}
+
+// startOpenFGA starts the OpenFGA binary given by OPENFGA_BIN or found in PATH with an in-memory datastore, and
+// returns a client for it. The test is skipped if there is no binary.
+func startOpenFGA(t *testing.T) *client.OpenFgaClient {
+	binary := os.Getenv("OPENFGA_BIN")
+	if binary == "" {
+		var err error
+		binary, err = exec.LookPath("openfga")
+		if err != nil {
+			t.Skip("No openfga binary in PATH and OPENFGA_BIN is not set")
+		}
+	}
+
+	freePort := func() int {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+		return listener.Addr().(*net.TCPAddr).Port
+	}
+
+	httpAddr := fmt.Sprintf("127.0.0.1:%d", freePort())
+	cmd := exec.Command(binary, "run",
+		"--datastore-engine", "memory",
+		"--http-addr", httpAddr,
+		"--grpc-addr", fmt.Sprintf("127.0.0.1:%d", freePort()),
+		"--playground-enabled=false",
+		"--metrics-enabled=false",
+		"--log-level", "warn",
+	)
+
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		resp, err := http.Get("http://" + httpAddr + "/healthz")
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				break
+			}
+		}
+
+		require.True(t, time.Now().Before(deadline), "OpenFGA did not become healthy: %v", err)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{ApiScheme: "http", ApiHost: httpAddr})
+	require.NoError(t, err)
+	return fga
+}
+
+func TestDifferentialOpenFGA(t *testing.T) {
+	ctx := context.Background()
+	fga := startOpenFGA(t)
+	u := newPropertyUniverse(t)
+	expected := &openFGAEngine{fga: fga, model: u.model}
+	actual := &evaluatorEngine{model: u.model}
+	queries := differentialQueries(u)
+
+	// A fixture granting each role of the model on one object, then random tuple sets.
+	matrix, err := NewRoleMatrix(ctx, u.model)
+	require.NoError(t, err)
+
+	fixture := append([]client.ClientTupleKey(nil), u.hierarchy...)
+	for i, role := range matrix.Roles {
+		roleType, relation, _ := strings.Cut(role, "#")
+		for _, object := range u.objects {
+			if strings.HasPrefix(object, roleType+":") {
+				fixture = append(fixture, client.ClientTupleKey{User: u.users[i%len(u.users)], Relation: relation, Object: object})
+				break
+			}
+		}
+	}
+
+	descriptions := []string{"Fixture"}
+	tupleSets := [][]client.ClientTupleKey{fixture}
+	for seed := int64(0); seed < 10; seed++ {
+		descriptions = append(descriptions, fmt.Sprintf("Seed %d", seed))
+		tupleSets = append(tupleSets, u.randomTuples(rand.New(rand.NewSource(seed)), 20))
+	}
+
+	for i, tuples := range tupleSets {
+		disagreements, err := compareEngines(ctx, expected, actual, tuples, queries)
+		require.NoError(t, err)
+		reportDisagreements(t, ctx, expected, actual, descriptions[i], tuples, disagreements)
+	}
+}
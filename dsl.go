@@ -0,0 +1,433 @@
+package openfga
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+)
+
+// Position is a position in a model written in the OpenFGA DSL. Lines and columns start at 1.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// String implements fmt.Stringer.
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// IsValid returns true if the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// DSLError is an error at a position of a model written in the DSL.
+type DSLError struct {
+	Position Position
+	Message  string
+}
+
+// Error implements error.
+func (e *DSLError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
+
+// ParseDSL parses an authorization model written in the OpenFGA DSL (schema 1.1), such as lxd.openfga. It produces
+// the same type definitions as `make update-openfga`, and records the position of each type and relation (see
+// Model.Position).
+//
+// Only the constructs used by lxd.openfga are supported: directly related user types (`[user, group#member,
+// user:*]`), computed relations, `<relation> from <tupleset>`, `or`, `and`, `but not` and parentheses. `or` and `and`
+// cannot be mixed without parentheses. Lines starting with `#` are comments.
+func ParseDSL(data []byte) (*Model, error) {
+	p := &dslParser{positions: make(map[string]Position)}
+	err := p.parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := NewModel(p.schemaVersion, p.typeDefinitions)
+	if err != nil {
+		return nil, err
+	}
+
+	model.positions = p.positions
+	return model, nil
+}
+
+// Position returns the position of the definition of a type (if relation is empty) or relation in the DSL. It is only
+// known for models parsed with ParseDSL.
+func (m *Model) Position(objectType string, relation string) (Position, bool) {
+	key := objectType
+	if relation != "" {
+		key += "#" + relation
+	}
+
+	position, ok := m.positions[key]
+	return position, ok
+}
+
+// dslParser parses a model line by line.
+type dslParser struct {
+	schemaVersion   string
+	typeDefinitions []openfgaSDK.TypeDefinition
+	positions       map[string]Position
+
+	// current is the type definition being parsed.
+	current *openfgaSDK.TypeDefinition
+
+	// inRelations is true after the `relations` line of the current type.
+	inRelations bool
+}
+
+// parse parses the lines of the model.
+func (p *dslParser) parse(source string) error {
+	seenModel := false
+	for i, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		position := Position{Line: i + 1, Column: strings.Index(line, trimmed) + 1}
+		fields := strings.Fields(trimmed)
+		switch {
+		case fields[0] == "model" && len(fields) == 1 && !seenModel:
+			seenModel = true
+		case fields[0] == "schema" && seenModel && p.schemaVersion == "" && p.current == nil:
+			if len(fields) != 2 || fields[1] != "1.1" {
+				return &DSLError{Position: position, Message: fmt.Sprintf("Unsupported schema %q", strings.Join(fields[1:], " "))}
+			}
+
+			p.schemaVersion = fields[1]
+		case fields[0] == "type" && p.schemaVersion != "":
+			if len(fields) != 2 || !isDSLIdentifier(fields[1]) {
+				return &DSLError{Position: position, Message: "Type must be of the form type <name>"}
+			}
+
+			_, ok := p.positions[fields[1]]
+			if ok {
+				return &DSLError{Position: position, Message: fmt.Sprintf("Duplicate type %q", fields[1])}
+			}
+
+			p.typeDefinitions = append(p.typeDefinitions, openfgaSDK.TypeDefinition{Type: fields[1], Relations: &map[string]openfgaSDK.Userset{}})
+			p.current = &p.typeDefinitions[len(p.typeDefinitions)-1]
+			p.inRelations = false
+			p.positions[fields[1]] = position
+		case fields[0] == "relations" && len(fields) == 1 && p.current != nil && !p.inRelations:
+			p.inRelations = true
+		case fields[0] == "define" && p.inRelations:
+			err := p.parseDefine(trimmed, position)
+			if err != nil {
+				return err
+			}
+
+		default:
+			return &DSLError{Position: position, Message: fmt.Sprintf("Unexpected %q", fields[0])}
+		}
+	}
+
+	if p.schemaVersion == "" {
+		return &DSLError{Position: Position{Line: 1, Column: 1}, Message: "Missing model and schema declaration"}
+	}
+
+	return nil
+}
+
+// parseDefine parses a `define <relation>: <rewrite>` line of the current type.
+func (p *dslParser) parseDefine(line string, position Position) error {
+	name, rewrite, ok := strings.Cut(strings.TrimPrefix(line, "define"), ":")
+	name = strings.TrimSpace(name)
+	if !ok || !isDSLIdentifier(name) {
+		return &DSLError{Position: position, Message: "Relation must be of the form define <name>: <rewrite>"}
+	}
+
+	key := p.current.Type + "#" + name
+	_, ok = p.positions[key]
+	if ok {
+		return &DSLError{Position: position, Message: fmt.Sprintf("Duplicate relation %q on type %q", name, p.current.Type)}
+	}
+
+	// Column of the rewrite, for errors within it.
+	offset := position.Column + strings.Index(line, ":") + 1
+	tokens, err := tokenizeDSL(rewrite, position.Line, offset)
+	if err != nil {
+		return err
+	}
+
+	e := &dslExpression{tokens: tokens, end: Position{Line: position.Line, Column: offset + len(rewrite)}}
+	userset, err := e.parseRewrite()
+	if err != nil {
+		return err
+	}
+
+	if e.pos < len(e.tokens) {
+		return e.errorf("Unexpected %q", e.tokens[e.pos].text)
+	}
+
+	relatedUserTypes := e.relatedUserTypes
+	if relatedUserTypes == nil {
+		relatedUserTypes = []openfgaSDK.RelationReference{}
+	}
+
+	if p.current.Metadata == nil {
+		p.current.Metadata = &openfgaSDK.Metadata{Relations: &map[string]openfgaSDK.RelationMetadata{}}
+	}
+
+	(*p.current.Relations)[name] = userset
+	(*p.current.Metadata.Relations)[name] = openfgaSDK.RelationMetadata{DirectlyRelatedUserTypes: &relatedUserTypes}
+	p.positions[key] = position
+	return nil
+}
+
+// dslToken is a token of a rewrite.
+type dslToken struct {
+	text     string
+	position Position
+}
+
+// tokenizeDSL splits a rewrite into identifiers and the punctuation `[`, `]`, `(`, `)` and `,`.
+func tokenizeDSL(rewrite string, line int, column int) ([]dslToken, error) {
+	var tokens []dslToken
+	for i := 0; i < len(rewrite); {
+		c := rune(rewrite[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("[](),", c):
+			tokens = append(tokens, dslToken{text: string(c), position: Position{Line: line, Column: column + i}})
+			i++
+		case isDSLIdentifierRune(c) || c == '*':
+			start := i
+			for i < len(rewrite) && (isDSLIdentifierRune(rune(rewrite[i])) || strings.ContainsRune(":#*", rune(rewrite[i]))) {
+				i++
+			}
+
+			tokens = append(tokens, dslToken{text: rewrite[start:i], position: Position{Line: line, Column: column + start}})
+		default:
+			return nil, &DSLError{Position: Position{Line: line, Column: column + i}, Message: fmt.Sprintf("Unexpected %q", c)}
+		}
+	}
+
+	return tokens, nil
+}
+
+// dslExpression parses the tokens of a rewrite.
+type dslExpression struct {
+	tokens []dslToken
+	pos    int
+
+	// end is the position after the last token.
+	end Position
+
+	// relatedUserTypes are the user types of the direct assignment, if any.
+	relatedUserTypes []openfgaSDK.RelationReference
+}
+
+// errorf returns an error at the current token.
+func (e *dslExpression) errorf(format string, args ...any) error {
+	position := e.end
+	if e.pos < len(e.tokens) {
+		position = e.tokens[e.pos].position
+	}
+
+	return &DSLError{Position: position, Message: fmt.Sprintf(format, args...)}
+}
+
+// peek returns the current token, or an empty string at the end.
+func (e *dslExpression) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos].text
+	}
+
+	return ""
+}
+
+// expect consumes the given token.
+func (e *dslExpression) expect(text string) error {
+	if e.peek() != text {
+		if e.pos == len(e.tokens) {
+			return e.errorf("Expected %q", text)
+		}
+
+		return e.errorf("Expected %q, got %q", text, e.peek())
+	}
+
+	e.pos++
+	return nil
+}
+
+// parseRewrite parses `<operand> [(or|and) <operand>]... [but not <operand>]`.
+func (e *dslExpression) parseRewrite() (openfgaSDK.Userset, error) {
+	operand, err := e.parseOperand()
+	if err != nil {
+		return openfgaSDK.Userset{}, err
+	}
+
+	children := []openfgaSDK.Userset{operand}
+	operator := ""
+	for e.peek() == "or" || e.peek() == "and" {
+		if operator != "" && e.peek() != operator {
+			return openfgaSDK.Userset{}, e.errorf("Cannot mix %q and %q without parentheses", operator, e.peek())
+		}
+
+		operator = e.peek()
+		e.pos++
+		operand, err := e.parseOperand()
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		children = append(children, operand)
+	}
+
+	userset := children[0]
+	switch operator {
+	case "or":
+		userset = openfgaSDK.Userset{Union: &openfgaSDK.Usersets{Child: &children}}
+	case "and":
+		userset = openfgaSDK.Userset{Intersection: &openfgaSDK.Usersets{Child: &children}}
+	}
+
+	if e.peek() == "but" {
+		e.pos++
+		err = e.expect("not")
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		subtract, err := e.parseOperand()
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		userset = openfgaSDK.Userset{Difference: &openfgaSDK.Difference{Base: userset, Subtract: subtract}}
+	}
+
+	return userset, nil
+}
+
+// parseOperand parses `[<user types>]`, `<relation>`, `<relation> from <tupleset>` or a parenthesised rewrite.
+func (e *dslExpression) parseOperand() (openfgaSDK.Userset, error) {
+	switch token := e.peek(); {
+	case token == "[":
+		if e.relatedUserTypes != nil {
+			return openfgaSDK.Userset{}, e.errorf("Directly related user types can only be given once")
+		}
+
+		e.pos++
+		e.relatedUserTypes = []openfgaSDK.RelationReference{}
+		for {
+			reference, err := e.parseRelationReference()
+			if err != nil {
+				return openfgaSDK.Userset{}, err
+			}
+
+			e.relatedUserTypes = append(e.relatedUserTypes, reference)
+			if e.peek() != "," {
+				break
+			}
+
+			e.pos++
+		}
+
+		err := e.expect("]")
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		return openfgaSDK.Userset{This: &map[string]any{}}, nil
+	case token == "(":
+		e.pos++
+		userset, err := e.parseRewrite()
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		err = e.expect(")")
+		if err != nil {
+			return openfgaSDK.Userset{}, err
+		}
+
+		return userset, nil
+	case isDSLIdentifier(token) && !isDSLKeyword(token):
+		e.pos++
+		if e.peek() != "from" {
+			return openfgaSDK.Userset{ComputedUserset: dslObjectRelation(token)}, nil
+		}
+
+		e.pos++
+		tupleset := e.peek()
+		if !isDSLIdentifier(tupleset) || isDSLKeyword(tupleset) {
+			return openfgaSDK.Userset{}, e.errorf("Expected a relation after \"from\"")
+		}
+
+		e.pos++
+		return openfgaSDK.Userset{TupleToUserset: &openfgaSDK.TupleToUserset{Tupleset: dslObjectRelation(tupleset), ComputedUserset: dslObjectRelation(token)}}, nil
+	case token == "":
+		return openfgaSDK.Userset{}, e.errorf("Expected a rewrite")
+	}
+
+	return openfgaSDK.Userset{}, e.errorf("Unexpected %q", e.peek())
+}
+
+// parseRelationReference parses a user type (`user`, `user:*` or `group#member`).
+func (e *dslExpression) parseRelationReference() (openfgaSDK.RelationReference, error) {
+	token := e.peek()
+	name, wildcard, isWildcard := strings.Cut(token, ":")
+	name, relation, isUserset := strings.Cut(name, "#")
+	if !isDSLIdentifier(name) || (isWildcard && (wildcard != "*" || isUserset)) || (isUserset && !isDSLIdentifier(relation)) {
+		return openfgaSDK.RelationReference{}, e.errorf("Invalid user type %q", token)
+	}
+
+	e.pos++
+	reference := openfgaSDK.RelationReference{Type: name}
+	if isUserset {
+		reference.Relation = &relation
+	}
+
+	if isWildcard {
+		reference.Wildcard = &map[string]any{}
+	}
+
+	return reference, nil
+}
+
+// dslObjectRelation returns the object relation of a computed relation or tupleset as written by the transformer.
+func dslObjectRelation(relation string) *openfgaSDK.ObjectRelation {
+	object := ""
+	return &openfgaSDK.ObjectRelation{Object: &object, Relation: &relation}
+}
+
+// isDSLKeyword returns true for the operators of the DSL.
+func isDSLKeyword(s string) bool {
+	switch s {
+	case "or", "and", "but", "not", "from":
+		return true
+	}
+
+	return false
+}
+
+// isDSLIdentifier returns true if s is a valid type or relation name.
+func isDSLIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !isDSLIdentifierRune(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isDSLIdentifierRune returns true if c may appear in a type or relation name.
+func isDSLIdentifierRune(c rune) bool {
+	return c == '_' || c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
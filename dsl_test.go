@@ -0,0 +1,147 @@
+package openfga
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSL(t *testing.T) {
+	model, err := ParseDSL([]byte(`model
+  schema 1.1
+type user
+# Groups of users.
+type group
+  relations
+    define member: [user]
+type server
+  relations
+    define admin: [user, group#member]
+    define user: [user:*]
+    define can_view: user or admin
+type project
+  relations
+    define server: [server]
+    define owner: [user] but not banned
+    define banned: [user]
+    define can_edit: (owner and admin from server) or [user]
+`))
+	require.NoError(t, err)
+
+	expected, err := ParseModel([]byte(`{
+  "schema_version": "1.1",
+  "type_definitions": [
+    {"type": "user", "relations": {}},
+    {"type": "group", "relations": {"member": {"this": {}}}, "metadata": {"relations": {"member": {"directly_related_user_types": [{"type": "user"}]}}}},
+    {"type": "server",
+     "relations": {
+       "admin": {"this": {}},
+       "user": {"this": {}},
+       "can_view": {"union": {"child": [{"computedUserset": {"object": "", "relation": "user"}}, {"computedUserset": {"object": "", "relation": "admin"}}]}}
+     },
+     "metadata": {"relations": {
+       "admin": {"directly_related_user_types": [{"type": "user"}, {"type": "group", "relation": "member"}]},
+       "user": {"directly_related_user_types": [{"type": "user", "wildcard": {}}]},
+       "can_view": {"directly_related_user_types": []}
+     }}},
+    {"type": "project",
+     "relations": {
+       "server": {"this": {}},
+       "owner": {"difference": {"base": {"this": {}}, "subtract": {"computedUserset": {"object": "", "relation": "banned"}}}},
+       "banned": {"this": {}},
+       "can_edit": {"union": {"child": [
+         {"intersection": {"child": [{"computedUserset": {"object": "", "relation": "owner"}}, {"tupleToUserset": {"tupleset": {"object": "", "relation": "server"}, "computedUserset": {"object": "", "relation": "admin"}}}]}},
+         {"this": {}}
+       ]}}
+     },
+     "metadata": {"relations": {
+       "server": {"directly_related_user_types": [{"type": "server"}]},
+       "owner": {"directly_related_user_types": [{"type": "user"}]},
+       "banned": {"directly_related_user_types": [{"type": "user"}]},
+       "can_edit": {"directly_related_user_types": [{"type": "user"}]}
+     }}}
+  ]
+}`))
+	require.NoError(t, err)
+	require.True(t, model.Equal(expected))
+
+	position, ok := model.Position("project", "can_edit")
+	require.True(t, ok)
+	require.Equal(t, Position{Line: 18, Column: 5}, position)
+
+	position, ok = model.Position("group", "")
+	require.True(t, ok)
+	require.Equal(t, Position{Line: 5, Column: 1}, position)
+
+	_, ok = expected.Position("group", "")
+	require.False(t, ok)
+
+	data, err := os.ReadFile("lxd.openfga")
+	require.NoError(t, err)
+	_, err = ParseDSL(data)
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		dsl         string
+		expectedErr string
+	}{
+		{
+			description: "Missing schema",
+			dsl:         "type user\n",
+			expectedErr: `1:1: Unexpected "type"`,
+		},
+		{
+			description: "Unsupported schema",
+			dsl:         "model\n  schema 1.0\n",
+			expectedErr: `2:3: Unsupported schema "1.0"`,
+		},
+		{
+			description: "Duplicate relation",
+			dsl:         "model\n  schema 1.1\ntype user\n  relations\n    define a: [user]\n    define a: [user]\n",
+			expectedErr: `6:5: Duplicate relation "a" on type "user"`,
+		},
+		{
+			description: "Mixed operators",
+			dsl:         "model\n  schema 1.1\ntype user\n  relations\n    define a: [user]\n    define b: a or a and a\n",
+			expectedErr: `6:22: Cannot mix "or" and "and" without parentheses`,
+		},
+		{
+			description: "Invalid user type",
+			dsl:         "model\n  schema 1.1\ntype user\n  relations\n    define a: [user:foo]\n",
+			expectedErr: `5:16: Invalid user type "user:foo"`,
+		},
+		{
+			description: "Unterminated user types",
+			dsl:         "model\n  schema 1.1\ntype user\n  relations\n    define a: [user\n",
+			expectedErr: `5:20: Expected "]"`,
+		},
+		{
+			description: "Missing tupleset",
+			dsl:         "model\n  schema 1.1\ntype user\n  relations\n    define a: b from\n",
+			expectedErr: `5:21: Expected a relation after "from"`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		_, err := ParseDSL([]byte(test.dsl))
+		require.EqualError(t, err, test.expectedErr)
+	}
+}
+
+func TestDefaultModelMatchesDSL(t *testing.T) {
+	data, err := os.ReadFile("lxd.openfga")
+	require.NoError(t, err)
+
+	model, err := ParseDSL(data)
+	require.NoError(t, err)
+
+	defaultModel, err := DefaultModel()
+	require.NoError(t, err)
+
+	// model.go is generated from lxd.openfga by `make update-openfga`.
+	require.True(t, model.Equal(defaultModel), "model.go is out of date, run `make update-openfga`")
+}
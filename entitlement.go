@@ -0,0 +1,84 @@
+package openfga
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entitlement is a relation that LXD checks before performing an action, e.g. `can_exec` on an instance.
+type Entitlement string
+
+// Entitlements defined in lxd.openfga.
+const (
+	EntitlementCanEdit                       Entitlement = "can_edit"
+	EntitlementCanView                       Entitlement = "can_view"
+	EntitlementCanEditServer                 Entitlement = "can_edit_server"
+	EntitlementCanViewServer                 Entitlement = "can_view_server"
+	EntitlementCanCreateStoragePool          Entitlement = "can_create_storage_pool"
+	EntitlementCanCreateProject              Entitlement = "can_create_project"
+	EntitlementCanViewResources              Entitlement = "can_view_resources"
+	EntitlementCanCreateCertificate          Entitlement = "can_create_certificate"
+	EntitlementCanEditCluster                Entitlement = "can_edit_cluster"
+	EntitlementCanViewCluster                Entitlement = "can_view_cluster"
+	EntitlementCanCreateClusterMember        Entitlement = "can_create_cluster_member"
+	EntitlementCanCreateClusterGroup         Entitlement = "can_create_cluster_group"
+	EntitlementCanViewMetrics                Entitlement = "can_view_metrics"
+	EntitlementCanTarget                     Entitlement = "can_target"
+	EntitlementCanCreateImages               Entitlement = "can_create_images"
+	EntitlementCanCreateInstances            Entitlement = "can_create_instances"
+	EntitlementCanCreateNetworks             Entitlement = "can_create_networks"
+	EntitlementCanCreateNetworkACLs          Entitlement = "can_create_network_acls"
+	EntitlementCanCreateNetworkZones         Entitlement = "can_create_network_zones"
+	EntitlementCanCreateNetworkForwards      Entitlement = "can_create_network_forwards"
+	EntitlementCanCreateNetworkLoadBalancers Entitlement = "can_create_network_load_balancers"
+	EntitlementCanCreateNetworkPeers         Entitlement = "can_create_network_peers"
+	EntitlementCanCreateProfiles             Entitlement = "can_create_profiles"
+	EntitlementCanCreateStoragePoolVolumes   Entitlement = "can_create_storage_pool_volumes"
+	EntitlementCanCreateStorageBuckets       Entitlement = "can_create_storage_buckets"
+	EntitlementCanUpdateState                Entitlement = "can_update_state"
+	EntitlementCanManageSnapshots            Entitlement = "can_manage_snapshots"
+	EntitlementCanManageBackups              Entitlement = "can_manage_backups"
+	EntitlementCanConnectSFTP                Entitlement = "can_connect_sftp"
+	EntitlementCanAccessFiles                Entitlement = "can_access_files"
+	EntitlementCanAccessConsole              Entitlement = "can_access_console"
+	EntitlementCanExec                       Entitlement = "can_exec"
+)
+
+// Role is a relation that is granted to users and groups and implies a set of entitlements.
+type Role string
+
+// Roles defined in lxd.openfga.
+const (
+	RoleAdmin    Role = "admin"
+	RoleOperator Role = "operator"
+	RoleViewer   Role = "viewer"
+	RoleManager  Role = "manager"
+	RoleUser     Role = "user"
+	RoleMember   Role = "member"
+)
+
+// UserSubject returns the OpenFGA user for the named LXD user.
+func UserSubject(name string) string {
+	return fmt.Sprintf("%s:%s", ObjectTypeUser, name)
+}
+
+// GroupSubject returns the OpenFGA userset for the members of the named group.
+func GroupSubject(name string) string {
+	return fmt.Sprintf("%s:%s#%s", ObjectTypeGroup, name, RoleMember)
+}
+
+// ParseSubject parses the user of a check or grant. Users are given as `user:<name>` and groups as `group:<name>`,
+// which is expanded to the group's members. Any other userset must be given in full (e.g. `project:default#operator`).
+func ParseSubject(subject string) (string, error) {
+	object, relation, isUserset := strings.Cut(subject, "#")
+	subjectType, name, ok := strings.Cut(object, ":")
+	if !ok || subjectType == "" || name == "" || (isUserset && relation == "") {
+		return "", fmt.Errorf("Invalid subject %q: Must be of the form <type>:<name> or <type>:<name>#<relation>", subject)
+	}
+
+	if ObjectType(subjectType) == ObjectTypeGroup && !isUserset {
+		return GroupSubject(name), nil
+	}
+
+	return subject, nil
+}
@@ -0,0 +1,194 @@
+package openfga
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrivilegeGrant declares that an entitlement lets its holder obtain a role outside of the model, for example because
+// it allows writing tuples or editing configuration that bypasses authorization.
+type PrivilegeGrant struct {
+	// Entitlement is the relation that must be held, e.g. `project#can_edit`.
+	Entitlement string
+
+	// Role is the relation that can be obtained with it, e.g. `server#admin`.
+	Role string
+
+	// Reason explains how the role is obtained.
+	Reason string
+}
+
+// LXDPrivilegeGrants are the entitlements of the LXD model that can be used to obtain a stronger role.
+var LXDPrivilegeGrants = []PrivilegeGrant{
+	{
+		Entitlement: "server#can_edit_server",
+		Role:        "server#admin",
+		Reason:      "The server configuration includes the OpenFGA connection, so editing it replaces the authorization model",
+	},
+	{
+		Entitlement: "server#can_create_certificate",
+		Role:        "server#admin",
+		Reason:      "A client certificate that is not restricted has full access to the server",
+	},
+	{
+		Entitlement: "project#can_edit",
+		Role:        "server#admin",
+		Reason:      "Lifting the project restrictions allows privileged instances and host devices, which give root access to the host",
+	},
+}
+
+// Escalation is a path by which a role obtains a stronger role through a PrivilegeGrant.
+type Escalation struct {
+	// Path lists the relations from the analysed role to the entitlement of the grant, followed by the role obtained.
+	// For an escalation that builds on an earlier one, the path starts with the path of the earlier escalation.
+	Path []string
+
+	// Grant is the grant that was used.
+	Grant PrivilegeGrant
+}
+
+// String implements fmt.Stringer.
+func (e Escalation) String() string {
+	return fmt.Sprintf("%s (%s)", strings.Join(e.Path, " -> "), e.Grant.Reason)
+}
+
+// PrivilegeReport is the result of AnalyzePrivileges.
+type PrivilegeReport struct {
+	// Role is the analysed role.
+	Role string
+
+	// Reaches lists the relations granted by the role through the model, sorted.
+	Reaches []string
+
+	// Escalations lists the paths by which the role obtains a stronger role.
+	Escalations []Escalation
+
+	// Effective lists the relations granted by the role including all escalations, sorted.
+	Effective []string
+}
+
+// AnalyzePrivileges computes the relations that a role (e.g. `project#operator`) reaches in the model, and the
+// escalations by which it obtains a stronger role through the given grants. A role reaches a relation if holding the
+// role on an object grants the relation on the same object or on an object below it (e.g. `instance#can_exec` for
+// `project#operator`). A grant is an escalation if the role reaches its entitlement and the granted role reaches a
+// relation that the role does not. Escalations are followed until no grant adds a relation.
+//
+// The analysis is static and errs on the side of reporting too much: relations combined in an intersection are treated
+// as if each of them grants the relation, and relations only subtracted in an exclusion are ignored.
+func AnalyzePrivileges(model *Model, role string, grants []PrivilegeGrant) (*PrivilegeReport, error) {
+	relations := []string{role}
+	for _, grant := range grants {
+		relations = append(relations, grant.Entitlement, grant.Role)
+	}
+
+	for _, relation := range relations {
+		objectType, name, ok := strings.Cut(relation, "#")
+		if !ok || !model.HasRelation(objectType, name) {
+			return nil, fmt.Errorf("Relation %q not found", relation)
+		}
+	}
+
+	implications := model.implications()
+	reaches, paths := reach(implications, role)
+	report := &PrivilegeReport{Role: role, Reaches: sortedKeys(reaches)}
+
+	effective := make(map[string]struct{}, len(reaches))
+	for relation := range reaches {
+		effective[relation] = struct{}{}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, grant := range grants {
+			path, ok := paths[grant.Entitlement]
+			if !ok {
+				continue
+			}
+
+			granted, grantedPaths := reach(implications, grant.Role)
+			if isSubset(granted, effective) {
+				continue
+			}
+
+			escalation := Escalation{Path: append(append([]string(nil), path...), grant.Role), Grant: grant}
+			report.Escalations = append(report.Escalations, escalation)
+			for relation := range granted {
+				effective[relation] = struct{}{}
+				_, ok := paths[relation]
+				if !ok {
+					paths[relation] = append(append([]string(nil), escalation.Path...), grantedPaths[relation][1:]...)
+				}
+			}
+
+			changed = true
+		}
+	}
+
+	report.Effective = sortedKeys(effective)
+	return report, nil
+}
+
+// implications returns the relations (`type#relation`) directly implied by each relation of the model, sorted. A
+// relation implies the relations on the same type that are computed from it, and the relations on child types that
+// are computed from it through the parent relation. These are the edges of the model graph that are not direct.
+func (m *Model) implications() map[string][]string {
+	implications := make(map[string][]string)
+	for _, edge := range m.graphEdges() {
+		if edge.label == graphEdgeComputed || edge.label == graphEdgeFromParent {
+			implications[edge.from] = append(implications[edge.from], edge.to)
+		}
+	}
+
+	for from := range implications {
+		sort.Strings(implications[from])
+	}
+
+	return implications
+}
+
+// reach returns the relations reachable from the given relation, including itself, and the shortest path to each.
+func reach(implications map[string][]string, from string) (map[string]struct{}, map[string][]string) {
+	reached := map[string]struct{}{from: {}}
+	paths := map[string][]string{from: {from}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		relation := queue[0]
+		queue = queue[1:]
+		for _, implied := range implications[relation] {
+			_, ok := reached[implied]
+			if ok {
+				continue
+			}
+
+			reached[implied] = struct{}{}
+			paths[implied] = append(append([]string(nil), paths[relation]...), implied)
+			queue = append(queue, implied)
+		}
+	}
+
+	return reached, paths
+}
+
+// isSubset returns true if every element of a is in b.
+func isSubset(a map[string]struct{}, b map[string]struct{}) bool {
+	for element := range a {
+		_, ok := b[element]
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortedKeys returns the sorted keys of a map, such as the elements of a set.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
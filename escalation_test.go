@@ -0,0 +1,98 @@
+package openfga
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePrivileges(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description     string
+		role            string
+		grants          []PrivilegeGrant
+		neverReaches    []string
+		escalationPaths [][]string
+	}{
+		{
+			description:  "Project operator cannot edit the project or the server",
+			role:         "project#operator",
+			grants:       LXDPrivilegeGrants,
+			neverReaches: []string{"server#can_edit_server", "server#admin", "project#can_edit", "project#manager", "storage_pool#can_edit"},
+		},
+		{
+			description:  "Server viewer cannot edit anything",
+			role:         "server#viewer",
+			grants:       LXDPrivilegeGrants,
+			neverReaches: []string{"server#can_edit_server", "project#can_view", "storage_pool#can_edit", "certificate#can_edit"},
+		},
+		{
+			description:  "Instance user cannot edit the instance",
+			role:         "instance#user",
+			grants:       LXDPrivilegeGrants,
+			neverReaches: []string{"instance#can_edit", "instance#can_manage_backups", "project#can_view"},
+		},
+		{
+			description:     "Project manager can lift the project restrictions",
+			role:            "project#manager",
+			grants:          LXDPrivilegeGrants,
+			escalationPaths: [][]string{{"project#manager", "project#can_edit", "server#admin"}},
+		},
+		{
+			description:     "Server operator manages every project",
+			role:            "server#operator",
+			grants:          LXDPrivilegeGrants,
+			escalationPaths: [][]string{{"server#operator", "project#manager", "project#can_edit", "server#admin"}},
+		},
+		{
+			description:  "Server admin has nothing to gain",
+			role:         "server#admin",
+			grants:       LXDPrivilegeGrants,
+			neverReaches: []string{},
+		},
+		{
+			description: "Instance manager that can manage the permissions of the project",
+			role:        "instance#manager",
+			grants:      append([]PrivilegeGrant{{Entitlement: "instance#can_edit", Role: "project#manager", Reason: "Tuple admin"}}, LXDPrivilegeGrants...),
+			escalationPaths: [][]string{
+				{"instance#manager", "instance#can_edit", "project#manager"},
+				{"instance#manager", "instance#can_edit", "project#manager", "project#can_edit", "server#admin"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		report, err := AnalyzePrivileges(model, test.role, test.grants)
+		require.NoError(t, err)
+		require.Contains(t, report.Reaches, test.role)
+		require.Subset(t, report.Effective, report.Reaches)
+
+		for _, relation := range test.neverReaches {
+			require.NotContains(t, report.Effective, relation)
+		}
+
+		paths := make([][]string, 0, len(report.Escalations))
+		for _, escalation := range report.Escalations {
+			paths = append(paths, escalation.Path)
+		}
+
+		require.ElementsMatch(t, test.escalationPaths, paths)
+	}
+
+	report, err := AnalyzePrivileges(model, "project#operator", nil)
+	require.NoError(t, err)
+	require.Contains(t, report.Reaches, "instance#can_exec")
+	require.Contains(t, report.Reaches, "project#can_create_instances")
+	require.Equal(t, report.Reaches, report.Effective)
+
+	_, err = AnalyzePrivileges(model, "project#owner", nil)
+	require.EqualError(t, err, `Relation "project#owner" not found`)
+
+	_, err = AnalyzePrivileges(model, "project#operator", []PrivilegeGrant{{Entitlement: "server#can_fly", Role: "server#admin"}})
+	require.EqualError(t, err, `Relation "server#can_fly" not found`)
+}
@@ -0,0 +1,369 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+)
+
+// maxResolutionDepth is the maximum number of nested rewrites followed by a check. It matches the default of the
+// OpenFGA server.
+const maxResolutionDepth = 25
+
+// Evaluator answers Check and ListObjects queries in process, using the rewrite rules of a Model and the tuples of a
+// MemoryStore. It follows the semantics of the OpenFGA server so that it can be used to reason about the model without
+// a network round trip.
+type Evaluator struct {
+	model *Model
+	store *MemoryStore
+}
+
+// NewEvaluator returns an Evaluator for the given model and tuples.
+func NewEvaluator(model *Model, store *MemoryStore) *Evaluator {
+	return &Evaluator{
+		model: model,
+		store: store,
+	}
+}
+
+// Check returns true if the user has the relation with the object.
+func (e *Evaluator) Check(ctx context.Context, request client.ClientCheckRequest) (bool, error) {
+	objectType, _, ok := strings.Cut(request.Object, ":")
+	if !ok {
+		return false, fmt.Errorf("Invalid object %q", request.Object)
+	}
+
+	if !e.model.HasRelation(objectType, request.Relation) {
+		return false, fmt.Errorf("Relation %q not found on type %q", request.Relation, objectType)
+	}
+
+	c := e.newCheck(request.ContextualTuples)
+	return c.check(request.User, request.Relation, request.Object, 0)
+}
+
+// ListObjects returns all objects of the given type that the user has the relation with.
+func (e *Evaluator) ListObjects(ctx context.Context, request client.ClientListObjectsRequest) ([]string, error) {
+	if !e.model.HasRelation(request.Type, request.Relation) {
+		return nil, fmt.Errorf("Relation %q not found on type %q", request.Relation, request.Type)
+	}
+
+	c := e.newCheck(request.ContextualTuples)
+
+	candidates := e.store.objectsOfType(request.Type)
+	for object := range c.contextualObjects(request.Type) {
+		candidates = append(candidates, object)
+	}
+
+	seen := make(map[string]struct{}, len(candidates))
+	objects := []string{}
+	for _, object := range candidates {
+		_, ok := seen[object]
+		if ok {
+			continue
+		}
+
+		seen[object] = struct{}{}
+
+		allowed, err := c.check(request.User, request.Relation, object, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects, nil
+}
+
+// ListUsers returns all users of the given type that have the relation with the object. The user type may be a
+// userset type such as `group#member`, in which case the matching usersets are returned. Public access is returned as
+// the wildcard user (e.g. `user:*`). Only users that appear in a tuple are considered.
+func (e *Evaluator) ListUsers(ctx context.Context, object string, relation string, userType string) ([]string, error) {
+	objectType, _, ok := strings.Cut(object, ":")
+	if !ok {
+		return nil, fmt.Errorf("Invalid object %q", object)
+	}
+
+	if !e.model.HasRelation(objectType, relation) {
+		return nil, fmt.Errorf("Relation %q not found on type %q", relation, objectType)
+	}
+
+	userType, userRelation, isUserset := strings.Cut(userType, "#")
+	if !e.model.HasType(userType) {
+		return nil, fmt.Errorf("Type %q not found", userType)
+	}
+
+	candidates := e.store.objectsOfType(userType)
+	if isUserset {
+		for i := range candidates {
+			candidates[i] += "#" + userRelation
+		}
+	} else {
+		candidates = append(candidates, userType+":*")
+	}
+
+	c := e.newCheck(nil)
+	users := []string{}
+	for _, user := range candidates {
+		allowed, err := c.check(user, relation, object, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// ExplanationStep is a single relationship on the path that grants a user a relation. Stored is true if the step is a
+// tuple in the store (or a contextual tuple), and false if it is derived from the model.
+type ExplanationStep struct {
+	client.ClientTupleKey
+
+	Stored bool `json:"stored"`
+}
+
+// String implements fmt.Stringer.
+func (s ExplanationStep) String() string {
+	if s.Stored {
+		return formatTuple(s.ClientTupleKey) + " (tuple)"
+	}
+
+	return formatTuple(s.ClientTupleKey)
+}
+
+// Explanation is the result of Explain.
+type Explanation struct {
+	Allowed bool              `json:"allowed"`
+	Path    []ExplanationStep `json:"path"`
+}
+
+// Explain checks the relation like Check and, if it is granted, returns the relationships that grant it. The path
+// starts with the checked relationship and ends with the tuples it was derived from.
+func (e *Evaluator) Explain(ctx context.Context, request client.ClientCheckRequest) (*Explanation, error) {
+	objectType, _, ok := strings.Cut(request.Object, ":")
+	if !ok {
+		return nil, fmt.Errorf("Invalid object %q", request.Object)
+	}
+
+	if !e.model.HasRelation(objectType, request.Relation) {
+		return nil, fmt.Errorf("Relation %q not found on type %q", request.Relation, objectType)
+	}
+
+	c := e.newCheck(request.ContextualTuples)
+	c.trace = true
+	allowed, err := c.check(request.User, request.Relation, request.Object, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Steps are recorded as each check returns, so the tuples come first.
+	path := make([]ExplanationStep, 0, len(c.path))
+	for i := len(c.path) - 1; i >= 0; i-- {
+		path = append(path, c.path[i])
+	}
+
+	return &Explanation{Allowed: allowed, Path: path}, nil
+}
+
+// evaluation holds the state of a single Check or ListObjects query.
+type evaluation struct {
+	*Evaluator
+
+	contextual map[objectRelation][]string
+	visiting   map[string]struct{}
+
+	// trace enables recording the path of a successful check for Explain.
+	trace bool
+	path  []ExplanationStep
+}
+
+// newCheck returns an evaluation with the given contextual tuples.
+func (e *Evaluator) newCheck(contextualTuples *[]client.ClientTupleKey) *evaluation {
+	c := &evaluation{
+		Evaluator:  e,
+		contextual: make(map[objectRelation][]string),
+		visiting:   make(map[string]struct{}),
+	}
+
+	if contextualTuples != nil {
+		for _, tuple := range *contextualTuples {
+			key := objectRelation{object: tuple.Object, relation: tuple.Relation}
+			c.contextual[key] = append(c.contextual[key], tuple.User)
+		}
+	}
+
+	return c
+}
+
+// contextualObjects returns the objects of the given type that appear in the contextual tuples.
+func (c *evaluation) contextualObjects(objectType string) map[string]struct{} {
+	objects := make(map[string]struct{})
+	for key := range c.contextual {
+		if strings.HasPrefix(key.object, objectType+":") {
+			objects[key.object] = struct{}{}
+		}
+	}
+
+	return objects
+}
+
+// directUsers returns the users related to the object by tuples in the store and contextual tuples.
+func (c *evaluation) directUsers(object string, relation string) []string {
+	users := c.store.directUsers(object, relation)
+	return append(users, c.contextual[objectRelation{object: object, relation: relation}]...)
+}
+
+// check resolves the relation of the object for the user.
+func (c *evaluation) check(user string, relation string, object string, depth int) (bool, error) {
+	if depth > maxResolutionDepth {
+		return false, fmt.Errorf("Resolution depth exceeded checking %s %s %s", user, relation, object)
+	}
+
+	// A cycle cannot grant access that is not granted by another path.
+	key := user + " " + relation + " " + object
+	_, ok := c.visiting[key]
+	if ok {
+		return false, nil
+	}
+
+	c.visiting[key] = struct{}{}
+	defer delete(c.visiting, key)
+
+	objectType, _, _ := strings.Cut(object, ":")
+	userset, ok := c.model.Userset(objectType, relation)
+	if !ok {
+		return false, nil
+	}
+
+	pathLength := len(c.path)
+	allowed, err := c.rewrite(user, userset, relation, object, depth)
+	if err != nil || !allowed {
+		// Discard the steps of branches that did not grant the relation, e.g. the base of an excluded difference.
+		c.path = c.path[:pathLength]
+		return false, err
+	}
+
+	c.record(client.ClientTupleKey{User: user, Relation: relation, Object: object}, false)
+	return true, nil
+}
+
+// record adds a step to the path if tracing is enabled. A derived step that repeats the step before it is skipped.
+func (c *evaluation) record(tuple client.ClientTupleKey, stored bool) {
+	if !c.trace {
+		return
+	}
+
+	if !stored && len(c.path) > 0 && c.path[len(c.path)-1].ClientTupleKey == tuple {
+		return
+	}
+
+	c.path = append(c.path, ExplanationStep{ClientTupleKey: tuple, Stored: stored})
+}
+
+// rewrite resolves a rewrite rule of the relation of the object for the user.
+func (c *evaluation) rewrite(user string, userset openfgaSDK.Userset, relation string, object string, depth int) (bool, error) {
+	switch {
+	case userset.This != nil:
+		return c.this(user, relation, object, depth)
+	case userset.ComputedUserset != nil:
+		return c.check(user, userset.ComputedUserset.GetRelation(), object, depth+1)
+	case userset.TupleToUserset != nil:
+		tupleset := userset.TupleToUserset.Tupleset.GetRelation()
+		computed := userset.TupleToUserset.ComputedUserset.GetRelation()
+		for _, parent := range c.directUsers(object, tupleset) {
+			parentType, _, _ := strings.Cut(parent, ":")
+			if strings.Contains(parent, "#") || !c.model.HasRelation(parentType, computed) {
+				continue
+			}
+
+			allowed, err := c.check(user, computed, parent, depth+1)
+			if err != nil {
+				return false, err
+			}
+
+			if allowed {
+				c.record(client.ClientTupleKey{User: parent, Relation: tupleset, Object: object}, true)
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case userset.Union != nil:
+		pathLength := len(c.path)
+		for _, child := range userset.Union.GetChild() {
+			allowed, err := c.rewrite(user, child, relation, object, depth)
+			if err != nil || allowed {
+				return allowed, err
+			}
+
+			c.path = c.path[:pathLength]
+		}
+
+		return false, nil
+	case userset.Intersection != nil:
+		pathLength := len(c.path)
+		for _, child := range userset.Intersection.GetChild() {
+			allowed, err := c.rewrite(user, child, relation, object, depth)
+			if err != nil || !allowed {
+				c.path = c.path[:pathLength]
+				return false, err
+			}
+		}
+
+		return len(userset.Intersection.GetChild()) > 0, nil
+	case userset.Difference != nil:
+		allowed, err := c.rewrite(user, userset.Difference.Base, relation, object, depth)
+		if err != nil || !allowed {
+			return false, err
+		}
+
+		pathLength := len(c.path)
+		denied, err := c.rewrite(user, userset.Difference.Subtract, relation, object, depth)
+		if err != nil {
+			return false, err
+		}
+
+		c.path = c.path[:pathLength]
+		return !denied, nil
+	}
+
+	return false, nil
+}
+
+// this resolves the tuples written directly against the relation of the object.
+func (c *evaluation) this(user string, relation string, object string, depth int) (bool, error) {
+	userType, _, _ := strings.Cut(user, ":")
+	for _, directUser := range c.directUsers(object, relation) {
+		// Type bound public access, e.g. `user:*`, applies to every user of that type but not to usersets.
+		if directUser == user || (directUser == userType+":*" && !strings.Contains(user, "#")) {
+			c.record(client.ClientTupleKey{User: directUser, Relation: relation, Object: object}, true)
+			return true, nil
+		}
+
+		usersetObject, usersetRelation, ok := strings.Cut(directUser, "#")
+		if !ok {
+			continue
+		}
+
+		allowed, err := c.check(user, usersetRelation, usersetObject, depth+1)
+		if err != nil {
+			return false, err
+		}
+
+		if allowed {
+			c.record(client.ClientTupleKey{User: directUser, Relation: relation, Object: object}, true)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,150 @@
+package openfga
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator(model, NewMemoryStore(
+		client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:project01"},
+		client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance01"},
+		client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance02"},
+		client.ClientTupleKey{User: "group:operators#member", Relation: "operator", Object: "project:project01"},
+		client.ClientTupleKey{User: "user:project_operator", Relation: "member", Object: "group:operators"},
+		client.ClientTupleKey{User: "user:instance_user", Relation: "user", Object: "instance:project01/instance01"},
+	))
+
+	tests := []struct {
+		description string
+		allowed     bool
+		request     client.ClientCheckRequest
+	}{
+		{
+			description: "Public access applies to any user",
+			allowed:     true,
+			request:     client.ClientCheckRequest{User: "user:anyone", Relation: "can_view_server", Object: "server:lxd"},
+		},
+		{
+			description: "Public access does not apply to usersets",
+			allowed:     false,
+			request:     client.ClientCheckRequest{User: "group:operators#member", Relation: "can_view_server", Object: "server:lxd"},
+		},
+		{
+			description: "Group members inherit the relations of the group",
+			allowed:     true,
+			request:     client.ClientCheckRequest{User: "user:project_operator", Relation: "can_exec", Object: "instance:project01/instance02"},
+		},
+		{
+			description: "The group userset itself has the relation",
+			allowed:     true,
+			request:     client.ClientCheckRequest{User: "group:operators#member", Relation: "can_create_instances", Object: "project:project01"},
+		},
+		{
+			description: "Project operators cannot edit the project",
+			allowed:     false,
+			request:     client.ClientCheckRequest{User: "user:project_operator", Relation: "can_edit", Object: "project:project01"},
+		},
+		{
+			description: "Instance users can exec into their instance",
+			allowed:     true,
+			request:     client.ClientCheckRequest{User: "user:instance_user", Relation: "can_exec", Object: "instance:project01/instance01"},
+		},
+		{
+			description: "Instance users cannot exec into other instances",
+			allowed:     false,
+			request:     client.ClientCheckRequest{User: "user:instance_user", Relation: "can_exec", Object: "instance:project01/instance02"},
+		},
+		{
+			description: "Contextual tuples are taken into account",
+			allowed:     true,
+			request: client.ClientCheckRequest{User: "user:instance_user", Relation: "can_exec", Object: "instance:project01/instance02", ContextualTuples: &[]client.ClientTupleKey{
+				{User: "user:instance_user", Relation: "user", Object: "instance:project01/instance02"},
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		allowed, err := evaluator.Check(context.Background(), test.request)
+		require.NoError(t, err)
+		require.Equal(t, test.allowed, allowed)
+	}
+
+	objects, err := evaluator.ListObjects(context.Background(), client.ClientListObjectsRequest{User: "user:project_operator", Relation: "can_update_state", Type: "instance"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"instance:project01/instance01", "instance:project01/instance02"}, objects)
+
+	objects, err = evaluator.ListObjects(context.Background(), client.ClientListObjectsRequest{User: "user:instance_user", Relation: "can_view", Type: "instance"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"instance:project01/instance01"}, objects)
+
+	_, err = evaluator.Check(context.Background(), client.ClientCheckRequest{User: "user:anyone", Relation: "admin", Object: "instance:project01/instance01"})
+	require.EqualError(t, err, `Relation "admin" not found on type "instance"`)
+}
+
+func TestEvaluatorListUsers(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator(model, NewMemoryStore(
+		client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:project01"},
+		client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance01"},
+		client.ClientTupleKey{User: "group:operators#member", Relation: "operator", Object: "project:project01"},
+		client.ClientTupleKey{User: "user:project_operator", Relation: "member", Object: "group:operators"},
+		client.ClientTupleKey{User: "user:admin", Relation: "admin", Object: "server:lxd"},
+		client.ClientTupleKey{User: "user:instance_user", Relation: "user", Object: "instance:project01/instance01"},
+	))
+
+	users, err := evaluator.ListUsers(context.Background(), "instance:project01/instance01", "can_exec", "user")
+	require.NoError(t, err)
+	require.Equal(t, []string{"user:admin", "user:instance_user", "user:project_operator"}, users)
+
+	users, err = evaluator.ListUsers(context.Background(), "instance:project01/instance01", "can_exec", "group#member")
+	require.NoError(t, err)
+	require.Equal(t, []string{"group:operators#member"}, users)
+
+	users, err = evaluator.ListUsers(context.Background(), "server:lxd", "user", "user")
+	require.NoError(t, err)
+	require.Equal(t, []string{"user:admin", "user:instance_user", "user:project_operator", "user:*"}, users)
+}
+
+func TestEvaluatorExplain(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator(model, NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:project01"},
+		client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance01"},
+		client.ClientTupleKey{User: "group:operators#member", Relation: "operator", Object: "project:project01"},
+		client.ClientTupleKey{User: "user:project_operator", Relation: "member", Object: "group:operators"},
+	))
+
+	explanation, err := evaluator.Explain(context.Background(), client.ClientCheckRequest{User: "user:project_operator", Relation: "can_exec", Object: "instance:project01/instance01"})
+	require.NoError(t, err)
+	require.True(t, explanation.Allowed)
+
+	path := make([]string, 0, len(explanation.Path))
+	for _, step := range explanation.Path {
+		path = append(path, step.String())
+	}
+
+	require.Equal(t, "user:project_operator can_exec instance:project01/instance01", path[0])
+	require.Contains(t, path, "project:project01 project instance:project01/instance01 (tuple)")
+	require.Contains(t, path, "group:operators#member operator project:project01 (tuple)")
+	require.Equal(t, "user:project_operator member group:operators (tuple)", path[len(path)-1])
+
+	explanation, err = evaluator.Explain(context.Background(), client.ClientCheckRequest{User: "user:project_operator", Relation: "can_edit", Object: "project:project01"})
+	require.NoError(t, err)
+	require.False(t, explanation.Allowed)
+	require.Empty(t, explanation.Path)
+}
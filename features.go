@@ -0,0 +1,208 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// DefaultProject is the name of the default project of LXD. Projects without a feature use its resources.
+const DefaultProject = "default"
+
+// ProjectFeature is a `features.*` config key of an LXD project. A project with the feature has its own resources of
+// the types of the feature, and a project without it uses the resources of the default project.
+//
+// A project that uses the resources of the default project only gets to view them. The model has no separate
+// entitlement to use a resource, so LXD must only require `can_view` to launch an instance from a shared image, apply
+// a shared profile, or attach a shared network or custom volume. Editing, deleting and creating shared resources
+// requires entitlements on the default project.
+type ProjectFeature string
+
+// Project features that determine the effective project of resources.
+const (
+	// ProjectFeatureImages covers images, and the image volumes of storage pools. Projects that use the images of the
+	// default project can view them, but not its image volumes.
+	ProjectFeatureImages ProjectFeature = "features.images"
+
+	// ProjectFeatureProfiles covers profiles.
+	ProjectFeatureProfiles ProjectFeature = "features.profiles"
+
+	// ProjectFeatureNetworks covers networks, network ACLs, and the forwards, load balancers and peers of networks.
+	ProjectFeatureNetworks ProjectFeature = "features.networks"
+
+	// ProjectFeatureStorageVolumes covers the custom volumes of storage pools.
+	ProjectFeatureStorageVolumes ProjectFeature = "features.storage.volumes"
+)
+
+// projectFeatureConsumers are the relations of the default project that link it to the projects that use its
+// resources of each feature, e.g. `project:p profile_consumer project:default`.
+var projectFeatureConsumers = map[ProjectFeature]string{
+	ProjectFeatureImages:         "image_consumer",
+	ProjectFeatureProfiles:       "profile_consumer",
+	ProjectFeatureNetworks:       "network_consumer",
+	ProjectFeatureStorageVolumes: "storage_volume_consumer",
+}
+
+// projectFeatureTypes are the object types covered by each feature, other than storage volumes which depend on the
+// volume type.
+var projectFeatureTypes = map[ObjectType]ProjectFeature{
+	ObjectTypeImage:               ProjectFeatureImages,
+	ObjectTypeProfile:             ProjectFeatureProfiles,
+	ObjectTypeNetwork:             ProjectFeatureNetworks,
+	ObjectTypeNetworkACL:          ProjectFeatureNetworks,
+	ObjectTypeNetworkForward:      ProjectFeatureNetworks,
+	ObjectTypeNetworkLoadBalancer: ProjectFeatureNetworks,
+	ObjectTypeNetworkPeer:         ProjectFeatureNetworks,
+}
+
+// projectFeatureEntitlements are the entitlements on a project to create resources covered by each feature. Storage
+// volumes are created as custom volumes.
+var projectFeatureEntitlements = map[Entitlement]ProjectFeature{
+	EntitlementCanCreateImages:               ProjectFeatureImages,
+	EntitlementCanCreateProfiles:             ProjectFeatureProfiles,
+	EntitlementCanCreateNetworks:             ProjectFeatureNetworks,
+	EntitlementCanCreateNetworkACLs:          ProjectFeatureNetworks,
+	EntitlementCanCreateNetworkForwards:      ProjectFeatureNetworks,
+	EntitlementCanCreateNetworkLoadBalancers: ProjectFeatureNetworks,
+	EntitlementCanCreateNetworkPeers:         ProjectFeatureNetworks,
+	EntitlementCanCreateStoragePoolVolumes:   ProjectFeatureStorageVolumes,
+}
+
+// ProjectFeatures are the features enabled on a project.
+type ProjectFeatures map[ProjectFeature]bool
+
+// ProjectFeaturesFromConfig returns the features enabled in the config of a project. As in LXD, a feature is enabled
+// by a true value ("true", "1", "yes" or "on") and disabled if it is unset.
+func ProjectFeaturesFromConfig(config map[string]string) ProjectFeatures {
+	features := make(ProjectFeatures, len(projectFeatureConsumers))
+	for feature := range projectFeatureConsumers {
+		features[feature] = isTrue(config[string(feature)])
+	}
+
+	return features
+}
+
+// isTrue returns true if a config value is true for LXD.
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "on":
+		return true
+	}
+
+	return false
+}
+
+// objectProjectFeature returns the feature that covers the type of a resource of a project. Storage volume names are
+// of the form `<pool>/<type>/<volume>`, and volumes of instances belong to the project of the instance whatever its
+// features.
+func objectProjectFeature(object Object) (ProjectFeature, bool) {
+	if object.Type != ObjectTypeStoragePoolVolume {
+		feature, ok := projectFeatureTypes[object.Type]
+		return feature, ok
+	}
+
+	parts := strings.SplitN(object.Name, "/", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+
+	switch parts[1] {
+	case "custom":
+		return ProjectFeatureStorageVolumes, true
+	case "image":
+		return ProjectFeatureImages, true
+	default:
+		return "", false
+	}
+}
+
+// EffectiveProject returns the project that a resource, named as it is used from a project with these features,
+// belongs to. This is the default project if the project does not have the feature covering the resource, and the
+// project of the object otherwise.
+func (f ProjectFeatures) EffectiveProject(object Object) string {
+	feature, ok := objectProjectFeature(object)
+	if !ok || object.Project == "" || f[feature] {
+		return object.Project
+	}
+
+	return DefaultProject
+}
+
+// ProjectFeatureTuples returns the tuples that let a project of the cluster use the resources of the default project
+// for each feature it does not have. They must be written when the project is created, and replaced when its features
+// change. The default project always has all features.
+func ProjectFeatureTuples(cluster Cluster, project string, features ProjectFeatures) []client.ClientTupleKey {
+	if project == DefaultProject {
+		return nil
+	}
+
+	var tuples []client.ClientTupleKey
+	for _, feature := range []ProjectFeature{ProjectFeatureImages, ProjectFeatureProfiles, ProjectFeatureNetworks, ProjectFeatureStorageVolumes} {
+		if features[feature] {
+			continue
+		}
+
+		tuples = append(tuples, client.ClientTupleKey{
+			User:     cluster.Project(project).String(),
+			Relation: projectFeatureConsumers[feature],
+			Object:   cluster.Project(DefaultProject).String(),
+		})
+	}
+
+	return tuples
+}
+
+// ProjectFeatureLookup returns the features of the named project, e.g. from the LXD database.
+type ProjectFeatureLookup func(ctx context.Context, project string) (ProjectFeatures, error)
+
+// projectFeatureQuerier is a Querier that checks resources in their effective project.
+type projectFeatureQuerier struct {
+	querier Querier
+	lookup  ProjectFeatureLookup
+}
+
+// NewProjectFeatureQuerier returns a Querier for objects named as they are used from a project, as extracted from LXD
+// requests. Resources that a project uses from the default project, because it does not have the feature covering
+// them, are checked in the default project. So are the entitlements to create them, as they are created in the default
+// project. Viewers of the project can view them through the tuples of ProjectFeatureTuples, and editing them requires
+// entitlements on the default project. ListObjects is not rewritten: it returns objects in the project they belong to,
+// which is the default project for the resources that a project uses from it. These objects are checked unchanged.
+func NewProjectFeatureQuerier(querier Querier, lookup ProjectFeatureLookup) Querier {
+	return &projectFeatureQuerier{querier: querier, lookup: lookup}
+}
+
+// Check implements Checker.
+func (q *projectFeatureQuerier) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	project := object.Project
+	feature, ok := objectProjectFeature(object)
+	if object.Type == ObjectTypeProject {
+		project = object.Name
+		feature, ok = projectFeatureEntitlements[entitlement]
+	}
+
+	if !ok || project == "" || project == DefaultProject {
+		return q.querier.Check(ctx, user, entitlement, object)
+	}
+
+	features, err := q.lookup(ctx, project)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get features of project %q: %w", project, err)
+	}
+
+	if !features[feature] {
+		if object.Type == ObjectTypeProject {
+			object.Name = DefaultProject
+		} else {
+			object.Project = DefaultProject
+		}
+	}
+
+	return q.querier.Check(ctx, user, entitlement, object)
+}
+
+// ListObjects implements Querier. Objects are returned in the project they belong to.
+func (q *projectFeatureQuerier) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	return q.querier.ListObjects(ctx, user, entitlement, objectType)
+}
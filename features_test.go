@@ -0,0 +1,355 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectFeaturesFromConfig(t *testing.T) {
+	features := ProjectFeaturesFromConfig(map[string]string{
+		"features.images":          "true",
+		"features.profiles":        "false",
+		"features.networks":        "1",
+		"features.storage.buckets": "true",
+	})
+
+	require.Equal(t, ProjectFeatures{
+		ProjectFeatureImages:         true,
+		ProjectFeatureProfiles:       false,
+		ProjectFeatureNetworks:       true,
+		ProjectFeatureStorageVolumes: false,
+	}, features)
+
+	require.Equal(t, []client.ClientTupleKey{
+		{User: "project:p@a", Relation: "profile_consumer", Object: "project:default@a"},
+		{User: "project:p@a", Relation: "storage_volume_consumer", Object: "project:default@a"},
+	}, ProjectFeatureTuples("a", "p", features))
+	require.Empty(t, ProjectFeatureTuples("a", DefaultProject, nil))
+}
+
+// newFeatureTestEvaluator returns an evaluator for projects default and p, which both have an image, a profile, a
+// network with a forward, and custom, image and instance volumes. Alice is a viewer and bob an operator of p, and carol
+// is an operator of the default project.
+func newFeatureTestEvaluator(t *testing.T, features ProjectFeatures) *Evaluator {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "user:alice", Relation: "viewer", Object: "project:p"},
+		client.ClientTupleKey{User: "user:bob", Relation: "operator", Object: "project:p"},
+		client.ClientTupleKey{User: "user:carol", Relation: "operator", Object: "project:default"},
+	)
+
+	for _, project := range []string{DefaultProject, "p"} {
+		projectObject := ProjectObject(project).String()
+		tuples := []client.ClientTupleKey{{User: ServerObject().String(), Relation: "server", Object: projectObject}}
+		for _, object := range []Object{
+			ProjectResourceObject(ObjectTypeImage, project, "img"),
+			ProjectResourceObject(ObjectTypeProfile, project, "prof"),
+			ProjectResourceObject(ObjectTypeNetwork, project, "net"),
+			ProjectResourceObject(ObjectTypeNetworkForward, project, "net/10.0.0.1"),
+			ProjectResourceObject(ObjectTypeStoragePoolVolume, project, "pool01/custom/vol"),
+			ProjectResourceObject(ObjectTypeStoragePoolVolume, project, "pool01/image/img"),
+			ProjectResourceObject(ObjectTypeStoragePoolVolume, project, "pool01/container/c1"),
+		} {
+			tuples = append(tuples, client.ClientTupleKey{User: projectObject, Relation: "project", Object: object.String()})
+		}
+
+		require.NoError(t, store.WriteTuples(context.Background(), tuples, nil))
+	}
+
+	require.NoError(t, store.WriteTuples(context.Background(), ProjectFeatureTuples("", "p", features), nil))
+	return NewEvaluator(model, store)
+}
+
+func TestProjectFeatureQuerier(t *testing.T) {
+	allFeatures := ProjectFeatures{
+		ProjectFeatureImages:         true,
+		ProjectFeatureProfiles:       true,
+		ProjectFeatureNetworks:       true,
+		ProjectFeatureStorageVolumes: true,
+	}
+
+	without := func(feature ProjectFeature) ProjectFeatures {
+		features := ProjectFeatures{}
+		for f, enabled := range allFeatures {
+			features[f] = enabled && f != feature
+		}
+
+		return features
+	}
+
+	tests := []struct {
+		description string
+		features    ProjectFeatures
+		user        string
+		entitlement Entitlement
+		object      Object
+		effective   string
+		allowed     bool
+	}{
+		{
+			description: "Without features.images, a viewer of the project can view the images of the default project",
+			features:    without(ProjectFeatureImages),
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.images, an operator of the project can use the images of the default project, which only requires can_view",
+			features:    without(ProjectFeatureImages),
+			user:        "user:bob",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.images, an operator of the project cannot edit the images of the default project",
+			features:    without(ProjectFeatureImages),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "Without features.images, an operator of the default project can edit its images used by the project",
+			features:    without(ProjectFeatureImages),
+			user:        "user:carol",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.images, images are created in the default project",
+			features:    without(ProjectFeatureImages),
+			user:        "user:bob",
+			entitlement: EntitlementCanCreateImages,
+			object:      ProjectObject("p"),
+		},
+		{
+			description: "Without features.images, image volumes are those of the default project",
+			features:    without(ProjectFeatureImages),
+			user:        "user:carol",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/image/img"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "With features.images, an operator of the project can edit its own images",
+			features:    allFeatures,
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   "p",
+			allowed:     true,
+		},
+		{
+			description: "With features.images, a viewer of the project cannot view the images of the default project",
+			features:    allFeatures,
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeImage, DefaultProject, "img"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "Without features.profiles, a viewer of the project can view the profiles of the default project",
+			features:    without(ProjectFeatureProfiles),
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeProfile, "p", "prof"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.profiles, an operator of the project can use the profiles of the default project, which only requires can_view",
+			features:    without(ProjectFeatureProfiles),
+			user:        "user:bob",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeProfile, "p", "prof"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.profiles, an operator of the project cannot edit the profiles of the default project",
+			features:    without(ProjectFeatureProfiles),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeProfile, "p", "prof"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "Without features.profiles, an operator of the default project can create profiles used by the project",
+			features:    without(ProjectFeatureProfiles),
+			user:        "user:carol",
+			entitlement: EntitlementCanCreateProfiles,
+			object:      ProjectObject("p"),
+			allowed:     true,
+		},
+		{
+			description: "Without features.profiles, the images of the project are its own",
+			features:    without(ProjectFeatureProfiles),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeImage, "p", "img"),
+			effective:   "p",
+			allowed:     true,
+		},
+		{
+			description: "With features.profiles, an operator of the project can create profiles",
+			features:    allFeatures,
+			user:        "user:bob",
+			entitlement: EntitlementCanCreateProfiles,
+			object:      ProjectObject("p"),
+			allowed:     true,
+		},
+		{
+			description: "Without features.networks, a viewer of the project can view the networks of the default project",
+			features:    without(ProjectFeatureNetworks),
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeNetwork, "p", "net"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.networks, an operator of the project can use the networks of the default project, which only requires can_view",
+			features:    without(ProjectFeatureNetworks),
+			user:        "user:bob",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeNetwork, "p", "net"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.networks, an operator of the project cannot edit the networks of the default project",
+			features:    without(ProjectFeatureNetworks),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeNetwork, "p", "net"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "Without features.networks, network forwards belong to the networks of the default project",
+			features:    without(ProjectFeatureNetworks),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeNetworkForward, "p", "net/10.0.0.1"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "With features.networks, an operator of the project can edit its own network forwards",
+			features:    allFeatures,
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeNetworkForward, "p", "net/10.0.0.1"),
+			effective:   "p",
+			allowed:     true,
+		},
+		{
+			description: "Without features.storage.volumes, a viewer of the project can view the custom volumes of the default project",
+			features:    without(ProjectFeatureStorageVolumes),
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/custom/vol"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.storage.volumes, an operator of the project can use the custom volumes of the default project, which only requires can_view",
+			features:    without(ProjectFeatureStorageVolumes),
+			user:        "user:bob",
+			entitlement: EntitlementCanView,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/custom/vol"),
+			effective:   DefaultProject,
+			allowed:     true,
+		},
+		{
+			description: "Without features.storage.volumes, an operator of the project cannot edit the custom volumes of the default project",
+			features:    without(ProjectFeatureStorageVolumes),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/custom/vol"),
+			effective:   DefaultProject,
+		},
+		{
+			description: "Without features.storage.volumes, instance volumes belong to the project of the instance",
+			features:    without(ProjectFeatureStorageVolumes),
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/container/c1"),
+			effective:   "p",
+			allowed:     true,
+		},
+		{
+			description: "With features.storage.volumes, an operator of the project can edit its own custom volumes",
+			features:    allFeatures,
+			user:        "user:bob",
+			entitlement: EntitlementCanEdit,
+			object:      ProjectResourceObject(ObjectTypeStoragePoolVolume, "p", "pool01/custom/vol"),
+			effective:   "p",
+			allowed:     true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		if test.object.Type != ObjectTypeProject {
+			require.Equal(t, test.effective, test.features.EffectiveProject(test.object))
+		}
+
+		querier := NewProjectFeatureQuerier(evaluatorChecker{evaluator: newFeatureTestEvaluator(t, test.features)}, func(ctx context.Context, project string) (ProjectFeatures, error) {
+			require.Equal(t, "p", project)
+			return test.features, nil
+		})
+
+		allowed, err := querier.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.Equal(t, test.allowed, allowed)
+	}
+
+	// Listings return the resources that a project uses from the default project in the default project, and each
+	// listed object is allowed by Check.
+	lookup := func(ctx context.Context, project string) (ProjectFeatures, error) {
+		return without(ProjectFeatureImages), nil
+	}
+
+	querier := NewProjectFeatureQuerier(evaluatorChecker{evaluator: newFeatureTestEvaluator(t, without(ProjectFeatureImages))}, lookup)
+	objects, err := querier.ListObjects(context.Background(), "user:alice", EntitlementCanView, ObjectTypeImage)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []Object{ProjectResourceObject(ObjectTypeImage, DefaultProject, "img"), ProjectResourceObject(ObjectTypeImage, "p", "img")}, objects)
+	for _, object := range objects {
+		allowed, err := querier.Check(context.Background(), "user:alice", EntitlementCanView, object)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	objects, err = querier.ListObjects(context.Background(), "user:alice", EntitlementCanView, ObjectTypeProfile)
+	require.NoError(t, err)
+	require.Equal(t, []Object{ProjectResourceObject(ObjectTypeProfile, "p", "prof")}, objects)
+
+	// Lookups are only made for resources covered by a feature, outside the default project.
+	querier = NewProjectFeatureQuerier(evaluatorChecker{evaluator: newFeatureTestEvaluator(t, allFeatures)}, func(ctx context.Context, project string) (ProjectFeatures, error) {
+		return nil, errors.New("Project not found")
+	})
+
+	_, err = querier.Check(context.Background(), "user:bob", EntitlementCanView, ProjectResourceObject(ObjectTypeProfile, "p", "prof"))
+	require.EqualError(t, err, `Failed to get features of project "p": Project not found`)
+
+	allowed, err := querier.Check(context.Background(), "user:bob", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	allowed, err = querier.Check(context.Background(), "user:carol", EntitlementCanEdit, ProjectResourceObject(ObjectTypeProfile, DefaultProject, "prof"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
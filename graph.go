@@ -0,0 +1,245 @@
+package openfga
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+)
+
+// GraphFormat is an output format of Model.EncodeGraph.
+type GraphFormat string
+
+const (
+	// GraphDOT is a Graphviz DOT digraph.
+	GraphDOT GraphFormat = "dot"
+
+	// GraphMermaid is a Mermaid flowchart.
+	GraphMermaid GraphFormat = "mermaid"
+)
+
+// Labels of the edges of a model graph.
+const (
+	graphEdgeDirect     = "direct"
+	graphEdgeComputed   = "computed"
+	graphEdgeFromParent = "from-parent"
+)
+
+// GraphOptions configures Model.EncodeGraph.
+type GraphOptions struct {
+	// Highlight is a relation (e.g. `instance#can_exec`) whose incoming paths are highlighted.
+	Highlight string
+}
+
+// graphEdge is an edge of a model graph between two nodes. Nodes are relations (`type#relation`) or types.
+type graphEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// graphNode is a node of a model graph.
+type graphNode struct {
+	id    string
+	label string
+}
+
+// EncodeGraph writes the model to w as a graph in the given format. Each type is a cluster containing a node for each
+// of its relations, and a node for the type itself if it can be written directly as a user. Edges point from what
+// grants a relation to the relation and are labelled `direct` (a user type that can be written directly), `computed`
+// (another relation of the same type) or `from-parent` (a relation of the parent object, e.g. `operator from
+// project`). If a relation is highlighted, every edge and node on a path reaching it is highlighted.
+func (m *Model) EncodeGraph(w io.Writer, format GraphFormat, options GraphOptions) error {
+	if format != GraphDOT && format != GraphMermaid {
+		return fmt.Errorf("Unknown graph format %q", format)
+	}
+
+	edges := m.graphEdges()
+	clusters := make(map[string][]graphNode)
+	typeNodes := make(map[string]struct{})
+	for _, edge := range edges {
+		if !strings.Contains(edge.from, "#") {
+			typeNodes[edge.from] = struct{}{}
+		}
+	}
+
+	for _, objectType := range m.Types() {
+		_, ok := typeNodes[objectType]
+		if ok || len(m.Relations(objectType)) == 0 {
+			clusters[objectType] = append(clusters[objectType], graphNode{id: objectType, label: objectType})
+		}
+
+		for _, relation := range m.Relations(objectType) {
+			clusters[objectType] = append(clusters[objectType], graphNode{id: objectType + "#" + relation, label: relation})
+		}
+	}
+
+	highlighted := make(map[string]struct{})
+	if options.Highlight != "" {
+		objectType, relation, ok := strings.Cut(options.Highlight, "#")
+		if !ok || !m.HasRelation(objectType, relation) {
+			return fmt.Errorf("Relation %q not found", options.Highlight)
+		}
+
+		// Walk the edges backwards from the highlighted relation.
+		highlighted[options.Highlight] = struct{}{}
+		for changed := true; changed; {
+			changed = false
+			for _, edge := range edges {
+				_, ok := highlighted[edge.to]
+				if !ok {
+					continue
+				}
+
+				_, ok = highlighted[edge.from]
+				if !ok {
+					highlighted[edge.from] = struct{}{}
+					changed = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	if format == GraphDOT {
+		encodeDOT(&b, m.Types(), clusters, edges, highlighted)
+	} else {
+		encodeMermaid(&b, m.Types(), clusters, edges, highlighted)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// graphEdges returns the edges of the model graph, in the order of the types and relations of the model. Relations
+// that are only subtracted in an exclusion do not grant the relation, so they have no edge.
+func (m *Model) graphEdges() []graphEdge {
+	var edges []graphEdge
+	for _, objectType := range m.Types() {
+		for _, relation := range m.Relations(objectType) {
+			node := objectType + "#" + relation
+
+			var collect func(userset openfgaSDK.Userset)
+			collect = func(userset openfgaSDK.Userset) {
+				switch {
+				case userset.This != nil:
+					for _, reference := range m.DirectlyRelatedUserTypes(objectType, relation) {
+						from := reference.Type
+						label := graphEdgeDirect
+						if reference.Relation != nil {
+							from += "#" + reference.GetRelation()
+						} else if reference.Wildcard != nil {
+							label += " (*)"
+						}
+
+						edges = append(edges, graphEdge{from: from, to: node, label: label})
+					}
+
+				case userset.ComputedUserset != nil:
+					edges = append(edges, graphEdge{from: objectType + "#" + userset.ComputedUserset.GetRelation(), to: node, label: graphEdgeComputed})
+				case userset.TupleToUserset != nil:
+					computed := userset.TupleToUserset.ComputedUserset.GetRelation()
+					for _, parent := range m.DirectlyRelatedUserTypes(objectType, userset.TupleToUserset.Tupleset.GetRelation()) {
+						if parent.Relation == nil && parent.Wildcard == nil {
+							edges = append(edges, graphEdge{from: parent.Type + "#" + computed, to: node, label: graphEdgeFromParent})
+						}
+					}
+
+				case userset.Union != nil:
+					for _, child := range userset.Union.GetChild() {
+						collect(child)
+					}
+
+				case userset.Intersection != nil:
+					for _, child := range userset.Intersection.GetChild() {
+						collect(child)
+					}
+
+				case userset.Difference != nil:
+					collect(userset.Difference.Base)
+				}
+			}
+
+			userset, _ := m.Userset(objectType, relation)
+			collect(userset)
+		}
+	}
+
+	return edges
+}
+
+// encodeDOT writes the graph in Graphviz DOT.
+func encodeDOT(b *strings.Builder, types []string, clusters map[string][]graphNode, edges []graphEdge, highlighted map[string]struct{}) {
+	b.WriteString("digraph model {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, objectType := range types {
+		fmt.Fprintf(b, "  subgraph %q {\n    label=%q;\n", "cluster_"+objectType, objectType)
+		for _, node := range clusters[objectType] {
+			attributes := fmt.Sprintf("label=%q", node.label)
+			_, ok := highlighted[node.id]
+			if ok {
+				attributes += ", color=red, penwidth=2"
+			}
+
+			fmt.Fprintf(b, "    %q [%s];\n", node.id, attributes)
+		}
+
+		b.WriteString("  }\n")
+	}
+
+	for _, edge := range edges {
+		attributes := fmt.Sprintf("label=%q", edge.label)
+		if isHighlighted(edge, highlighted) {
+			attributes += ", color=red, penwidth=2"
+		}
+
+		fmt.Fprintf(b, "  %q -> %q [%s];\n", edge.from, edge.to, attributes)
+	}
+
+	b.WriteString("}\n")
+}
+
+// encodeMermaid writes the graph as a Mermaid flowchart.
+func encodeMermaid(b *strings.Builder, types []string, clusters map[string][]graphNode, edges []graphEdge, highlighted map[string]struct{}) {
+	b.WriteString("flowchart LR\n")
+	for _, objectType := range types {
+		fmt.Fprintf(b, "  subgraph type_%s[%q]\n", objectType, objectType)
+		for _, node := range clusters[objectType] {
+			fmt.Fprintf(b, "    %s[%q]\n", mermaidID(node.id), node.label)
+		}
+
+		b.WriteString("  end\n")
+	}
+
+	var highlightedEdges []string
+	for i, edge := range edges {
+		fmt.Fprintf(b, "  %s -->|%q| %s\n", mermaidID(edge.from), edge.label, mermaidID(edge.to))
+		if isHighlighted(edge, highlighted) {
+			highlightedEdges = append(highlightedEdges, fmt.Sprint(i))
+		}
+	}
+
+	if len(highlightedEdges) > 0 {
+		fmt.Fprintf(b, "  linkStyle %s stroke:red,stroke-width:2px\n", strings.Join(highlightedEdges, ","))
+	}
+
+	for _, objectType := range types {
+		for _, node := range clusters[objectType] {
+			_, ok := highlighted[node.id]
+			if ok {
+				fmt.Fprintf(b, "  style %s stroke:red,stroke-width:2px\n", mermaidID(node.id))
+			}
+		}
+	}
+}
+
+// isHighlighted returns true if the edge is on a path to the highlighted relation.
+func isHighlighted(edge graphEdge, highlighted map[string]struct{}) bool {
+	_, ok := highlighted[edge.to]
+	return ok
+}
+
+// mermaidID returns the Mermaid node ID of a graph node, which must not contain `#`.
+func mermaidID(id string) string {
+	return strings.ReplaceAll(id, "#", "__")
+}
@@ -0,0 +1,56 @@
+package openfga
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelEncodeGraph(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = model.EncodeGraph(&buf, GraphDOT, GraphOptions{})
+	require.NoError(t, err)
+	dot := buf.String()
+	require.True(t, strings.HasPrefix(dot, "digraph model {\n"))
+	require.Contains(t, dot, "  subgraph \"cluster_instance\" {\n    label=\"instance\";\n")
+	require.Contains(t, dot, `    "instance#can_exec" [label="can_exec"];`)
+	require.Contains(t, dot, `  "project#operator" -> "instance#can_exec" [label="from-parent"];`)
+	require.Contains(t, dot, `  "instance#user" -> "instance#can_exec" [label="computed"];`)
+	require.Contains(t, dot, `  "group#member" -> "instance#can_exec" [label="direct"];`)
+	require.Contains(t, dot, `  "user" -> "server#user" [label="direct (*)"];`)
+	require.Contains(t, dot, `  "project" -> "instance#project" [label="direct"];`)
+	require.NotContains(t, dot, "red")
+
+	buf.Reset()
+	err = model.EncodeGraph(&buf, GraphDOT, GraphOptions{Highlight: "instance#can_exec"})
+	require.NoError(t, err)
+	dot = buf.String()
+	require.Contains(t, dot, `    "instance#can_exec" [label="can_exec", color=red, penwidth=2];`)
+	require.Contains(t, dot, `    "server#admin" [label="admin", color=red, penwidth=2];`)
+	require.Contains(t, dot, `    "project#viewer" [label="viewer"];`)
+	require.Contains(t, dot, `  "project#operator" -> "instance#can_exec" [label="from-parent", color=red, penwidth=2];`)
+	require.Contains(t, dot, `  "server#operator" -> "project#operator" [label="from-parent", color=red, penwidth=2];`)
+	require.Contains(t, dot, `  "project#operator" -> "project#viewer" [label="computed"];`)
+
+	buf.Reset()
+	err = model.EncodeGraph(&buf, GraphMermaid, GraphOptions{Highlight: "instance#can_exec"})
+	require.NoError(t, err)
+	mermaid := buf.String()
+	require.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"))
+	require.Contains(t, mermaid, "  subgraph type_instance[\"instance\"]\n    instance__can_access_console[\"can_access_console\"]\n")
+	require.Contains(t, mermaid, `  project__operator -->|"from-parent"| instance__can_exec`)
+	require.Contains(t, mermaid, "  style instance__can_exec stroke:red,stroke-width:2px\n")
+	require.NotContains(t, mermaid, "style project__viewer ")
+	require.Contains(t, mermaid, "  linkStyle ")
+
+	err = model.EncodeGraph(&buf, GraphDOT, GraphOptions{Highlight: "instance#can_fly"})
+	require.EqualError(t, err, `Relation "instance#can_fly" not found`)
+
+	err = model.EncodeGraph(&buf, "svg", GraphOptions{})
+	require.EqualError(t, err, `Unknown graph format "svg"`)
+}
@@ -0,0 +1,377 @@
+// Package openfgatest provides a fake OpenFGA server for tests.
+package openfgatest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+
+	openfga "github.com/markylaing/lxd-openfga"
+)
+
+// Server implements the store, authorization model, tuple, changes, check and list objects endpoints of the OpenFGA
+// HTTP API. Checks and object listings are evaluated with the in-process Evaluator. Lists are returned in pages of two to
+// exercise pagination.
+type Server struct {
+	// URL is the URL of the server.
+	URL *url.URL
+
+	mu     sync.Mutex
+	nextID int
+	stores []openfgaSDK.Store
+
+	// models are the authorization models of each store, latest first.
+	models map[string][]openfgaSDK.AuthorizationModel
+
+	// tuples are the tuples of each store.
+	tuples map[string]*openfga.MemoryStore
+
+	// changes are the changelogs of each store, of which the first pruned changes have been removed.
+	changes map[string][]openfgaSDK.TupleChange
+	pruned  map[string]int
+}
+
+// NewServer starts a fake server that is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		models:  make(map[string][]openfgaSDK.AuthorizationModel),
+		tuples:  make(map[string]*openfga.MemoryStore),
+		changes: make(map[string][]openfgaSDK.TupleChange),
+		pruned:  make(map[string]int),
+	}
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	s.URL = u
+	return s
+}
+
+// NewClient returns a client for the server.
+func (s *Server) NewClient(t testing.TB) *client.OpenFgaClient {
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{ApiScheme: s.URL.Scheme, ApiHost: s.URL.Host})
+	require.NoError(t, err)
+
+	return fga
+}
+
+// Stores returns the stores of the server.
+func (s *Server) Stores() []openfgaSDK.Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]openfgaSDK.Store(nil), s.stores...)
+}
+
+// Models returns the authorization models of a store, latest first.
+func (s *Server) Models(storeID string) []openfgaSDK.AuthorizationModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]openfgaSDK.AuthorizationModel(nil), s.models[storeID]...)
+}
+
+// Tuples returns the tuples of a store. Tuples written to it are visible to the clients of the server, but are not
+// recorded in the changelog of the store.
+func (s *Server) Tuples(storeID string) *openfga.MemoryStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store(storeID)
+}
+
+// PruneChanges removes all changes from the changelog of a store, so that reading changes from an earlier continuation
+// token fails with an invalid continuation token error.
+func (s *Server) PruneChanges(storeID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruned[storeID] = len(s.changes[storeID])
+}
+
+// id returns a new ID. Like ULIDs, IDs sort in the order they are created.
+func (s *Server) id() string {
+	s.nextID++
+	return fmt.Sprintf("01H%023d", s.nextID)
+}
+
+// store returns the tuples of a store.
+func (s *Server) store(storeID string) *openfga.MemoryStore {
+	if s.tuples[storeID] == nil {
+		s.tuples[storeID] = openfga.NewMemoryStore()
+	}
+
+	return s.tuples[storeID]
+}
+
+// page returns the page of items starting at the continuation token, and the token of the next page. Concurrent
+// deletes can leave a token past the end of the items, which is then an empty last page.
+func page[T any](items []T, continuationToken string) ([]T, string) {
+	start, _ := strconv.Atoi(continuationToken)
+	if start > len(items) {
+		start = len(items)
+	}
+
+	end := start + 2
+	if end >= len(items) {
+		return items[start:], ""
+	}
+
+	return items[start:end], strconv.Itoa(end)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	reply := func(response any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+
+	// fail replies with an error, which OpenFGA reports as invalid input.
+	fail := func(err error) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "validation_error", "message": err.Error()})
+	}
+
+	continuationToken := r.URL.Query().Get("continuation_token")
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		stores, token := page(s.stores, continuationToken)
+		reply(openfgaSDK.ListStoresResponse{Stores: &stores, ContinuationToken: &token})
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		var request openfgaSDK.CreateStoreRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		id := s.id()
+		s.stores = append(s.stores, openfgaSDK.Store{Id: &id, Name: &request.Name})
+		reply(openfgaSDK.CreateStoreResponse{Id: &id, Name: &request.Name})
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		for i, store := range s.stores {
+			if store.GetId() == parts[1] {
+				s.stores = append(s.stores[:i], s.stores[i+1:]...)
+				delete(s.models, parts[1])
+				delete(s.tuples, parts[1])
+				delete(s.changes, parts[1])
+				delete(s.pruned, parts[1])
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	case len(parts) == 3 && parts[2] == "authorization-models" && r.Method == http.MethodGet:
+		models, token := page(s.models[parts[1]], continuationToken)
+		reply(openfgaSDK.ReadAuthorizationModelsResponse{AuthorizationModels: &models, ContinuationToken: &token})
+	case len(parts) == 4 && parts[2] == "authorization-models" && r.Method == http.MethodGet:
+		for _, model := range s.models[parts[1]] {
+			if model.GetId() == parts[3] {
+				reply(openfgaSDK.ReadAuthorizationModelResponse{AuthorizationModel: &model})
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	case len(parts) == 3 && parts[2] == "authorization-models" && r.Method == http.MethodPost:
+		var request openfgaSDK.WriteAuthorizationModelRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		id := s.id()
+		model := openfgaSDK.AuthorizationModel{Id: &id, SchemaVersion: request.GetSchemaVersion(), TypeDefinitions: &request.TypeDefinitions}
+		s.models[parts[1]] = append([]openfgaSDK.AuthorizationModel{model}, s.models[parts[1]]...)
+		reply(openfgaSDK.WriteAuthorizationModelResponse{AuthorizationModelId: &id})
+	case len(parts) == 3 && parts[2] == "read" && r.Method == http.MethodPost:
+		var request openfgaSDK.ReadRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		filter := tupleKey(request.GetTupleKey())
+		keys, _ := s.store(parts[1]).ReadTuples(r.Context(), filter)
+		tuples := make([]openfgaSDK.Tuple, 0, len(keys))
+		for _, key := range keys {
+			tuples = append(tuples, openfgaSDK.Tuple{Key: &openfgaSDK.TupleKey{User: openfgaSDK.PtrString(key.User), Relation: openfgaSDK.PtrString(key.Relation), Object: openfgaSDK.PtrString(key.Object)}})
+		}
+
+		tuples, token := page(tuples, request.GetContinuationToken())
+		reply(openfgaSDK.ReadResponse{Tuples: &tuples, ContinuationToken: &token})
+	case len(parts) == 3 && parts[2] == "write" && r.Method == http.MethodPost:
+		var request openfgaSDK.WriteRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		err := s.write(parts[1], request)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		reply(map[string]any{})
+	case len(parts) == 3 && parts[2] == "changes" && r.Method == http.MethodGet:
+		changes, token, err := s.readChanges(parts[1], r.URL.Query().Get("type"), continuationToken)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"code": "invalid_continuation_token", "message": err.Error()})
+			return
+		}
+
+		reply(openfgaSDK.ReadChangesResponse{Changes: &changes, ContinuationToken: &token})
+	case len(parts) == 3 && parts[2] == "check" && r.Method == http.MethodPost:
+		var request openfgaSDK.CheckRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		evaluator, err := s.evaluator(parts[1], request.AuthorizationModelId)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		allowed, err := evaluator.Check(r.Context(), client.ClientCheckRequest{
+			User:             request.TupleKey.GetUser(),
+			Relation:         request.TupleKey.GetRelation(),
+			Object:           request.TupleKey.GetObject(),
+			ContextualTuples: contextualTuples(request.ContextualTuples),
+		})
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		reply(openfgaSDK.CheckResponse{Allowed: &allowed})
+	case len(parts) == 3 && parts[2] == "list-objects" && r.Method == http.MethodPost:
+		var request openfgaSDK.ListObjectsRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		evaluator, err := s.evaluator(parts[1], request.AuthorizationModelId)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		objects, err := evaluator.ListObjects(r.Context(), client.ClientListObjectsRequest{
+			User:             request.User,
+			Relation:         request.Relation,
+			Type:             request.Type,
+			ContextualTuples: contextualTuples(request.ContextualTuples),
+		})
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		reply(openfgaSDK.ListObjectsResponse{Objects: &objects})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// write applies a write request to the tuples of a store. Like OpenFGA, writes of existing tuples and deletes of
+// missing tuples fail the whole transaction.
+func (s *Server) write(storeID string, request openfgaSDK.WriteRequest) error {
+	keys := func(tupleKeys *openfgaSDK.TupleKeys) []client.ClientTupleKey {
+		var result []client.ClientTupleKey
+		if tupleKeys != nil {
+			for _, key := range tupleKeys.TupleKeys {
+				result = append(result, tupleKey(key))
+			}
+		}
+
+		return result
+	}
+
+	writes := keys(request.Writes)
+	deletes := keys(request.Deletes)
+	err := s.store(storeID).WriteTuples(context.Background(), writes, deletes)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	log := func(tuples []client.ClientTupleKey, operation openfgaSDK.TupleOperation) {
+		for _, tuple := range tuples {
+			key := openfgaSDK.TupleKey{User: openfgaSDK.PtrString(tuple.User), Relation: openfgaSDK.PtrString(tuple.Relation), Object: openfgaSDK.PtrString(tuple.Object)}
+			s.changes[storeID] = append(s.changes[storeID], openfgaSDK.TupleChange{TupleKey: &key, Operation: &operation, Timestamp: &now})
+		}
+	}
+
+	log(deletes, openfgaSDK.DELETE)
+	log(writes, openfgaSDK.WRITE)
+	return nil
+}
+
+// readChanges returns the page of changes of a store after the continuation token, optionally only those on objects of
+// the given type, and the token to read the next changes from. Like OpenFGA, the token of the end of the changelog is
+// returned once all changes have been read, so that later changes can be read from it. Tokens are offsets in the
+// changelog, and fail once the changes they point to have been pruned.
+func (s *Server) readChanges(storeID string, objectType string, continuationToken string) ([]openfgaSDK.TupleChange, string, error) {
+	start := s.pruned[storeID]
+	if continuationToken != "" {
+		offset, err := strconv.Atoi(continuationToken)
+		if err != nil || offset < start || offset > len(s.changes[storeID]) {
+			return nil, "", fmt.Errorf("Invalid continuation token")
+		}
+
+		start = offset
+	}
+
+	changes := []openfgaSDK.TupleChange{}
+	end := start
+	for end < len(s.changes[storeID]) && len(changes) < 2 {
+		change := s.changes[storeID][end]
+		end++
+		if objectType == "" || strings.HasPrefix(change.TupleKey.GetObject(), objectType+":") {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, strconv.Itoa(end), nil
+}
+
+// evaluator returns an Evaluator over the tuples of a store with the given authorization model, or the latest model of
+// the store if the ID is not set.
+func (s *Server) evaluator(storeID string, authModelID *string) (*openfga.Evaluator, error) {
+	for _, model := range s.models[storeID] {
+		if authModelID != nil && *authModelID != "" && model.GetId() != *authModelID {
+			continue
+		}
+
+		parsed, err := openfga.NewModel(model.SchemaVersion, model.GetTypeDefinitions())
+		if err != nil {
+			return nil, err
+		}
+
+		return openfga.NewEvaluator(parsed, s.store(storeID)), nil
+	}
+
+	return nil, fmt.Errorf("Authorization model not found")
+}
+
+// tupleKey converts a tuple key of the API to a tuple key of the client.
+func tupleKey(key openfgaSDK.TupleKey) client.ClientTupleKey {
+	return client.ClientTupleKey{User: key.GetUser(), Relation: key.GetRelation(), Object: key.GetObject()}
+}
+
+// contextualTuples converts the contextual tuples of a request.
+func contextualTuples(tupleKeys *openfgaSDK.ContextualTupleKeys) *[]client.ClientTupleKey {
+	if tupleKeys == nil {
+		return nil
+	}
+
+	tuples := make([]client.ClientTupleKey, 0, len(tupleKeys.TupleKeys))
+	for _, key := range tupleKeys.TupleKeys {
+		tuples = append(tuples, tupleKey(key))
+	}
+
+	return &tuples
+}
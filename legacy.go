@@ -0,0 +1,496 @@
+package openfga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// Roles of the legacy Canonical RBAC integration.
+const (
+	LegacyRoleAdmin                = "admin"
+	LegacyRoleView                 = "view"
+	LegacyRoleManageProjects       = "manage-projects"
+	LegacyRoleManageContainers     = "manage-containers"
+	LegacyRoleManageInstances      = "manage-instances"
+	LegacyRoleOperateContainers    = "operate-containers"
+	LegacyRoleOperateInstances     = "operate-instances"
+	LegacyRoleManageImages         = "manage-images"
+	LegacyRoleManageNetworks       = "manage-networks"
+	LegacyRoleManageProfiles       = "manage-profiles"
+	LegacyRoleManageStorageVolumes = "manage-storage-volumes"
+)
+
+// LegacyExport is an export of the authorization configuration of an LXD server that uses Canonical RBAC and
+// restricted TLS certificates.
+type LegacyExport struct {
+	RoleAssignments []LegacyRoleAssignment `json:"role_assignments"`
+	Certificates    []LegacyCertificate    `json:"certificates"`
+}
+
+// LegacyRoleAssignment is a Canonical RBAC role assigned to a user or group.
+type LegacyRoleAssignment struct {
+	// Subject is `user:<name>` or `group:<name>`.
+	Subject string `json:"subject"`
+
+	// Project is the project the role is assigned on. It is empty for the server-wide `admin` and `manage-projects`.
+	Project string `json:"project,omitempty"`
+
+	Role string `json:"role"`
+}
+
+// LegacyCertificate is a trusted TLS client certificate. Restricted certificates may only access the listed projects.
+type LegacyCertificate struct {
+	Fingerprint string   `json:"fingerprint"`
+	Restricted  bool     `json:"restricted"`
+	Projects    []string `json:"projects,omitempty"`
+}
+
+// LoadLegacyExport decodes a LegacyExport from JSON.
+func LoadLegacyExport(r io.Reader) (*LegacyExport, error) {
+	var export LegacyExport
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode legacy export: %w", err)
+	}
+
+	return &export, nil
+}
+
+// legacyRole describes what a legacy role allows and how it is converted.
+type legacyRole struct {
+	// serverWide is true for roles assigned on the server rather than on a project.
+	serverWide bool
+
+	// entitlements are the entitlements the role grants, by type, on the objects in its scope. A nil map grants every
+	// entitlement on every object.
+	entitlements map[ObjectType][]string
+
+	// scopeRelations are written for the subject on the server or project the role is assigned on.
+	scopeRelations []string
+
+	// resourceRelations are written for the subject on each existing resource of the type in the project. The first
+	// relation that is defined in the model is used.
+	resourceRelations map[ObjectType][]string
+
+	// note explains how the converted tuples differ from the legacy role, if they do.
+	note string
+}
+
+// projectViewEntitlements are granted by every project role. LXD lets anyone with a role on a project view the
+// project and its resources.
+var projectViewEntitlements = map[ObjectType][]string{
+	ObjectTypeProject:             {"can_view"},
+	ObjectTypeImage:               {"can_view"},
+	ObjectTypeInstance:            {"can_view"},
+	ObjectTypeNetwork:             {"can_view"},
+	ObjectTypeNetworkACL:          {"can_view"},
+	ObjectTypeNetworkZone:         {"can_view"},
+	ObjectTypeNetworkForward:      {"can_view"},
+	ObjectTypeNetworkLoadBalancer: {"can_view"},
+	ObjectTypeNetworkPeer:         {"can_view"},
+	ObjectTypeProfile:             {"can_view"},
+	ObjectTypeStoragePoolVolume:   {"can_view"},
+	ObjectTypeStorageBucket:       {"can_view"},
+}
+
+// newResourcesNote is the note for roles converted to grants on each existing resource.
+const newResourcesNote = "Converted to grants on existing resources; resources created later must be granted explicitly"
+
+var legacyRoles = map[string]legacyRole{
+	LegacyRoleAdmin: {
+		serverWide:     true,
+		scopeRelations: []string{"admin"},
+	},
+	LegacyRoleManageProjects: {
+		serverWide: true,
+		entitlements: map[ObjectType][]string{
+			ObjectTypeServer:  {"can_create_project"},
+			ObjectTypeProject: {"can_view", "can_edit"},
+		},
+		scopeRelations: []string{"operator"},
+		note:           "Converted to server operator, which also grants viewing server resources and managing the resources in every project",
+	},
+	LegacyRoleView: {
+		entitlements:   projectViewEntitlements,
+		scopeRelations: []string{"viewer"},
+	},
+	LegacyRoleManageContainers: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeProject:  {"can_create_instances"},
+			ObjectTypeInstance: {"can_edit", "can_update_state", "can_manage_snapshots", "can_manage_backups", "can_connect_sftp", "can_access_files", "can_access_console", "can_exec"},
+		}),
+		scopeRelations:    []string{"viewer", "can_create_instances"},
+		resourceRelations: map[ObjectType][]string{ObjectTypeInstance: {"manager"}},
+		note:              newResourcesNote,
+	},
+	LegacyRoleOperateContainers: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeInstance: {"can_update_state", "can_manage_snapshots", "can_manage_backups", "can_connect_sftp", "can_access_files", "can_access_console", "can_exec"},
+		}),
+		scopeRelations:    []string{"viewer"},
+		resourceRelations: map[ObjectType][]string{ObjectTypeInstance: {"operator"}},
+		note:              newResourcesNote,
+	},
+	LegacyRoleManageImages: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeProject: {"can_create_images"},
+			ObjectTypeImage:   {"can_edit"},
+		}),
+		scopeRelations:    []string{"viewer", "can_create_images"},
+		resourceRelations: map[ObjectType][]string{ObjectTypeImage: {"manager", "can_edit"}},
+		note:              newResourcesNote,
+	},
+	LegacyRoleManageNetworks: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeProject:             {"can_create_networks", "can_create_network_acls", "can_create_network_zones", "can_create_network_forwards", "can_create_network_load_balancers", "can_create_network_peers"},
+			ObjectTypeNetwork:             {"can_edit"},
+			ObjectTypeNetworkACL:          {"can_edit"},
+			ObjectTypeNetworkZone:         {"can_edit"},
+			ObjectTypeNetworkForward:      {"can_edit"},
+			ObjectTypeNetworkLoadBalancer: {"can_edit"},
+			ObjectTypeNetworkPeer:         {"can_edit"},
+		}),
+		scopeRelations: []string{"viewer", "can_create_networks", "can_create_network_acls", "can_create_network_zones", "can_create_network_forwards", "can_create_network_load_balancers", "can_create_network_peers"},
+		resourceRelations: map[ObjectType][]string{
+			ObjectTypeNetwork:             {"manager", "can_edit"},
+			ObjectTypeNetworkACL:          {"can_edit"},
+			ObjectTypeNetworkZone:         {"can_edit"},
+			ObjectTypeNetworkForward:      {"can_edit"},
+			ObjectTypeNetworkLoadBalancer: {"can_edit"},
+			ObjectTypeNetworkPeer:         {"can_edit"},
+		},
+		note: newResourcesNote,
+	},
+	LegacyRoleManageProfiles: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeProject: {"can_create_profiles"},
+			ObjectTypeProfile: {"can_edit"},
+		}),
+		scopeRelations:    []string{"viewer", "can_create_profiles"},
+		resourceRelations: map[ObjectType][]string{ObjectTypeProfile: {"can_edit"}},
+		note:              newResourcesNote,
+	},
+	LegacyRoleManageStorageVolumes: {
+		entitlements: withProjectView(map[ObjectType][]string{
+			ObjectTypeProject:           {"can_create_storage_pool_volumes", "can_create_storage_buckets"},
+			ObjectTypeStoragePoolVolume: {"can_edit"},
+			ObjectTypeStorageBucket:     {"can_edit"},
+		}),
+		scopeRelations:    []string{"viewer", "can_create_storage_pool_volumes", "can_create_storage_buckets"},
+		resourceRelations: map[ObjectType][]string{ObjectTypeStoragePoolVolume: {"can_edit"}, ObjectTypeStorageBucket: {"can_edit"}},
+		note:              newResourcesNote,
+	},
+}
+
+// legacyRoleAliases maps the names used before LXD renamed containers to instances.
+var legacyRoleAliases = map[string]string{
+	LegacyRoleManageInstances:  LegacyRoleManageContainers,
+	LegacyRoleOperateInstances: LegacyRoleOperateContainers,
+}
+
+// restrictedCertificateRole is the role of a restricted TLS certificate on each of its projects. A restricted client
+// can create and manage all resources in its projects but cannot change their configuration.
+var restrictedCertificateRole = legacyRole{
+	scopeRelations: []string{"operator"},
+}
+
+// unrestrictedCertificateRole is the role of an unrestricted TLS certificate.
+var unrestrictedCertificateRole = legacyRole{
+	serverWide:     true,
+	scopeRelations: []string{"admin"},
+}
+
+// withProjectView adds projectViewEntitlements to the given entitlements.
+func withProjectView(entitlements map[ObjectType][]string) map[ObjectType][]string {
+	result := make(map[ObjectType][]string, len(projectViewEntitlements))
+	for objectType, relations := range projectViewEntitlements {
+		result[objectType] = append(result[objectType], relations...)
+	}
+
+	for objectType, relations := range entitlements {
+		result[objectType] = append(result[objectType], relations...)
+	}
+
+	return result
+}
+
+// LegacyConversionNote records a role that could not be converted, or not converted exactly.
+type LegacyConversionNote struct {
+	Subject string
+	Project string
+	Role    string
+	Message string
+}
+
+// String implements fmt.Stringer.
+func (n LegacyConversionNote) String() string {
+	scope := "server"
+	if n.Project != "" {
+		scope = "project " + n.Project
+	}
+
+	return fmt.Sprintf("%s %s on %s: %s", n.Subject, n.Role, scope, n.Message)
+}
+
+// LegacyConversion is the result of ConvertLegacy.
+type LegacyConversion struct {
+	Tuples []client.ClientTupleKey
+	Notes  []LegacyConversionNote
+}
+
+// legacyGrant is a role assigned to a subject on the server (empty project) or a project.
+type legacyGrant struct {
+	subject string
+	project string
+	name    string
+	role    legacyRole
+}
+
+// ConvertLegacy converts the Canonical RBAC role assignments and TLS certificates of an LXD server to tuples. Roles that
+// grant access to individual resources are converted to grants on the resources listed in the inventory. Anything that
+// cannot be mapped exactly is listed in the notes of the result. The tuples are on the objects of the given cluster.
+func ConvertLegacy(model *Model, cluster Cluster, export LegacyExport, inventory Inventory) (*LegacyConversion, error) {
+	grants, notes, err := legacyGrants(export)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples := make(map[client.ClientTupleKey]struct{})
+	for _, grant := range grants {
+		user := grant.subject
+		if strings.HasPrefix(user, string(ObjectTypeGroup)+":") {
+			user += "#member"
+		}
+
+		scope := cluster.Server()
+		if !grant.role.serverWide {
+			scope = cluster.Project(grant.project)
+		}
+
+		for _, relation := range grant.role.scopeRelations {
+			tuples[client.ClientTupleKey{User: user, Relation: relation, Object: scope.String()}] = struct{}{}
+		}
+
+		for objectType, candidates := range grant.role.resourceRelations {
+			relation := ""
+			for _, candidate := range candidates {
+				if model.HasRelation(string(objectType), candidate) {
+					relation = candidate
+					break
+				}
+			}
+
+			if relation == "" {
+				return nil, fmt.Errorf("Model has none of the relations %v on type %q", candidates, objectType)
+			}
+
+			for _, entry := range inventory[objectType] {
+				if entry.Project != grant.project {
+					continue
+				}
+
+				object := cluster.ProjectResource(objectType, entry.Project, entry.Name)
+				tuples[client.ClientTupleKey{User: user, Relation: relation, Object: object.String()}] = struct{}{}
+			}
+		}
+
+		if grant.role.note != "" {
+			notes = append(notes, LegacyConversionNote{Subject: grant.subject, Project: grant.project, Role: grant.name, Message: grant.role.note})
+		}
+	}
+
+	conversion := &LegacyConversion{Notes: notes}
+	for tuple := range tuples {
+		conversion.Tuples = append(conversion.Tuples, tuple)
+	}
+
+	err = ValidateTuples(model, conversion.Tuples...)
+	if err != nil {
+		return nil, err
+	}
+
+	sortTuples(conversion.Tuples)
+	return conversion, nil
+}
+
+// legacyGrants resolves the role assignments and certificates of an export, and notes those that cannot be converted.
+func legacyGrants(export LegacyExport) ([]legacyGrant, []LegacyConversionNote, error) {
+	var grants []legacyGrant
+	var notes []LegacyConversionNote
+	for _, assignment := range export.RoleAssignments {
+		subjectType, subjectName, _ := strings.Cut(assignment.Subject, ":")
+		if subjectName == "" || (subjectType != string(ObjectTypeUser) && subjectType != string(ObjectTypeGroup)) {
+			return nil, nil, fmt.Errorf("Invalid role assignment subject %q: Must be of the form user:<name> or group:<name>", assignment.Subject)
+		}
+
+		name := assignment.Role
+		alias, ok := legacyRoleAliases[name]
+		if ok {
+			name = alias
+		}
+
+		role, ok := legacyRoles[name]
+		if !ok {
+			notes = append(notes, LegacyConversionNote{Subject: assignment.Subject, Project: assignment.Project, Role: assignment.Role, Message: "Unknown role, not converted"})
+			continue
+		}
+
+		if role.serverWide && assignment.Project != "" {
+			notes = append(notes, LegacyConversionNote{Subject: assignment.Subject, Project: assignment.Project, Role: assignment.Role, Message: "Server-wide role assigned on a project, not converted"})
+			continue
+		}
+
+		if !role.serverWide && assignment.Project == "" {
+			notes = append(notes, LegacyConversionNote{Subject: assignment.Subject, Role: assignment.Role, Message: "Project role assigned without a project, not converted"})
+			continue
+		}
+
+		grants = append(grants, legacyGrant{subject: assignment.Subject, project: assignment.Project, name: name, role: role})
+	}
+
+	for _, certificate := range export.Certificates {
+		subject := Object{Type: ObjectTypeUser, Name: certificate.Fingerprint}.String()
+		if !certificate.Restricted {
+			grants = append(grants, legacyGrant{subject: subject, name: "unrestricted certificate", role: unrestrictedCertificateRole})
+			continue
+		}
+
+		if len(certificate.Projects) == 0 {
+			notes = append(notes, LegacyConversionNote{Subject: subject, Role: "restricted certificate", Message: "Restricted certificate without projects has no access, not converted"})
+			continue
+		}
+
+		for _, project := range certificate.Projects {
+			grants = append(grants, legacyGrant{subject: subject, project: project, name: "restricted certificate", role: restrictedCertificateRole})
+		}
+	}
+
+	return grants, notes, nil
+}
+
+// allows returns true if the legacy grant allows the entitlement on the object.
+func (g legacyGrant) allows(object Object, entitlement string) bool {
+	if !g.role.serverWide && object.Project != g.project && !(object.Type == ObjectTypeProject && object.Name == g.project) {
+		return false
+	}
+
+	if g.role.entitlements == nil {
+		// Restricted certificates cannot edit the configuration of their projects.
+		return g.role.serverWide || object.Type != ObjectTypeProject || entitlement != "can_edit"
+	}
+
+	for _, allowed := range g.role.entitlements[object.Type] {
+		if allowed == entitlement {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LegacyMismatch is an entitlement on which the legacy configuration and the converted tuples disagree.
+type LegacyMismatch struct {
+	Subject     string
+	Entitlement string
+	Object      string
+	Legacy      bool
+	Converted   bool
+}
+
+// String implements fmt.Stringer.
+func (m LegacyMismatch) String() string {
+	return fmt.Sprintf("%s %s %s: legacy=%t converted=%t", m.Subject, m.Entitlement, m.Object, m.Legacy, m.Converted)
+}
+
+// VerifyLegacyConversion checks that every subject of the export gets the same effective permissions from the
+// converted tuples as from the legacy configuration. Every `can_*` entitlement is checked on every object in the
+// inventory using the in-process Evaluator, and the disagreements are returned. Roles that have a note in the
+// conversion are expected to disagree.
+func VerifyLegacyConversion(ctx context.Context, model *Model, cluster Cluster, export LegacyExport, inventory Inventory, conversion *LegacyConversion) ([]LegacyMismatch, error) {
+	grants, _, err := legacyGrants(export)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, links, err := inventoryObjects(model, cluster, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	store := NewMemoryStore(conversion.Tuples...)
+	for link := range links {
+		store.add(link)
+	}
+
+	// Every authenticated user can view the server.
+	store.add(client.ClientTupleKey{User: Object{Type: ObjectTypeUser, Name: "*"}.String(), Relation: "user", Object: cluster.Server().String()})
+
+	evaluator := NewEvaluator(model, store)
+
+	subjects := make(map[string][]legacyGrant)
+	for _, grant := range grants {
+		subjects[grant.subject] = append(subjects[grant.subject], grant)
+	}
+
+	objects := make([]string, 0, len(existing))
+	for object := range existing {
+		objects = append(objects, object)
+	}
+
+	sort.Strings(objects)
+
+	var mismatches []LegacyMismatch
+	for subject, subjectGrants := range subjects {
+		user := subject
+		if strings.HasPrefix(user, string(ObjectTypeGroup)+":") {
+			user += "#member"
+		}
+
+		for _, objectName := range objects {
+			object, err := ParseObject(objectName)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, entitlement := range model.Relations(string(object.Type)) {
+				if !strings.HasPrefix(entitlement, "can_") {
+					continue
+				}
+
+				legacy := false
+				for _, grant := range subjectGrants {
+					if grant.allows(object, entitlement) {
+						legacy = true
+						break
+					}
+				}
+
+				// Every authenticated user can view the server.
+				if object.Type == ObjectTypeServer && entitlement == string(EntitlementCanViewServer) && strings.HasPrefix(subject, string(ObjectTypeUser)+":") {
+					legacy = true
+				}
+
+				converted, err := evaluator.Check(ctx, client.ClientCheckRequest{User: user, Relation: entitlement, Object: objectName})
+				if err != nil {
+					return nil, err
+				}
+
+				if legacy != converted {
+					mismatches = append(mismatches, LegacyMismatch{Subject: subject, Entitlement: entitlement, Object: objectName, Legacy: legacy, Converted: converted})
+				}
+			}
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].String() < mismatches[j].String()
+	})
+
+	return mismatches, nil
+}
@@ -0,0 +1,121 @@
+package openfga
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyTestInventory = `{
+	"project": [{"name": "project01"}, {"name": "project02"}],
+	"storage_pool": [{"name": "pool01"}],
+	"certificate": [{"name": "eeef45f0570c"}],
+	"image": [{"project": "project01", "name": "image01"}],
+	"instance": [{"project": "project01", "name": "instance01"}, {"project": "project02", "name": "instance01"}],
+	"network": [{"project": "project01", "name": "network01"}],
+	"network_acl": [{"project": "project01", "name": "network_acl01"}],
+	"profile": [{"project": "project01", "name": "default"}, {"project": "project02", "name": "default"}],
+	"storage_pool_volume": [{"project": "project01", "name": "volume01"}],
+	"storage_bucket": [{"project": "project02", "name": "bucket01"}]
+}`
+
+func TestConvertLegacy(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	inventory, err := LoadInventory(strings.NewReader(legacyTestInventory))
+	require.NoError(t, err)
+
+	export, err := LoadLegacyExport(strings.NewReader(`{
+		"role_assignments": [
+			{"subject": "user:admin", "role": "admin"},
+			{"subject": "group:viewers", "project": "project01", "role": "view"},
+			{"subject": "user:developer", "project": "project01", "role": "manage-instances"},
+			{"subject": "user:developer", "project": "project01", "role": "manage-images"},
+			{"subject": "user:support", "project": "project01", "role": "operate-containers"},
+			{"subject": "user:netadmin", "project": "project01", "role": "manage-networks"},
+			{"subject": "user:storage", "project": "project02", "role": "manage-storage-volumes"},
+			{"subject": "user:profiles", "project": "project02", "role": "manage-profiles"},
+			{"subject": "user:projects", "role": "manage-projects"},
+			{"subject": "user:typo", "project": "project01", "role": "manage-everything"},
+			{"subject": "user:misplaced", "project": "project01", "role": "admin"}
+		],
+		"certificates": [
+			{"fingerprint": "trusted", "restricted": false},
+			{"fingerprint": "restricted", "restricted": true, "projects": ["project02"]},
+			{"fingerprint": "locked", "restricted": true}
+		]
+	}`))
+	require.NoError(t, err)
+
+	conversion, err := ConvertLegacy(model, "", *export, inventory)
+	require.NoError(t, err)
+
+	expected := []client.ClientTupleKey{
+		{User: "user:developer", Relation: "manager", Object: "image:project01/image01"},
+		{User: "user:developer", Relation: "manager", Object: "instance:project01/instance01"},
+		{User: "user:support", Relation: "operator", Object: "instance:project01/instance01"},
+		{User: "user:netadmin", Relation: "manager", Object: "network:project01/network01"},
+		{User: "user:netadmin", Relation: "can_edit", Object: "network_acl:project01/network_acl01"},
+		{User: "user:profiles", Relation: "can_edit", Object: "profile:project02/default"},
+		{User: "user:developer", Relation: "can_create_images", Object: "project:project01"},
+		{User: "user:developer", Relation: "can_create_instances", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_network_acls", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_network_forwards", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_network_load_balancers", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_network_peers", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_network_zones", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "can_create_networks", Object: "project:project01"},
+		{User: "group:viewers#member", Relation: "viewer", Object: "project:project01"},
+		{User: "user:developer", Relation: "viewer", Object: "project:project01"},
+		{User: "user:netadmin", Relation: "viewer", Object: "project:project01"},
+		{User: "user:support", Relation: "viewer", Object: "project:project01"},
+		{User: "user:profiles", Relation: "can_create_profiles", Object: "project:project02"},
+		{User: "user:storage", Relation: "can_create_storage_buckets", Object: "project:project02"},
+		{User: "user:storage", Relation: "can_create_storage_pool_volumes", Object: "project:project02"},
+		{User: "user:restricted", Relation: "operator", Object: "project:project02"},
+		{User: "user:profiles", Relation: "viewer", Object: "project:project02"},
+		{User: "user:storage", Relation: "viewer", Object: "project:project02"},
+		{User: "user:admin", Relation: "admin", Object: "server:lxd"},
+		{User: "user:trusted", Relation: "admin", Object: "server:lxd"},
+		{User: "user:projects", Relation: "operator", Object: "server:lxd"},
+		{User: "user:storage", Relation: "can_edit", Object: "storage_bucket:project02/bucket01"},
+	}
+
+	require.Equal(t, expected, conversion.Tuples)
+
+	notes := make(map[string]string)
+	for _, note := range conversion.Notes {
+		notes[note.Subject+" "+note.Role] = note.Message
+	}
+
+	require.Equal(t, "Unknown role, not converted", notes["user:typo manage-everything"])
+	require.Equal(t, "Server-wide role assigned on a project, not converted", notes["user:misplaced admin"])
+	require.Equal(t, "Restricted certificate without projects has no access, not converted", notes["user:locked restricted certificate"])
+	require.Equal(t, newResourcesNote, notes["user:developer manage-containers"])
+	require.Contains(t, notes, "user:projects manage-projects")
+	require.NotContains(t, notes, "user:admin admin")
+	require.NotContains(t, notes, "group:viewers view")
+
+	mismatches, err := VerifyLegacyConversion(context.Background(), model, "", *export, inventory, conversion)
+	require.NoError(t, err)
+	require.NotEmpty(t, mismatches)
+
+	// Only the inexact manage-projects conversion may change effective permissions, and only by widening them.
+	for _, mismatch := range mismatches {
+		require.Equal(t, "user:projects", mismatch.Subject, mismatch.String())
+		require.False(t, mismatch.Legacy, mismatch.String())
+	}
+}
+
+func TestConvertLegacyInvalidSubject(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	export := LegacyExport{RoleAssignments: []LegacyRoleAssignment{{Subject: "alice", Role: LegacyRoleAdmin}}}
+	_, err = ConvertLegacy(model, "", export, Inventory{})
+	require.EqualError(t, err, `Invalid role assignment subject "alice": Must be of the form user:<name> or group:<name>`)
+}
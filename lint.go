@@ -0,0 +1,284 @@
+package openfga
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+)
+
+// LintViolation is a violation of a LintRule.
+type LintViolation struct {
+	Rule     string `json:"rule"`
+	Type     string `json:"type"`
+	Relation string `json:"relation,omitempty"`
+
+	// Position is the position of the relation, or of the type if the relation is missing. It is only valid for models
+	// parsed with ParseDSL.
+	Position Position `json:"position"`
+	Message  string   `json:"message"`
+}
+
+// String implements fmt.Stringer.
+func (v LintViolation) String() string {
+	subject := v.Type
+	if v.Relation != "" {
+		subject += "#" + v.Relation
+	}
+
+	if !v.Position.IsValid() {
+		return fmt.Sprintf("%s: %s: %s", v.Rule, subject, v.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s: %s", v.Position, v.Rule, subject, v.Message)
+}
+
+// LintRule is a convention that the model must follow.
+type LintRule interface {
+	// Name is the name of the rule in violations.
+	Name() string
+
+	// Check returns the violations of the rule. Positions are filled in by Lint.
+	Check(model *Model) []LintViolation
+}
+
+// LXDLintRules are the conventions of the LXD model described in the README.
+var LXDLintRules = []LintRule{
+	LeafRelations{
+		Relations: []string{"manager", "viewer", "can_edit", "can_view"},
+		// These types have no roles yet, only entitlements. Remove a type once it defines the roles.
+		Except: map[string]string{
+			"network_acl":           "ACLs are applied to the instances and networks of the whole project, so access to them follows the project.",
+			"network_zone":          "Zones are attached to networks by project operators, and LXD does not delegate zones to other users.",
+			"network_forward":       "Forwards are part of a network, and should inherit its roles once they are linked to it rather than to the project.",
+			"network_load_balancer": "Load balancers are part of a network, and should inherit its roles once they are linked to it rather than to the project.",
+			"network_peer":          "Peers are part of a network, and should inherit its roles once they are linked to it rather than to the project.",
+			"profile":               "Profiles are shared by the instances of a project, and are edited by its operators.",
+			"storage_pool_volume":   "Volumes of instances follow their instance, and custom volumes are only granted entitlements so far.",
+			"storage_bucket":        "Bucket contents are accessed with S3 keys managed by LXD, not with these roles.",
+		},
+	},
+	InheritedEntitlements{},
+	RequiredParentRelation{Type: "project", Prefix: "can_create_", Tupleset: "server", Relation: "operator"},
+}
+
+// Lint checks the model against the rules and returns the violations, sorted by position and then by type and
+// relation.
+func Lint(model *Model, rules []LintRule) []LintViolation {
+	var violations []LintViolation
+	for _, rule := range rules {
+		for _, violation := range rule.Check(model) {
+			violation.Rule = rule.Name()
+			position, ok := model.Position(violation.Type, violation.Relation)
+			if !ok {
+				position, _ = model.Position(violation.Type, "")
+			}
+
+			violation.Position = position
+			violations = append(violations, violation)
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Position != b.Position {
+			return a.Position.Line < b.Position.Line || (a.Position.Line == b.Position.Line && a.Position.Column < b.Position.Column)
+		}
+
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		return a.Relation < b.Relation
+	})
+
+	return violations
+}
+
+// leafTypes returns the types that have a parent but no children, in model order.
+func (m *Model) leafTypes() []string {
+	parents := make(map[string]struct{})
+	for _, objectType := range m.Types() {
+		_, parentType, ok := m.ParentRelation(objectType)
+		if ok {
+			parents[parentType] = struct{}{}
+		}
+	}
+
+	var leaves []string
+	for _, objectType := range m.Types() {
+		_, _, ok := m.ParentRelation(objectType)
+		_, isParent := parents[objectType]
+		if ok && !isParent {
+			leaves = append(leaves, objectType)
+		}
+	}
+
+	return leaves
+}
+
+// LeafRelations requires every leaf resource type (a type with a parent and no children) to define the given
+// relations.
+type LeafRelations struct {
+	Relations []string
+
+	// Except maps the types that are not checked to the reason why. An exception is a violation once the type is not
+	// a leaf type or defines all the relations, so that it is removed.
+	Except map[string]string
+}
+
+// Name implements LintRule.
+func (r LeafRelations) Name() string {
+	return "leaf-relations"
+}
+
+// Check implements LintRule.
+func (r LeafRelations) Check(model *Model) []LintViolation {
+	var violations []LintViolation
+	leaves := make(map[string]bool)
+	for _, objectType := range model.leafTypes() {
+		leaves[objectType] = true
+		_, excepted := r.Except[objectType]
+		missing := false
+		for _, relation := range r.Relations {
+			if !model.HasRelation(objectType, relation) {
+				missing = true
+				if !excepted {
+					violations = append(violations, LintViolation{Type: objectType, Message: fmt.Sprintf("Missing relation %q", relation)})
+				}
+			}
+		}
+
+		if excepted && !missing {
+			violations = append(violations, LintViolation{Type: objectType, Message: "Excepted but defines all relations"})
+		}
+	}
+
+	for _, objectType := range sortedKeys(r.Except) {
+		if !leaves[objectType] {
+			violations = append(violations, LintViolation{Type: objectType, Message: "Excepted but not a leaf type"})
+		}
+	}
+
+	return violations
+}
+
+// InheritedEntitlements requires every entitlement (`can_*`) of a type with a parent to be granted through the parent,
+// either directly (`operator from project`) or through another relation of the type.
+type InheritedEntitlements struct {
+	// Except lists relations (`type#relation`) that are not checked.
+	Except []string
+}
+
+// Name implements LintRule.
+func (r InheritedEntitlements) Name() string {
+	return "inherited-entitlements"
+}
+
+// Check implements LintRule.
+func (r InheritedEntitlements) Check(model *Model) []LintViolation {
+	fromParent := make(map[string]struct{})
+	incoming := make(map[string][]graphEdge)
+	for _, edge := range model.graphEdges() {
+		if edge.label == graphEdgeFromParent {
+			fromParent[edge.to] = struct{}{}
+		}
+
+		incoming[edge.to] = append(incoming[edge.to], edge)
+	}
+
+	// inherits returns true if the relation is granted from the parent, following computed relations of the type.
+	visited := make(map[string]bool)
+	var inherits func(relation string) bool
+	inherits = func(relation string) bool {
+		result, ok := visited[relation]
+		if ok {
+			return result
+		}
+
+		visited[relation] = false
+		_, ok = fromParent[relation]
+		if !ok {
+			for _, edge := range incoming[relation] {
+				if edge.label == graphEdgeComputed && inherits(edge.from) {
+					ok = true
+					break
+				}
+			}
+		}
+
+		visited[relation] = ok
+		return ok
+	}
+
+	var violations []LintViolation
+	for _, objectType := range model.Types() {
+		_, parentType, ok := model.ParentRelation(objectType)
+		if !ok {
+			continue
+		}
+
+		for _, relation := range model.Relations(objectType) {
+			if !strings.HasPrefix(relation, "can_") || containsString(r.Except, objectType+"#"+relation) {
+				continue
+			}
+
+			if !inherits(objectType + "#" + relation) {
+				violations = append(violations, LintViolation{Type: objectType, Relation: relation, Message: fmt.Sprintf("Not granted through the parent %s", parentType)})
+			}
+		}
+	}
+
+	return violations
+}
+
+// RequiredParentRelation requires the relations of a type with the given prefix to include `<Relation> from
+// <Tupleset>` in their rewrite, e.g. `operator from server` for `project#can_create_*`.
+type RequiredParentRelation struct {
+	Type     string
+	Prefix   string
+	Tupleset string
+	Relation string
+}
+
+// Name implements LintRule.
+func (r RequiredParentRelation) Name() string {
+	return "required-parent-relation"
+}
+
+// Check implements LintRule.
+func (r RequiredParentRelation) Check(model *Model) []LintViolation {
+	var violations []LintViolation
+	for _, relation := range model.Relations(r.Type) {
+		if !strings.HasPrefix(relation, r.Prefix) {
+			continue
+		}
+
+		found := false
+		userset, _ := model.Userset(r.Type, relation)
+		walkUserset(userset, func(child openfgaSDK.Userset) {
+			ttu := child.TupleToUserset
+			if ttu != nil && ttu.Tupleset.GetRelation() == r.Tupleset && ttu.ComputedUserset.GetRelation() == r.Relation {
+				found = true
+			}
+		})
+
+		if !found {
+			violations = append(violations, LintViolation{Type: r.Type, Relation: relation, Message: fmt.Sprintf("Must include %q", r.Relation+" from "+r.Tupleset)})
+		}
+	}
+
+	return violations
+}
+
+// containsString returns true if the slice contains the string.
+func containsString(slice []string, s string) bool {
+	for _, element := range slice {
+		if element == s {
+			return true
+		}
+	}
+
+	return false
+}
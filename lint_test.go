@@ -0,0 +1,83 @@
+package openfga
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	model, err := ParseDSL([]byte(`model
+  schema 1.1
+type user
+type server
+  relations
+    define operator: [user]
+type project
+  relations
+    define server: [server]
+    define operator: [user] or operator from server
+    define can_create_instances: [user] or operator
+    define can_create_images: [user]
+type instance
+  relations
+    define project: [project]
+    define manager: [user]
+    define can_edit: manager or operator from project
+    define can_view: manager
+type profile
+  relations
+    define project: [project]
+    define can_edit: [user] or operator from project
+`))
+	require.NoError(t, err)
+
+	rules := []LintRule{
+		LeafRelations{Relations: []string{"manager", "can_edit"}, Except: map[string]string{"instance": "Instances are not checked.", "project": "Projects are not checked."}},
+		InheritedEntitlements{},
+		RequiredParentRelation{Type: "project", Prefix: "can_create_", Tupleset: "server", Relation: "operator"},
+	}
+
+	formatted := func(violations []LintViolation) []string {
+		result := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			result = append(result, violation.String())
+		}
+
+		return result
+	}
+
+	require.Equal(t, []string{
+		`7:1: leaf-relations: project: Excepted but not a leaf type`,
+		`11:5: required-parent-relation: project#can_create_instances: Must include "operator from server"`,
+		`12:5: inherited-entitlements: project#can_create_images: Not granted through the parent server`,
+		`12:5: required-parent-relation: project#can_create_images: Must include "operator from server"`,
+		`13:1: leaf-relations: instance: Excepted but defines all relations`,
+		`18:5: inherited-entitlements: instance#can_view: Not granted through the parent project`,
+		`19:1: leaf-relations: profile: Missing relation "manager"`,
+	}, formatted(Lint(model, rules)))
+
+	rules[0] = LeafRelations{Relations: []string{"manager", "can_edit"}, Except: map[string]string{"profile": "Profiles have no roles yet."}}
+	rules[1] = InheritedEntitlements{Except: []string{"project#can_create_images", "instance#can_view"}}
+	require.Len(t, Lint(model, rules), 2)
+
+	// Models parsed from JSON have no positions. Network ACLs have no roles, and are excepted in LXDLintRules.
+	defaultModel, err := DefaultModel()
+	require.NoError(t, err)
+	violations := Lint(defaultModel, []LintRule{LeafRelations{Relations: []string{"manager"}}})
+	require.NotEmpty(t, violations)
+	require.Equal(t, `leaf-relations: network_acl: Missing relation "manager"`, violations[0].String())
+}
+
+func TestLintLXDModel(t *testing.T) {
+	data, err := os.ReadFile("lxd.openfga")
+	require.NoError(t, err)
+
+	model, err := ParseDSL(data)
+	require.NoError(t, err)
+
+	for _, violation := range Lint(model, LXDLintRules) {
+		t.Errorf("lxd.openfga:%s", violation)
+	}
+}
@@ -0,0 +1,261 @@
+package openfga
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// MatrixFormat is an output format of a RoleMatrix.
+type MatrixFormat string
+
+const (
+	// MatrixMarkdown is a Markdown table.
+	MatrixMarkdown MatrixFormat = "markdown"
+
+	// MatrixHTML is an HTML table.
+	MatrixHTML MatrixFormat = "html"
+
+	// MatrixCSV is a CSV file with a column of booleans for each role.
+	MatrixCSV MatrixFormat = "csv"
+)
+
+// matrixSubject is the user that is granted each role when computing a RoleMatrix.
+const matrixSubject = "user:subject"
+
+// RoleMatrix lists the entitlements granted by each role of a model.
+type RoleMatrix struct {
+	// Roles are the relations (e.g. `project#operator`) that can be granted to a user and grant at least one
+	// entitlement, in the order of their types in the model.
+	Roles []string
+
+	// Entitlements are the relations starting with `can_` (e.g. `instance#can_exec`), in the order of their types in
+	// the model.
+	Entitlements []string
+
+	// granted is the set of roles that grant each entitlement.
+	granted map[string]map[string]struct{}
+}
+
+// NewRoleMatrix computes the RoleMatrix of the model. The canonical fixture of MatrixFixture is loaded into an
+// Evaluator, and for each role a user is granted the role on the fixture object of its type and checked for every
+// entitlement on the fixture object of each type. An entitlement is granted by a role if the check succeeds.
+func NewRoleMatrix(ctx context.Context, model *Model) (*RoleMatrix, error) {
+	fixture := MatrixFixture(model)
+	evaluator := NewEvaluator(model, NewMemoryStore(fixture...))
+	implications := model.implications()
+
+	matrix := &RoleMatrix{granted: make(map[string]map[string]struct{})}
+	for _, objectType := range model.Types() {
+		for _, relation := range model.Relations(objectType) {
+			if strings.HasPrefix(relation, "can_") {
+				matrix.Entitlements = append(matrix.Entitlements, objectType+"#"+relation)
+				continue
+			}
+
+			// Roles must be assignable to a user and grant an entitlement.
+			if !model.allowsUser(objectType, relation) {
+				continue
+			}
+
+			reached, _ := reach(implications, objectType+"#"+relation)
+			for implied := range reached {
+				_, name, _ := strings.Cut(implied, "#")
+				if strings.HasPrefix(name, "can_") {
+					matrix.Roles = append(matrix.Roles, objectType+"#"+relation)
+					break
+				}
+			}
+		}
+	}
+
+	for _, role := range matrix.Roles {
+		roleType, roleRelation, _ := strings.Cut(role, "#")
+		contextualTuples := []client.ClientTupleKey{{User: matrixSubject, Relation: roleRelation, Object: matrixObject(model, roleType)}}
+		for _, entitlement := range matrix.Entitlements {
+			objectType, relation, _ := strings.Cut(entitlement, "#")
+			allowed, err := evaluator.Check(ctx, client.ClientCheckRequest{
+				User:             matrixSubject,
+				Relation:         relation,
+				Object:           matrixObject(model, objectType),
+				ContextualTuples: &contextualTuples,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Failed to check %q for %q: %w", entitlement, role, err)
+			}
+
+			if allowed {
+				if matrix.granted[entitlement] == nil {
+					matrix.granted[entitlement] = make(map[string]struct{})
+				}
+
+				matrix.granted[entitlement][role] = struct{}{}
+			}
+		}
+	}
+
+	return matrix, nil
+}
+
+// MatrixFixture returns the canonical tuples used to compute a RoleMatrix. There is a single object of each type with
+// a parent (`server:lxd`, `project:default`, `certificate:example`, `instance:default/example` and so on), linked to
+// the single object of its parent type. The objects are also linked to `project:default` through every other relation
+// to a project, such as `target_project` on `storage_pool` and `image_consumer` on `project`, so that the matrix shows
+// what the roles of a project are granted on the resources it can use. As in a store initialised by
+// `lxd-fga store init`, all users can access the server, and are not granted anything else.
+func MatrixFixture(model *Model) []client.ClientTupleKey {
+	tuples := []client.ClientTupleKey{{User: UserSubject("*"), Relation: string(RoleUser), Object: ServerObject().String()}}
+	for _, objectType := range model.Types() {
+		parentRelation, parentType, ok := model.ParentRelation(objectType)
+		if ok {
+			tuples = append(tuples, client.ClientTupleKey{User: matrixObject(model, parentType), Relation: parentRelation, Object: matrixObject(model, objectType)})
+		}
+
+		for _, relation := range model.Relations(objectType) {
+			relatedTypes := model.DirectlyRelatedUserTypes(objectType, relation)
+			if relation == parentRelation || len(relatedTypes) != 1 || relatedTypes[0].Type != string(ObjectTypeProject) || relatedTypes[0].Relation != nil || relatedTypes[0].Wildcard != nil {
+				continue
+			}
+
+			tuples = append(tuples, client.ClientTupleKey{User: matrixObject(model, string(ObjectTypeProject)), Relation: relation, Object: matrixObject(model, objectType)})
+		}
+	}
+
+	return tuples
+}
+
+// matrixObject returns the fixture object of the given type.
+func matrixObject(model *Model, objectType string) string {
+	switch ObjectType(objectType) {
+	case ObjectTypeServer:
+		return ServerObject().String()
+	case ObjectTypeProject:
+		return ProjectObject("default").String()
+	}
+
+	_, parentType, ok := model.ParentRelation(objectType)
+	if ok && ObjectType(parentType) == ObjectTypeProject {
+		return ProjectResourceObject(ObjectType(objectType), "default", "example").String()
+	}
+
+	return objectType + ":example"
+}
+
+// allowsUser returns true if a single user may be written directly against the relation.
+func (m *Model) allowsUser(objectType string, relation string) bool {
+	for _, reference := range m.DirectlyRelatedUserTypes(objectType, relation) {
+		if reference.Type == string(ObjectTypeUser) && reference.Relation == nil && reference.Wildcard == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Granted returns true if the role grants the entitlement.
+func (m *RoleMatrix) Granted(role string, entitlement string) bool {
+	_, ok := m.granted[entitlement][role]
+	return ok
+}
+
+// Encode writes the matrix to w in the given format, with a row for each entitlement and a column for each role.
+func (m *RoleMatrix) Encode(w io.Writer, format MatrixFormat) error {
+	switch format {
+	case MatrixMarkdown:
+		return m.encodeMarkdown(w)
+	case MatrixHTML:
+		return m.encodeHTML(w)
+	case MatrixCSV:
+		return m.encodeCSV(w)
+	}
+
+	return fmt.Errorf("Unknown matrix format %q", format)
+}
+
+// encodeMarkdown writes the matrix as a Markdown table.
+func (m *RoleMatrix) encodeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Entitlement |")
+	for _, role := range m.Roles {
+		fmt.Fprintf(&b, " `%s` |", role)
+	}
+
+	b.WriteString("\n|---|")
+	for range m.Roles {
+		b.WriteString(":---:|")
+	}
+
+	b.WriteString("\n")
+	for _, entitlement := range m.Entitlements {
+		fmt.Fprintf(&b, "| `%s` |", entitlement)
+		for _, role := range m.Roles {
+			if m.Granted(role, entitlement) {
+				b.WriteString(" ✓ |")
+			} else {
+				b.WriteString("   |")
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// encodeHTML writes the matrix as an HTML table.
+func (m *RoleMatrix) encodeHTML(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("<table>\n  <thead>\n    <tr>\n      <th>Entitlement</th>\n")
+	for _, role := range m.Roles {
+		fmt.Fprintf(&b, "      <th>%s</th>\n", html.EscapeString(role))
+	}
+
+	b.WriteString("    </tr>\n  </thead>\n  <tbody>\n")
+	for _, entitlement := range m.Entitlements {
+		fmt.Fprintf(&b, "    <tr>\n      <th>%s</th>\n", html.EscapeString(entitlement))
+		for _, role := range m.Roles {
+			if m.Granted(role, entitlement) {
+				b.WriteString("      <td>✓</td>\n")
+			} else {
+				b.WriteString("      <td></td>\n")
+			}
+		}
+
+		b.WriteString("    </tr>\n")
+	}
+
+	b.WriteString("  </tbody>\n</table>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// encodeCSV writes the matrix as CSV.
+func (m *RoleMatrix) encodeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(append([]string{"entitlement"}, m.Roles...))
+	if err != nil {
+		return fmt.Errorf("Failed to encode matrix: %w", err)
+	}
+
+	for _, entitlement := range m.Entitlements {
+		row := []string{entitlement}
+		for _, role := range m.Roles {
+			row = append(row, strconv.FormatBool(m.Granted(role, entitlement)))
+		}
+
+		err = writer.Write(row)
+		if err != nil {
+			return fmt.Errorf("Failed to encode matrix: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,94 @@
+package openfga
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleMatrix(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	matrix, err := NewRoleMatrix(context.Background(), model)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"server#admin", "server#operator", "server#viewer",
+		"certificate#manager", "certificate#viewer",
+		"cluster_member#manager", "cluster_member#viewer",
+		"cluster_group#manager", "cluster_group#viewer",
+		"storage_pool#manager", "storage_pool#viewer",
+		"project#manager", "project#operator", "project#viewer",
+		"image#manager", "image#viewer",
+		"instance#manager", "instance#operator", "instance#user", "instance#viewer",
+		"network#manager", "network#viewer",
+	}, matrix.Roles)
+	require.Contains(t, matrix.Entitlements, "instance#can_exec")
+	require.NotContains(t, matrix.Entitlements, "instance#operator")
+
+	tests := []struct {
+		role        string
+		entitlement string
+		granted     bool
+	}{
+		{role: "server#admin", entitlement: "storage_pool#can_edit", granted: true},
+		{role: "server#admin", entitlement: "instance#can_exec", granted: true},
+		{role: "server#viewer", entitlement: "server#can_view_resources", granted: true},
+		{role: "server#viewer", entitlement: "server#can_view_server", granted: true},
+		{role: "instance#user", entitlement: "server#can_view_server", granted: true},
+		{role: "certificate#manager", entitlement: "certificate#can_edit", granted: true},
+		{role: "storage_pool#viewer", entitlement: "storage_pool#can_view", granted: true},
+		{role: "storage_pool#viewer", entitlement: "storage_pool#can_edit", granted: false},
+		{role: "cluster_group#manager", entitlement: "cluster_group#can_target", granted: true},
+		{role: "project#manager", entitlement: "project#can_edit", granted: true},
+		{role: "project#operator", entitlement: "project#can_edit", granted: false},
+		{role: "project#operator", entitlement: "instance#can_exec", granted: true},
+		{role: "project#operator", entitlement: "server#can_create_project", granted: false},
+		{role: "project#manager", entitlement: "cluster_member#can_target", granted: true},
+		{role: "project#operator", entitlement: "cluster_member#can_target", granted: true},
+		{role: "project#operator", entitlement: "cluster_group#can_target", granted: true},
+		{role: "project#operator", entitlement: "storage_pool#can_view", granted: true},
+		{role: "project#operator", entitlement: "storage_pool#can_edit", granted: false},
+		{role: "project#viewer", entitlement: "cluster_group#can_target", granted: false},
+		{role: "project#viewer", entitlement: "storage_pool#can_view", granted: false},
+		{role: "project#viewer", entitlement: "network#can_view", granted: true},
+		{role: "project#viewer", entitlement: "network#can_edit", granted: false},
+		{role: "instance#user", entitlement: "instance#can_exec", granted: true},
+		{role: "instance#user", entitlement: "instance#can_edit", granted: false},
+		{role: "instance#manager", entitlement: "instance#can_edit", granted: true},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.granted, matrix.Granted(test.role, test.entitlement), "%s grants %s", test.role, test.entitlement)
+	}
+
+	var buf bytes.Buffer
+	err = matrix.Encode(&buf, MatrixCSV)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, len(matrix.Entitlements)+1)
+	require.True(t, strings.HasPrefix(lines[0], "entitlement,server#admin,server#operator,"))
+	require.Contains(t, lines, "instance#can_exec,true,true,false,false,false,false,false,false,false,false,false,true,true,false,false,false,true,true,true,false,false,false")
+
+	buf.Reset()
+	err = matrix.Encode(&buf, MatrixHTML)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "<th>instance#can_exec</th>")
+	require.Equal(t, len(matrix.Entitlements)+1, strings.Count(buf.String(), "<tr>"))
+
+	err = matrix.Encode(&buf, "pdf")
+	require.EqualError(t, err, `Unknown matrix format "pdf"`)
+
+	// The committed matrix must be regenerated with `make update-matrix` whenever the model changes.
+	buf.Reset()
+	err = matrix.Encode(&buf, MatrixMarkdown)
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("roles.md")
+	require.NoError(t, err)
+	require.Equal(t, string(committed), buf.String(), "roles.md is out of date, run make update-matrix")
+}
@@ -0,0 +1,233 @@
+package openfga
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are Prometheus metrics of authorization queries, tuple reads and writes, and caches. Labels are limited to
+// values from the model (types and relations), the operation, the decision or result, and the backend name, so that
+// their cardinality is bounded. Objects and users are never used as labels.
+type Metrics struct {
+	queries       *prometheus.CounterVec
+	queryDuration *prometheus.HistogramVec
+	storeOps      *prometheus.CounterVec
+	storeDuration *prometheus.HistogramVec
+	tuples        *prometheus.CounterVec
+	cache         *prometheus.CounterVec
+
+	replicaSyncs    *prometheus.CounterVec
+	replicaLastSync prometheus.Gauge
+	replicaLag      prometheus.Histogram
+	replicaTuples   prometheus.Gauge
+}
+
+// NewMetrics returns the metrics. They must be registered with a prometheus.Registerer to be exported.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lxd_openfga",
+			Name:      "queries_total",
+			Help:      "Number of authorization queries by operation, type, relation, decision and backend.",
+		}, []string{"operation", "type", "relation", "decision", "backend"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "lxd_openfga",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of authorization queries by operation, type, relation and backend.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"operation", "type", "relation", "backend"}),
+		storeOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lxd_openfga",
+			Name:      "store_operations_total",
+			Help:      "Number of tuple store reads and writes by operation, result and backend.",
+		}, []string{"operation", "result", "backend"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "lxd_openfga",
+			Name:      "store_operation_duration_seconds",
+			Help:      "Duration of tuple store reads and writes by operation and backend.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"operation", "backend"}),
+		tuples: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lxd_openfga",
+			Name:      "tuples_written_total",
+			Help:      "Number of tuples successfully written or deleted by change, type, relation and backend.",
+		}, []string{"change", "type", "relation", "backend"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lxd_openfga",
+			Name:      "cache_requests_total",
+			Help:      "Number of cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		replicaSyncs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lxd_openfga",
+			Name:      "replica_syncs_total",
+			Help:      "Number of replica syncs by kind (changes, initial or resync after an expired token) and result.",
+		}, []string{"kind", "result"}),
+		replicaLastSync: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "lxd_openfga",
+			Name:      "replica_last_sync_timestamp_seconds",
+			Help:      "Time of the last successful replica sync, in seconds since the epoch.",
+		}),
+		replicaLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "lxd_openfga",
+			Name:      "replica_lag_seconds",
+			Help:      "Delay between a change being written to OpenFGA and it being applied to the replica.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		replicaTuples: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "lxd_openfga",
+			Name:      "replica_tuples",
+			Help:      "Number of tuples in the replica.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range m.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range m.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// collectors returns the metrics as collectors.
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.queries, m.queryDuration, m.storeOps, m.storeDuration, m.tuples, m.cache, m.replicaSyncs, m.replicaLastSync, m.replicaLag, m.replicaTuples}
+}
+
+// CacheHit records a hit in the named cache. It is intended for caches in front of a Querier or TupleStore, such as the
+// `replica` and `admins` caches recorded by Replica and NewResilientQuerier.
+func (m *Metrics) CacheHit(cache string) {
+	m.cache.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss records a miss in the named cache.
+func (m *Metrics) CacheMiss(cache string) {
+	m.cache.WithLabelValues(cache, "miss").Inc()
+}
+
+// observeQuery records an authorization query that started at the given time.
+func (m *Metrics) observeQuery(start time.Time, operation string, objectType ObjectType, entitlement Entitlement, decision AuditDecision, backend string) {
+	m.queries.WithLabelValues(operation, string(objectType), string(entitlement), string(decision), backend).Inc()
+	m.queryDuration.WithLabelValues(operation, string(objectType), string(entitlement), backend).Observe(time.Since(start).Seconds())
+}
+
+// observeStore records a tuple store operation that started at the given time.
+func (m *Metrics) observeStore(start time.Time, operation string, err error, backend string) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.storeOps.WithLabelValues(operation, result, backend).Inc()
+	m.storeDuration.WithLabelValues(operation, backend).Observe(time.Since(start).Seconds())
+}
+
+// observeSync records a replica sync of the given kind. On success, the lag of the applied changes and the size of the
+// replica are recorded too.
+func (m *Metrics) observeSync(kind string, err error, changes []TupleChange, tuples int) {
+	if err != nil {
+		m.replicaSyncs.WithLabelValues(kind, "error").Inc()
+		return
+	}
+
+	m.replicaSyncs.WithLabelValues(kind, "ok").Inc()
+	m.replicaLastSync.SetToCurrentTime()
+	m.replicaTuples.Set(float64(tuples))
+	for _, change := range changes {
+		if !change.Timestamp.IsZero() {
+			m.replicaLag.Observe(time.Since(change.Timestamp).Seconds())
+		}
+	}
+}
+
+// instrumentedQuerier is a Querier that records metrics of the queries of the wrapped Querier.
+type instrumentedQuerier struct {
+	querier Querier
+	metrics *Metrics
+	backend string
+}
+
+// NewInstrumentedQuerier returns a Querier that records the queries of the given Querier in the metrics, labelled with
+// the backend name (e.g. `openfga`).
+func NewInstrumentedQuerier(querier Querier, metrics *Metrics, backend string) Querier {
+	return &instrumentedQuerier{querier: querier, metrics: metrics, backend: backend}
+}
+
+// Check implements Checker.
+func (q *instrumentedQuerier) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	start := time.Now()
+	allowed, err := q.querier.Check(ctx, user, entitlement, object)
+	q.metrics.observeQuery(start, "check", object.Type, entitlement, queryDecision(allowed, err), q.backend)
+	return allowed, err
+}
+
+// ListObjects implements Querier. The decision is allow if any object is returned.
+func (q *instrumentedQuerier) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	start := time.Now()
+	objects, err := q.querier.ListObjects(ctx, user, entitlement, objectType)
+	q.metrics.observeQuery(start, "list_objects", objectType, entitlement, queryDecision(len(objects) > 0, err), q.backend)
+	return objects, err
+}
+
+// queryDecision returns the decision of a query.
+func queryDecision(allowed bool, err error) AuditDecision {
+	if err != nil {
+		return AuditDecisionError
+	}
+
+	if allowed {
+		return AuditDecisionAllow
+	}
+
+	return AuditDecisionDeny
+}
+
+// instrumentedStore is a TupleStore that records metrics of the reads and writes of the wrapped TupleStore.
+type instrumentedStore struct {
+	store   TupleStore
+	metrics *Metrics
+	backend string
+}
+
+// NewInstrumentedStore returns a TupleStore that records the reads and writes of the given store in the metrics,
+// labelled with the backend name.
+func NewInstrumentedStore(store TupleStore, metrics *Metrics, backend string) TupleStore {
+	return &instrumentedStore{store: store, metrics: metrics, backend: backend}
+}
+
+// ReadTuples implements TupleStore.
+func (s *instrumentedStore) ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	start := time.Now()
+	tuples, err := s.store.ReadTuples(ctx, filter)
+	s.metrics.observeStore(start, "read", err, s.backend)
+	return tuples, err
+}
+
+// WriteTuples implements TupleStore.
+func (s *instrumentedStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	start := time.Now()
+	err := s.store.WriteTuples(ctx, writes, deletes)
+	s.metrics.observeStore(start, "write", err, s.backend)
+	if err != nil {
+		return err
+	}
+
+	for change, tuples := range map[string][]client.ClientTupleKey{"write": writes, "delete": deletes} {
+		for _, tuple := range tuples {
+			objectType, _, _ := strings.Cut(tuple.Object, ":")
+			s.metrics.tuples.WithLabelValues(change, objectType, tuple.Relation, s.backend).Inc()
+		}
+	}
+
+	return nil
+}
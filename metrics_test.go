@@ -0,0 +1,75 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(metrics))
+
+	querier := NewInstrumentedQuerier(newAuditTestQuerier(t), metrics, "evaluator")
+
+	// Checks on many objects and by many users add no series.
+	for i := 0; i < 50; i++ {
+		_, err := querier.Check(context.Background(), fmt.Sprintf("user:u%d", i), EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", fmt.Sprintf("c%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err := querier.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	_, err = querier.Check(context.Background(), "user:alice", "can_fly", ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.Error(t, err)
+	_, err = querier.ListObjects(context.Background(), "user:alice", EntitlementCanView, ObjectTypeInstance)
+	require.NoError(t, err)
+
+	require.Equal(t, float64(50), testutil.ToFloat64(metrics.queries.WithLabelValues("check", "instance", "can_exec", "deny", "evaluator")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.queries.WithLabelValues("check", "instance", "can_exec", "allow", "evaluator")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.queries.WithLabelValues("check", "instance", "can_fly", "error", "evaluator")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.queries.WithLabelValues("list_objects", "instance", "can_view", "allow", "evaluator")))
+	require.Equal(t, 4, testutil.CollectAndCount(metrics, "lxd_openfga_queries_total"))
+	require.Equal(t, 3, testutil.CollectAndCount(metrics, "lxd_openfga_query_duration_seconds"))
+
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewInstrumentedStore(NewValidatingStore(NewMemoryStore(), model), metrics, "memory")
+	err = store.WriteTuples(context.Background(), []client.ClientTupleKey{
+		{User: "user:alice", Relation: "operator", Object: "instance:p/c1"},
+		{User: "user:bob", Relation: "operator", Object: "instance:p/c2"},
+	}, nil)
+	require.NoError(t, err)
+
+	err = store.WriteTuples(context.Background(), nil, []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:p/c1"}})
+	require.NoError(t, err)
+
+	err = store.WriteTuples(context.Background(), []client.ClientTupleKey{{User: "user:alice", Relation: "can_fly", Object: "instance:p/c1"}}, nil)
+	require.Error(t, err)
+
+	_, err = store.ReadTuples(context.Background(), client.ClientTupleKey{Object: "instance:"})
+	require.NoError(t, err)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.storeOps.WithLabelValues("write", "ok", "memory")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.storeOps.WithLabelValues("write", "error", "memory")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.storeOps.WithLabelValues("read", "ok", "memory")))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.tuples.WithLabelValues("write", "instance", "operator", "memory")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.tuples.WithLabelValues("delete", "instance", "operator", "memory")))
+
+	metrics.CacheHit("check")
+	metrics.CacheHit("check")
+	metrics.CacheMiss("check")
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.cache.WithLabelValues("check", "hit")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.cache.WithLabelValues("check", "miss")))
+
+	problems, err := testutil.GatherAndLint(registry)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+}
@@ -0,0 +1,275 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Checker checks whether a user has an entitlement on an object. It is implemented by Authorizer.
+type Checker interface {
+	Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error)
+}
+
+// ObjectExtractor returns the object of the given type that a request acts on, from the request and the parameters
+// matched by the `{name}` segments of its route.
+type ObjectExtractor func(objectType ObjectType, r *http.Request, params map[string]string) (Object, error)
+
+// ExtractServer returns the server object.
+func ExtractServer(objectType ObjectType, r *http.Request, params map[string]string) (Object, error) {
+	return ServerObject(), nil
+}
+
+// ExtractProject returns the project of the request, given by the `project` query parameter. LXD uses the default
+// project if it is not set.
+func ExtractProject(objectType ObjectType, r *http.Request, params map[string]string) (Object, error) {
+	return ProjectObject(requestProject(r)), nil
+}
+
+// ExtractName returns an extractor for objects that are identified by their name alone, taken from the given path
+// parameter (e.g. storage pools and projects).
+func ExtractName(param string) ObjectExtractor {
+	return func(objectType ObjectType, r *http.Request, params map[string]string) (Object, error) {
+		name := params[param]
+		if name == "" {
+			return Object{}, fmt.Errorf("Missing path parameter %q", param)
+		}
+
+		return Object{Type: objectType, Name: name}, nil
+	}
+}
+
+// ExtractProjectName returns an extractor for objects that belong to the project of the request and are named by the
+// given path parameter (e.g. instances and profiles).
+func ExtractProjectName(param string) ObjectExtractor {
+	return func(objectType ObjectType, r *http.Request, params map[string]string) (Object, error) {
+		name := params[param]
+		if name == "" {
+			return Object{}, fmt.Errorf("Missing path parameter %q", param)
+		}
+
+		return ProjectResourceObject(objectType, requestProject(r), name), nil
+	}
+}
+
+// ExtractTemplate returns an extractor for objects whose ID is given by a template, in which `{name}` is replaced by
+// the path parameter and `{project}` by the project of the request, e.g. `{project}/{pool}/{type}/{volume}` for storage
+// volumes. A path parameter named `project` takes precedence over the project of the request.
+func ExtractTemplate(template string) ObjectExtractor {
+	return func(objectType ObjectType, r *http.Request, params map[string]string) (Object, error) {
+		var id strings.Builder
+		rest := template
+		for {
+			before, after, ok := strings.Cut(rest, "{")
+			id.WriteString(before)
+			if !ok {
+				break
+			}
+
+			param, after, ok := strings.Cut(after, "}")
+			if !ok {
+				return Object{}, fmt.Errorf("Invalid object template %q: Unterminated parameter", template)
+			}
+
+			value, ok := params[param]
+			if !ok && param == "project" {
+				value, ok = requestProject(r), true
+			}
+
+			if !ok || value == "" {
+				return Object{}, fmt.Errorf("Missing path parameter %q", param)
+			}
+
+			id.WriteString(value)
+			rest = after
+		}
+
+		object, err := ParseObject(string(objectType) + ":" + id.String())
+		if err != nil {
+			return Object{}, err
+		}
+
+		// The cluster of an object is never taken from a request.
+		if object.Cluster != "" {
+			return Object{}, fmt.Errorf("Invalid object %q: Path parameters must not contain %q", object, "@")
+		}
+
+		return object, nil
+	}
+}
+
+// requestProject returns the `project` query parameter of the request, or the default project.
+func requestProject(r *http.Request) string {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		return DefaultProject
+	}
+
+	return project
+}
+
+// Route maps requests with the given method and path to the entitlement that they require.
+type Route struct {
+	Method string
+
+	// Pattern is a path in which segments of the form `{name}` match any single segment, e.g.
+	// `/1.0/instances/{name}/state`.
+	Pattern string
+
+	Type        ObjectType
+	Entitlement Entitlement
+	Object      ObjectExtractor
+}
+
+// String returns the method and pattern of the route.
+func (r Route) String() string {
+	return r.Method + " " + r.Pattern
+}
+
+// RouteTable matches requests to routes.
+type RouteTable struct {
+	routes   []Route
+	segments [][]string
+}
+
+// NewRouteTable returns a route table for the given routes. It returns an error if a pattern is invalid or if the
+// entitlement of a route is not defined on its type in the model.
+func NewRouteTable(model *Model, routes []Route) (*RouteTable, error) {
+	table := &RouteTable{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Pattern, "/") {
+			return nil, fmt.Errorf("Invalid route %q: Pattern must start with a slash", route)
+		}
+
+		if route.Object == nil {
+			return nil, fmt.Errorf("Invalid route %q: Missing object extractor", route)
+		}
+
+		if !model.HasRelation(string(route.Type), string(route.Entitlement)) {
+			return nil, fmt.Errorf("Invalid route %q: Entitlement %q is not defined on type %q", route, route.Entitlement, route.Type)
+		}
+
+		segments := strings.Split(route.Pattern[1:], "/")
+		for _, segment := range segments {
+			if strings.HasPrefix(segment, "{") != strings.HasSuffix(segment, "}") || segment == "{}" {
+				return nil, fmt.Errorf("Invalid route %q: Invalid segment %q", route, segment)
+			}
+		}
+
+		table.routes = append(table.routes, route)
+		table.segments = append(table.segments, segments)
+	}
+
+	return table, nil
+}
+
+// Routes returns the routes of the table.
+func (t *RouteTable) Routes() []Route {
+	return append([]Route(nil), t.routes...)
+}
+
+// Match returns the route that matches the method and path of the request, and the parameters matched by its
+// `{name}` segments. If several routes match, literal segments take precedence over parameters from left to right, so
+// `/1.0/images/aliases` is preferred over `/1.0/images/{fingerprint}`. Path segments are unescaped before they are
+// matched, so that names may contain a slash.
+func (t *RouteTable) Match(r *http.Request) (Route, map[string]string, bool) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	best := -1
+	var bestParams map[string]string
+	for i, route := range t.routes {
+		if route.Method != r.Method || len(t.segments[i]) != len(segments) {
+			continue
+		}
+
+		params, ok := matchSegments(t.segments[i], segments)
+		if ok && (best < 0 || moreSpecific(t.segments[i], t.segments[best])) {
+			best = i
+			bestParams = params
+		}
+	}
+
+	if best < 0 {
+		return Route{}, nil, false
+	}
+
+	return t.routes[best], bestParams, true
+}
+
+// moreSpecific returns true if the first pattern has a literal segment where the second has a parameter, before any
+// segment where the opposite is true.
+func moreSpecific(a []string, b []string) bool {
+	for i := range a {
+		aParam, bParam := strings.HasPrefix(a[i], "{"), strings.HasPrefix(b[i], "{")
+		if aParam != bParam {
+			return bParam
+		}
+	}
+
+	return false
+}
+
+// matchSegments matches escaped path segments against the segments of a pattern.
+func matchSegments(pattern []string, segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, segment := range segments {
+		value, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, false
+		}
+
+		if strings.HasPrefix(pattern[i], "{") {
+			params[strings.Trim(pattern[i], "{}")] = value
+		} else if pattern[i] != value {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// UserExtractor returns the OpenFGA user that made a request (e.g. `user:alice`), or false if the request is not
+// authenticated.
+type UserExtractor func(r *http.Request) (string, bool)
+
+// Middleware returns HTTP middleware that only passes on requests whose user has the entitlement required by the
+// matching route. Requests that match no route are denied, so every endpoint must be listed in the route table.
+// Responses are 401 for unauthenticated requests, 403 for denied or unmapped requests, 400 if the object cannot be
+// determined and 500 if the check fails.
+func Middleware(checker Checker, routes *RouteTable, user UserExtractor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, ok := user(r)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			route, params, ok := routes.Match(r)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			object, err := route.Object(route.Type, r, params)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			allowed, err := checker.Check(r.Context(), subject, route.Entitlement, object)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if !allowed {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluatorChecker is a Checker backed by an Evaluator.
+type evaluatorChecker struct {
+	evaluator *Evaluator
+}
+
+// Check implements Checker.
+func (c evaluatorChecker) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	return c.evaluator.Check(ctx, client.ClientCheckRequest{User: user, Relation: string(entitlement), Object: object.String()})
+}
+
+// ListObjects implements Querier.
+func (c evaluatorChecker) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	names, err := c.evaluator.ListObjects(ctx, client.ClientListObjectsRequest{User: user, Relation: string(entitlement), Type: string(objectType)})
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, 0, len(names))
+	for _, name := range names {
+		object, err := ParseObject(name)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// failingChecker is a Checker that always fails.
+type failingChecker struct{}
+
+// Check implements Checker.
+func (failingChecker) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	return false, errors.New("Connection refused")
+}
+
+// headerUser returns the user from the `X-User` header.
+func headerUser(r *http.Request) (string, bool) {
+	user := r.Header.Get("X-User")
+	return user, user != ""
+}
+
+func TestMiddleware(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	routes, err := NewRouteTable(model, []Route{
+		{Method: http.MethodGet, Pattern: "/1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanViewServer, Object: ExtractServer},
+		{Method: http.MethodPost, Pattern: "/1.0/instances", Type: ObjectTypeProject, Entitlement: EntitlementCanCreateInstances, Object: ExtractProject},
+		{Method: http.MethodGet, Pattern: "/1.0/instances/{name}", Type: ObjectTypeInstance, Entitlement: EntitlementCanView, Object: ExtractProjectName("name")},
+		{Method: http.MethodPut, Pattern: "/1.0/instances/{name}/state", Type: ObjectTypeInstance, Entitlement: EntitlementCanUpdateState, Object: ExtractProjectName("name")},
+		{Method: http.MethodPut, Pattern: "/1.0/storage-pools/{name}", Type: ObjectTypeStoragePool, Entitlement: EntitlementCanEdit, Object: ExtractName("name")},
+	})
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator(model, NewMemoryStore(
+		client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:default"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:p"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "storage_pool:pool01"},
+		client.ClientTupleKey{User: "project:p", Relation: "project", Object: "instance:p/c1"},
+		client.ClientTupleKey{User: "project:p", Relation: "project", Object: "instance:p/a/b"},
+		client.ClientTupleKey{User: "project:default", Relation: "project", Object: "instance:default/c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:p/c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:p/a/b"},
+		client.ClientTupleKey{User: "user:bob", Relation: "operator", Object: "project:default"},
+		client.ClientTupleKey{User: "user:admin", Relation: "admin", Object: "server:lxd"},
+	))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := httptest.NewServer(Middleware(evaluatorChecker{evaluator: evaluator}, routes, headerUser)(handler))
+	defer server.Close()
+
+	failing := httptest.NewServer(Middleware(failingChecker{}, routes, headerUser)(handler))
+	defer failing.Close()
+
+	tests := []struct {
+		description string
+		url         string
+		method      string
+		path        string
+		user        string
+		status      int
+	}{
+		{
+			description: "Unauthenticated requests are rejected",
+			method:      http.MethodGet,
+			path:        "/1.0",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description: "Any user can view the server",
+			method:      http.MethodGet,
+			path:        "/1.0",
+			user:        "user:anyone",
+			status:      http.StatusOK,
+		},
+		{
+			description: "An instance operator can update the state of the instance in its project",
+			method:      http.MethodPut,
+			path:        "/1.0/instances/c1/state?project=p",
+			user:        "user:alice",
+			status:      http.StatusOK,
+		},
+		{
+			description: "An instance operator cannot update the state of an instance with the same name in another project",
+			method:      http.MethodPut,
+			path:        "/1.0/instances/c1/state",
+			user:        "user:alice",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "Instances without a project query parameter are in the default project",
+			method:      http.MethodPut,
+			path:        "/1.0/instances/c1/state",
+			user:        "user:bob",
+			status:      http.StatusOK,
+		},
+		{
+			description: "Escaped slashes in path parameters are part of the name",
+			method:      http.MethodGet,
+			path:        "/1.0/instances/a%2Fb?project=p",
+			user:        "user:alice",
+			status:      http.StatusOK,
+		},
+		{
+			description: "A project operator can create instances in the project",
+			method:      http.MethodPost,
+			path:        "/1.0/instances",
+			user:        "user:bob",
+			status:      http.StatusOK,
+		},
+		{
+			description: "A project operator cannot create instances in another project",
+			method:      http.MethodPost,
+			path:        "/1.0/instances?project=p",
+			user:        "user:bob",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "A project operator cannot edit a storage pool",
+			method:      http.MethodPut,
+			path:        "/1.0/storage-pools/pool01",
+			user:        "user:bob",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "A server admin can edit a storage pool",
+			method:      http.MethodPut,
+			path:        "/1.0/storage-pools/pool01",
+			user:        "user:admin",
+			status:      http.StatusOK,
+		},
+		{
+			description: "Unmapped routes are denied, even for a server admin",
+			method:      http.MethodDelete,
+			path:        "/1.0/storage-pools/pool01",
+			user:        "user:admin",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "Unmapped paths are denied",
+			method:      http.MethodGet,
+			path:        "/1.0/instances/c1/logs",
+			user:        "user:admin",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "Empty path parameters are rejected",
+			method:      http.MethodGet,
+			path:        "/1.0/instances/",
+			user:        "user:admin",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "Failed checks are not allowed",
+			url:         failing.URL,
+			method:      http.MethodGet,
+			path:        "/1.0",
+			user:        "user:admin",
+			status:      http.StatusInternalServerError,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		url := test.url
+		if url == "" {
+			url = server.URL
+		}
+
+		request, err := http.NewRequest(test.method, url+test.path, nil)
+		require.NoError(t, err)
+		if test.user != "" {
+			request.Header.Set("X-User", test.user)
+		}
+
+		response, err := server.Client().Do(request)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, test.status, response.StatusCode)
+	}
+}
+
+func TestNewRouteTable(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		route       Route
+		err         string
+	}{
+		{
+			description: "Entitlements must be defined on the type",
+			route:       Route{Method: http.MethodPut, Pattern: "/1.0/storage-pools/{name}", Type: ObjectTypeStoragePool, Entitlement: EntitlementCanExec, Object: ExtractName("name")},
+			err:         `Invalid route "PUT /1.0/storage-pools/{name}": Entitlement "can_exec" is not defined on type "storage_pool"`,
+		},
+		{
+			description: "Patterns must be absolute",
+			route:       Route{Method: http.MethodGet, Pattern: "1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanViewServer, Object: ExtractServer},
+			err:         `Invalid route "GET 1.0": Pattern must start with a slash`,
+		},
+		{
+			description: "Parameters must be whole segments",
+			route:       Route{Method: http.MethodGet, Pattern: "/1.0/instances/{name", Type: ObjectTypeInstance, Entitlement: EntitlementCanView, Object: ExtractProjectName("name")},
+			err:         `Invalid route "GET /1.0/instances/{name": Invalid segment "{name"`,
+		},
+		{
+			description: "Routes must have an object extractor",
+			route:       Route{Method: http.MethodGet, Pattern: "/1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanViewServer},
+			err:         `Invalid route "GET /1.0": Missing object extractor`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		_, err := NewRouteTable(model, []Route{test.route})
+		require.EqualError(t, err, test.err)
+	}
+}
@@ -0,0 +1,413 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// Migrations are the migrations of the tuples of an LXD store, in order. A migration must be added whenever a change
+// to lxd.openfga renames, moves or removes a relation or type that tuples may refer to, together with that change.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Rename the server entitlements can_edit and can_view to can_edit_server and can_view_server",
+		Steps: []MigrationStep{
+			RenameRelation{Type: ObjectTypeServer, From: "can_edit", To: "can_edit_server"},
+			RenameRelation{Type: ObjectTypeServer, From: "can_view", To: "can_view_server"},
+		},
+	},
+	{
+		Version:     2,
+		Description: "Replace direct grants of can_edit and can_view on certificates, cluster members, cluster groups, storage pools, images and networks with the manager and viewer roles",
+		Steps: []MigrationStep{
+			RenameRelation{Type: ObjectTypeCertificate, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeCertificate, From: "can_view", To: "viewer"},
+			RenameRelation{Type: ObjectTypeClusterMember, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeClusterMember, From: "can_view", To: "viewer"},
+			RenameRelation{Type: ObjectTypeClusterGroup, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeClusterGroup, From: "can_view", To: "viewer"},
+			RenameRelation{Type: ObjectTypeStoragePool, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeStoragePool, From: "can_view", To: "viewer"},
+			RenameRelation{Type: ObjectTypeImage, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeImage, From: "can_view", To: "viewer"},
+			RenameRelation{Type: ObjectTypeNetwork, From: "can_edit", To: "manager"},
+			RenameRelation{Type: ObjectTypeNetwork, From: "can_view", To: "viewer"},
+		},
+	},
+}
+
+// MigrationStep rewrites the tuples affected by a single change to the model.
+type MigrationStep interface {
+	// Rewrite returns the tuples that replace the given tuple. Tuples that are not affected by the change are
+	// returned unchanged. Rewriting the result again must not return any tuple that is not already in the result, so
+	// that a migration can be run again after it was interrupted.
+	Rewrite(tuple client.ClientTupleKey) ([]client.ClientTupleKey, error)
+}
+
+// Migration is a versioned set of steps that are applied together.
+type Migration struct {
+	Version     int
+	Description string
+	Steps       []MigrationStep
+}
+
+// migrationObjectType and migrationRelation record the version of the last migration applied to the tuples of a
+// cluster in a tuple such as `server:lxd applied migration:2`, qualified by the cluster in a shared store. The type is
+// only used by this package, so Model.Types leaves it out.
+const (
+	migrationObjectType = "migration"
+	migrationRelation   = "applied"
+)
+
+// migrationRecord returns the tuple that records the version for the cluster.
+func migrationRecord(cluster Cluster, version int) client.ClientTupleKey {
+	return client.ClientTupleKey{
+		User:     cluster.Server().String(),
+		Relation: migrationRelation,
+		Object:   cluster.Resource(migrationObjectType, strconv.Itoa(version)).String(),
+	}
+}
+
+// MigrationVersion returns the version of the last migration applied to the tuples of the cluster, or 0 if none was
+// recorded.
+func MigrationVersion(ctx context.Context, store TupleStore, cluster Cluster) (int, error) {
+	records, err := store.ReadTuples(ctx, client.ClientTupleKey{User: cluster.Server().String(), Relation: migrationRelation, Object: migrationObjectType + ":"})
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for _, record := range records {
+		object, err := ParseObject(record.Object)
+		if err != nil {
+			return 0, err
+		}
+
+		recorded, err := strconv.Atoi(object.Name)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid migration version %q: %w", object.Name, err)
+		}
+
+		if object.Cluster == cluster && recorded > version {
+			version = recorded
+		}
+	}
+
+	return version, nil
+}
+
+// RecordMigrationVersion replaces the recorded version of the cluster, fromVersion, with the given version. Both are
+// changed in a single write, which fails if fromVersion is no longer recorded or if version was recorded concurrently.
+// MigrationVersion returns the highest version recorded, so the version of a cluster cannot move backwards. It is
+// called by Migrate, and must be called with fromVersion 0 and the latest version of Migrations when a new store is
+// created, as there are no tuples to migrate.
+func RecordMigrationVersion(ctx context.Context, store TupleStore, cluster Cluster, fromVersion int, version int) error {
+	if version == fromVersion {
+		return nil
+	}
+
+	var deletes []client.ClientTupleKey
+	if fromVersion > 0 {
+		deletes = append(deletes, migrationRecord(cluster, fromVersion))
+	}
+
+	err := store.WriteTuples(ctx, []client.ClientTupleKey{migrationRecord(cluster, version)}, deletes)
+	if err != nil {
+		return fmt.Errorf("Failed to record migration version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+// checkMigrationTargets returns an error if a step of the migrations renames or moves a relation to a relation or type
+// that the model does not define, unless a later step renames or moves it again.
+func checkMigrationTargets(model *Model, migrations []Migration) error {
+	// replaced are the relations (`<type>#<relation>`) and types that later steps rename, move or split.
+	replaced := make(map[string]bool)
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		for j := len(migration.Steps) - 1; j >= 0; j-- {
+			switch step := migration.Steps[j].(type) {
+			case RenameRelation:
+				if !replaced[string(step.Type)+"#"+step.To] && !model.HasRelation(string(step.Type), step.To) {
+					return fmt.Errorf("Migration %d: Relation %q is not defined on type %q", migration.Version, step.To, step.Type)
+				}
+
+				replaced[string(step.Type)+"#"+step.From] = true
+			case MoveRelation:
+				if !replaced[string(step.ToType)+"#"+step.ToRelation] && !model.HasRelation(string(step.ToType), step.ToRelation) {
+					return fmt.Errorf("Migration %d: Relation %q is not defined on type %q", migration.Version, step.ToRelation, step.ToType)
+				}
+
+				replaced[string(step.Type)+"#"+step.Relation] = true
+			case SplitType:
+				for _, into := range step.Into {
+					if !replaced[string(into)] && !model.HasType(string(into)) {
+						return fmt.Errorf("Migration %d: Type %q is not defined", migration.Version, into)
+					}
+				}
+
+				replaced[string(step.Type)] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// rewrite applies the steps of the migration to the tuple in order.
+func (m Migration) rewrite(tuple client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	tuples := []client.ClientTupleKey{tuple}
+	for _, step := range m.Steps {
+		var next []client.ClientTupleKey
+		for _, tuple := range tuples {
+			rewritten, err := step.Rewrite(tuple)
+			if err != nil {
+				return nil, fmt.Errorf("Migration %d: %w", m.Version, err)
+			}
+
+			next = append(next, rewritten...)
+		}
+
+		tuples = next
+	}
+
+	return tuples, nil
+}
+
+// RenameRelation renames a relation of a type. Tuples with the relation are rewritten, as are usersets that refer to it
+// (e.g. `server:lxd#can_edit`).
+type RenameRelation struct {
+	Type ObjectType
+	From string
+	To   string
+}
+
+// Rewrite implements MigrationStep.
+func (r RenameRelation) Rewrite(tuple client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	objectType, _, _ := strings.Cut(tuple.Object, ":")
+	if ObjectType(objectType) == r.Type && tuple.Relation == r.From {
+		tuple.Relation = r.To
+	}
+
+	userObject, userRelation, isUserset := strings.Cut(tuple.User, "#")
+	userType, _, _ := strings.Cut(userObject, ":")
+	if isUserset && ObjectType(userType) == r.Type && userRelation == r.From {
+		tuple.User = userObject + "#" + r.To
+	}
+
+	return []client.ClientTupleKey{tuple}, nil
+}
+
+// MoveRelation moves a relation from one type to another. Each object of the old type is replaced by the object of the
+// new type with the same ID, both in tuples with the relation and in usersets that refer to it.
+type MoveRelation struct {
+	Type       ObjectType
+	Relation   string
+	ToType     ObjectType
+	ToRelation string
+}
+
+// Rewrite implements MigrationStep.
+func (m MoveRelation) Rewrite(tuple client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	objectType, objectID, _ := strings.Cut(tuple.Object, ":")
+	if ObjectType(objectType) == m.Type && tuple.Relation == m.Relation {
+		tuple.Object = string(m.ToType) + ":" + objectID
+		tuple.Relation = m.ToRelation
+	}
+
+	userObject, userRelation, isUserset := strings.Cut(tuple.User, "#")
+	userType, userID, _ := strings.Cut(userObject, ":")
+	if isUserset && ObjectType(userType) == m.Type && userRelation == m.Relation {
+		tuple.User = string(m.ToType) + ":" + userID + "#" + m.ToRelation
+	}
+
+	return []client.ClientTupleKey{tuple}, nil
+}
+
+// SplitType splits a type into several types. Classify returns the new type of each object of the old type from its
+// ID, and must return one of the types listed in Into. Objects are replaced wherever they appear in a tuple. A
+// wildcard of the old type (e.g. `network:*`) is replaced by a wildcard of each of the new types.
+type SplitType struct {
+	Type     ObjectType
+	Into     []ObjectType
+	Classify func(id string) ObjectType
+}
+
+// Rewrite implements MigrationStep.
+func (s SplitType) Rewrite(tuple client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	objectType, objectID, _ := strings.Cut(tuple.Object, ":")
+	if ObjectType(objectType) == s.Type {
+		newType, err := s.classify(objectID)
+		if err != nil {
+			return nil, err
+		}
+
+		tuple.Object = string(newType) + ":" + objectID
+	}
+
+	userObject, userRelation, isUserset := strings.Cut(tuple.User, "#")
+	userType, userID, _ := strings.Cut(userObject, ":")
+	if ObjectType(userType) != s.Type {
+		return []client.ClientTupleKey{tuple}, nil
+	}
+
+	if userID == "*" {
+		tuples := make([]client.ClientTupleKey, 0, len(s.Into))
+		for _, newType := range s.Into {
+			tuples = append(tuples, client.ClientTupleKey{User: string(newType) + ":*", Relation: tuple.Relation, Object: tuple.Object})
+		}
+
+		return tuples, nil
+	}
+
+	newType, err := s.classify(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tuple.User = string(newType) + ":" + userID
+	if isUserset {
+		tuple.User += "#" + userRelation
+	}
+
+	return []client.ClientTupleKey{tuple}, nil
+}
+
+// classify returns the new type of the object with the given ID.
+func (s SplitType) classify(id string) (ObjectType, error) {
+	newType := s.Classify(id)
+	for _, into := range s.Into {
+		if newType == into {
+			return newType, nil
+		}
+	}
+
+	return "", fmt.Errorf("Cannot split %s %q into %q: Type must be one of %q", s.Type, id, newType, s.Into)
+}
+
+// MigrateOptions configures Migrate.
+type MigrateOptions struct {
+	// DryRun computes the changes without writing them.
+	DryRun bool
+}
+
+// MigrationResult is the number of tuples rewritten by a migration.
+type MigrationResult struct {
+	Version     int
+	Description string
+	Rewritten   int
+}
+
+// MigrationReport is the result of Migrate.
+type MigrationReport struct {
+	// Version is the version of the last migration applied, to be passed to the next call of Migrate.
+	Version int
+	Results []MigrationResult
+	Diff    TupleDiff
+}
+
+// Migrate applies the migrations with a version greater than fromVersion to the tuples of the cluster, and returns the
+// new version. The tuples of users and groups are shared by all clusters and migrated by each of them, which is a no-op
+// once one has. The migrations must only rename or move relations to relations and types defined by the model. Unless
+// this is a dry run, the new version is recorded in the store once the tuples have been written, so fromVersion is
+// usually the result of MigrationVersion. BootstrapStore runs the pending migrations when it writes a new model.
+//
+// All pending migrations are applied to a copy of the tuples first and only the overall difference is written,
+// validated against the model. Writes are applied before deletes in transactions of at most MaxTuplesPerWrite tuples,
+// so an interrupted migration leaves both the old and the new tuples in place. Running the same migrations again from
+// the same version completes it.
+func Migrate(ctx context.Context, store TupleStore, model *Model, cluster Cluster, migrations []Migration, fromVersion int, options MigrateOptions) (*MigrationReport, error) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("Migration versions must be increasing: %d follows %d", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if migration.Version > fromVersion {
+			pending = append(pending, migration)
+		}
+	}
+
+	err := checkMigrationTargets(model, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := store.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		return nil, err
+	}
+
+	// The tuples of other clusters sharing the store are migrated by those clusters.
+	current := make([]client.ClientTupleKey, 0, len(tuples))
+	for _, tuple := range tuples {
+		object, err := ParseObject(tuple.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		if object.InCluster(cluster) == object {
+			current = append(current, tuple)
+		}
+	}
+
+	report := &MigrationReport{Version: fromVersion}
+	tuples = current
+	for _, migration := range pending {
+		result := MigrationResult{Version: migration.Version, Description: migration.Description}
+		next := make(map[client.ClientTupleKey]struct{}, len(tuples))
+		for _, tuple := range tuples {
+			rewritten, err := migration.rewrite(tuple)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(rewritten) != 1 || rewritten[0] != tuple {
+				result.Rewritten++
+			}
+
+			for _, tuple := range rewritten {
+				next[tuple] = struct{}{}
+			}
+		}
+
+		tuples = make([]client.ClientTupleKey, 0, len(next))
+		for tuple := range next {
+			tuples = append(tuples, tuple)
+		}
+
+		report.Results = append(report.Results, result)
+		report.Version = migration.Version
+	}
+
+	report.Diff = DiffTuples(current, tuples, true)
+	if options.DryRun {
+		err = ValidateTuples(model, report.Diff.Writes...)
+		if err != nil {
+			return nil, err
+		}
+
+		return report, nil
+	}
+
+	err = writeTuplesInChunks(ctx, store, model, report.Diff.Writes, report.Diff.Deletes)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Version > fromVersion {
+		err = RecordMigrationVersion(ctx, store, cluster, fromVersion, report.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
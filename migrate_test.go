@@ -0,0 +1,332 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore fails every write after the first n.
+type failingStore struct {
+	*MemoryStore
+	n int
+}
+
+func (s *failingStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	if s.n == 0 {
+		return errors.New("Connection lost")
+	}
+
+	s.n--
+	return s.MemoryStore.WriteTuples(ctx, writes, deletes)
+}
+
+func TestMigrationSteps(t *testing.T) {
+	split := SplitType{
+		Type: ObjectTypeInstance,
+		Into: []ObjectType{ObjectTypeInstance, "virtual_machine"},
+		Classify: func(id string) ObjectType {
+			if strings.HasPrefix(id, "default/vm") {
+				return "virtual_machine"
+			}
+
+			return ObjectTypeInstance
+		},
+	}
+
+	tests := []struct {
+		description string
+		step        MigrationStep
+		tuple       client.ClientTupleKey
+		expected    []client.ClientTupleKey
+	}{
+		{
+			description: "Renamed relation",
+			step:        RenameRelation{Type: ObjectTypeServer, From: "can_edit", To: "can_edit_server"},
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "can_edit", Object: "server:lxd"},
+			expected:    []client.ClientTupleKey{{User: "user:bob", Relation: "can_edit_server", Object: "server:lxd"}},
+		},
+		{
+			description: "Userset of a renamed relation",
+			step:        RenameRelation{Type: ObjectTypeServer, From: "can_edit", To: "can_edit_server"},
+			tuple:       client.ClientTupleKey{User: "server:lxd#can_edit", Relation: "operator", Object: "project:default"},
+			expected:    []client.ClientTupleKey{{User: "server:lxd#can_edit_server", Relation: "operator", Object: "project:default"}},
+		},
+		{
+			description: "Same relation on another type is not renamed",
+			step:        RenameRelation{Type: ObjectTypeServer, From: "can_edit", To: "can_edit_server"},
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "can_edit", Object: "storage_pool:pool01"},
+			expected:    []client.ClientTupleKey{{User: "user:bob", Relation: "can_edit", Object: "storage_pool:pool01"}},
+		},
+		{
+			description: "Moved relation",
+			step:        MoveRelation{Type: ObjectTypeStoragePool, Relation: "can_edit", ToType: "storage_pool_config", ToRelation: "editor"},
+			tuple:       client.ClientTupleKey{User: "group:ops#member", Relation: "can_edit", Object: "storage_pool:pool01"},
+			expected:    []client.ClientTupleKey{{User: "group:ops#member", Relation: "editor", Object: "storage_pool_config:pool01"}},
+		},
+		{
+			description: "Other relations of the type are not moved",
+			step:        MoveRelation{Type: ObjectTypeStoragePool, Relation: "can_edit", ToType: "storage_pool_config", ToRelation: "editor"},
+			tuple:       client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "storage_pool:pool01"},
+			expected:    []client.ClientTupleKey{{User: "server:lxd", Relation: "server", Object: "storage_pool:pool01"}},
+		},
+		{
+			description: "Split object",
+			step:        split,
+			tuple:       client.ClientTupleKey{User: "project:default", Relation: "project", Object: "instance:default/vm1"},
+			expected:    []client.ClientTupleKey{{User: "project:default", Relation: "project", Object: "virtual_machine:default/vm1"}},
+		},
+		{
+			description: "Split object that keeps its type",
+			step:        split,
+			tuple:       client.ClientTupleKey{User: "project:default", Relation: "project", Object: "instance:default/c1"},
+			expected:    []client.ClientTupleKey{{User: "project:default", Relation: "project", Object: "instance:default/c1"}},
+		},
+		{
+			description: "Split userset",
+			step:        split,
+			tuple:       client.ClientTupleKey{User: "instance:default/vm1#user", Relation: "viewer", Object: "storage_pool:pool01"},
+			expected:    []client.ClientTupleKey{{User: "virtual_machine:default/vm1#user", Relation: "viewer", Object: "storage_pool:pool01"}},
+		},
+		{
+			description: "Split wildcard",
+			step:        split,
+			tuple:       client.ClientTupleKey{User: "instance:*", Relation: "viewer", Object: "storage_pool:pool01"},
+			expected: []client.ClientTupleKey{
+				{User: "instance:*", Relation: "viewer", Object: "storage_pool:pool01"},
+				{User: "virtual_machine:*", Relation: "viewer", Object: "storage_pool:pool01"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		rewritten, err := test.step.Rewrite(test.tuple)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, rewritten)
+
+		// Rewriting the result again does not change it.
+		for _, tuple := range rewritten {
+			again, err := test.step.Rewrite(tuple)
+			require.NoError(t, err)
+			require.Subset(t, rewritten, again)
+		}
+	}
+
+	_, err := SplitType{Type: ObjectTypeInstance, Into: []ObjectType{"container"}, Classify: func(string) ObjectType { return "vm" }}.Rewrite(client.ClientTupleKey{User: "user:bob", Relation: "user", Object: "instance:default/c1"})
+	require.EqualError(t, err, `Cannot split instance "default/c1" into "vm": Type must be one of ["container"]`)
+}
+
+func TestMigrate(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	// The target model has a virtual_machine type identical to instance.
+	typeDefinitions := append([]openfgaSDK.TypeDefinition(nil), model.TypeDefinitions...)
+	for _, typeDefinition := range model.TypeDefinitions {
+		if typeDefinition.Type == string(ObjectTypeInstance) {
+			typeDefinition.Type = "virtual_machine"
+			typeDefinitions = append(typeDefinitions, typeDefinition)
+		}
+	}
+
+	target, err := NewModel(model.SchemaVersion, typeDefinitions)
+	require.NoError(t, err)
+
+	migrations := []Migration{
+		{
+			Version:     1,
+			Description: "Merge instance users into operators",
+			Steps:       []MigrationStep{RenameRelation{Type: ObjectTypeInstance, From: "user", To: "operator"}},
+		},
+		{
+			Version:     2,
+			Description: "Split virtual machines from instances",
+			Steps: []MigrationStep{SplitType{
+				Type: ObjectTypeInstance,
+				Into: []ObjectType{ObjectTypeInstance, "virtual_machine"},
+				Classify: func(id string) ObjectType {
+					if strings.Contains(id, "/vm") {
+						return "virtual_machine"
+					}
+
+					return ObjectTypeInstance
+				},
+			}},
+		},
+	}
+
+	tuples := []client.ClientTupleKey{
+		{User: "user:*", Relation: "user", Object: "server:lxd"},
+		{User: "server:lxd", Relation: "server", Object: "project:default"},
+	}
+
+	for i := 0; i < 60; i++ {
+		tuples = append(tuples,
+			client.ClientTupleKey{User: "project:default", Relation: "project", Object: fmt.Sprintf("instance:default/vm%02d", i)},
+			client.ClientTupleKey{User: "user:bob", Relation: "user", Object: fmt.Sprintf("instance:default/vm%02d", i)},
+		)
+	}
+
+	tuples = append(tuples, client.ClientTupleKey{User: "user:alice", Relation: "user", Object: "instance:default/c1"})
+	store := &failingStore{MemoryStore: NewMemoryStore(tuples...), n: 1}
+
+	report, err := Migrate(context.Background(), store, target, "", migrations, 0, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Version)
+	require.Equal(t, []MigrationResult{
+		{Version: 1, Description: "Merge instance users into operators", Rewritten: 61},
+		{Version: 2, Description: "Split virtual machines from instances", Rewritten: 120},
+	}, report.Results)
+	require.Len(t, report.Diff.Writes, 121)
+	require.Len(t, report.Diff.Deletes, 121)
+	require.Contains(t, report.Diff.Writes, client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:default/c1"})
+	require.Contains(t, report.Diff.Writes, client.ClientTupleKey{User: "user:bob", Relation: "operator", Object: "virtual_machine:default/vm00"})
+	require.Len(t, store.Tuples(), len(tuples), "Dry run must not write")
+
+	// The connection is lost after the first transaction.
+	_, err = Migrate(context.Background(), store, target, "", migrations, 0, MigrateOptions{})
+	require.EqualError(t, err, "Connection lost")
+	require.Len(t, store.Tuples(), len(tuples)+MaxTuplesPerWrite)
+
+	// Running the migrations again completes them.
+	store.n = -1
+	report, err = Migrate(context.Background(), store, target, "", migrations, 0, MigrateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Version)
+	require.Len(t, store.Tuples(), len(tuples)+1)
+
+	version, err := MigrationVersion(context.Background(), store, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	remaining, err := store.ReadTuples(context.Background(), client.ClientTupleKey{Object: "instance:"})
+	require.NoError(t, err)
+	require.Equal(t, []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:default/c1"}}, remaining)
+
+	// Migrations that have been applied are skipped.
+	report, err = Migrate(context.Background(), store, target, "", migrations, 2, MigrateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Version)
+	require.Empty(t, report.Results)
+	require.True(t, report.Diff.Empty())
+
+	_, err = Migrate(context.Background(), store, target, "", []Migration{{Version: 2}, {Version: 1}}, 0, MigrateOptions{})
+	require.EqualError(t, err, "Migration versions must be increasing: 1 follows 2")
+
+	// Steps must target relations and types of the model, unless a later step renames them again.
+	_, err = Migrate(context.Background(), store, model, "", migrations, 0, MigrateOptions{DryRun: true})
+	require.EqualError(t, err, `Migration 2: Type "virtual_machine" is not defined`)
+
+	renames := []Migration{
+		{Version: 3, Steps: []MigrationStep{RenameRelation{Type: ObjectTypeInstance, From: "user", To: "can_use"}}},
+		{Version: 4, Steps: []MigrationStep{RenameRelation{Type: ObjectTypeInstance, From: "can_use", To: "operator"}}},
+	}
+
+	_, err = Migrate(context.Background(), store, target, "", renames, 2, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+
+	_, err = Migrate(context.Background(), store, target, "", renames, 3, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+
+	_, err = Migrate(context.Background(), store, target, "", renames[:1], 2, MigrateOptions{DryRun: true})
+	require.EqualError(t, err, `Migration 3: Relation "can_use" is not defined on type "instance"`)
+
+	_, err = Migrate(context.Background(), store, target, "", []Migration{{Version: 3, Steps: []MigrationStep{MoveRelation{Type: ObjectTypeInstance, Relation: "user", ToType: "virtual_machine", ToRelation: "can_use"}}}}, 2, MigrateOptions{DryRun: true})
+	require.EqualError(t, err, `Migration 3: Relation "can_use" is not defined on type "virtual_machine"`)
+}
+
+func TestMigrations(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	// Tuples of a store written before the first migration.
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "certificate:c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "certificate:c1"},
+		client.ClientTupleKey{User: "group:ops#member", Relation: "can_view", Object: "certificate:c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "cluster_member:node01"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_view", Object: "cluster_group:gpu"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "storage_pool:default"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_view", Object: "image:default/i1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "network:default/n1"},
+		client.ClientTupleKey{User: "user:bob", Relation: "can_edit", Object: "instance:default/c1"},
+	)
+
+	// Dry runs check the targets of the steps and validate the migrated tuples against the model.
+	report, err := Migrate(context.Background(), store, model, "", Migrations, 0, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, Migrations[len(Migrations)-1].Version, report.Version)
+	require.ElementsMatch(t, []client.ClientTupleKey{
+		{User: "user:alice", Relation: "manager", Object: "certificate:c1"},
+		{User: "group:ops#member", Relation: "viewer", Object: "certificate:c1"},
+		{User: "user:alice", Relation: "manager", Object: "cluster_member:node01"},
+		{User: "user:alice", Relation: "viewer", Object: "cluster_group:gpu"},
+		{User: "user:alice", Relation: "manager", Object: "storage_pool:default"},
+		{User: "user:alice", Relation: "viewer", Object: "image:default/i1"},
+		{User: "user:alice", Relation: "manager", Object: "network:default/n1"},
+	}, report.Diff.Writes)
+	require.Len(t, report.Diff.Deletes, 7)
+}
+
+func TestMigrateClusters(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+	require.NotContains(t, model.Types(), migrationObjectType, "The migration version is not an LXD object")
+
+	var graph strings.Builder
+	err = model.EncodeGraph(&graph, GraphDOT, GraphOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, graph.String(), migrationObjectType)
+
+	// Two clusters share the store, and so does the membership of the ops group.
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd@c1", Relation: "server", Object: "certificate:a@c1"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "certificate:a@c1"},
+		client.ClientTupleKey{User: "server:lxd@c2", Relation: "server", Object: "certificate:a@c2"},
+		client.ClientTupleKey{User: "user:alice", Relation: "can_edit", Object: "certificate:a@c2"},
+		client.ClientTupleKey{User: "user:bob", Relation: "member", Object: "group:ops"},
+	)
+
+	report, err := Migrate(context.Background(), store, model, "c1", Migrations, 0, MigrateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []client.ClientTupleKey{{User: "user:alice", Relation: "manager", Object: "certificate:a@c1"}}, report.Diff.Writes)
+
+	latest := Migrations[len(Migrations)-1].Version
+	version, err := MigrationVersion(context.Background(), store, "c1")
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+
+	// The other cluster still has to migrate its own tuples.
+	version, err = MigrationVersion(context.Background(), store, "c2")
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+
+	_, err = Migrate(context.Background(), store, model, "c2", Migrations, 0, MigrateOptions{})
+	require.NoError(t, err)
+	require.Contains(t, store.Tuples(), client.ClientTupleKey{User: "user:alice", Relation: "manager", Object: "certificate:a@c2"})
+	require.Contains(t, store.Tuples(), client.ClientTupleKey{User: "server:lxd@c1", Relation: migrationRelation, Object: fmt.Sprintf("migration:%d@c1", latest)})
+	require.Contains(t, store.Tuples(), client.ClientTupleKey{User: "server:lxd@c2", Relation: migrationRelation, Object: fmt.Sprintf("migration:%d@c2", latest)})
+
+	// The version is only replaced if it has not changed since it was read.
+	err = RecordMigrationVersion(context.Background(), store, "c1", latest+1, latest+2)
+	require.Error(t, err)
+
+	err = RecordMigrationVersion(context.Background(), store, "c2", 0, latest)
+	require.Error(t, err)
+
+	err = RecordMigrationVersion(context.Background(), store, "c1", latest, latest+1)
+	require.NoError(t, err)
+
+	version, err = MigrationVersion(context.Background(), store, "c1")
+	require.NoError(t, err)
+	require.Equal(t, latest+1, version)
+}
@@ -2,4 +2,4 @@ package openfga
 
 // Code generated by Makefile; DO NOT EDIT.
 
-var authModel = `{"schema_version":"1.1","type_definitions":[{"type":"user","relations":{}},{"type":"group","relations":{"member":{"this":{}}},"metadata":{"relations":{"member":{"directly_related_user_types":[{"type":"user"}]}}}},{"type":"server","relations":{"admin":{"this":{}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"user":{"this":{}},"can_edit":{"computedUserset":{"object":"","relation":"admin"}},"can_view":{"computedUserset":{"object":"","relation":"user"}},"can_create_storage_pool":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_create_project":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"can_view_resources":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}},"can_create_certificate":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_edit_cluster":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_view_cluster":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}},"can_create_cluster_member":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_create_cluster_group":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_view_metrics":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}}},"metadata":{"relations":{"admin":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"operator":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"user":{"directly_related_user_types":[{"type":"user","wildcard":{}}]},"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"can_create_storage_pool":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_project":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view_resources":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_certificate":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_edit_cluster":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view_cluster":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_cluster_member":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_cluster_group":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view_metrics":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"certificate","relations":{"server":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"admin"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"server":{"directly_related_user_types":[{"type":"server"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"cluster_member","relations":{"server":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"admin"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"server":{"directly_related_user_types":[{"type":"server"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"cluster_group","relations":{"server":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"admin"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"server":{"directly_related_user_types":[{"type":"server"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"storage_pool","relations":{"server":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"admin"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"server":{"directly_related_user_types":[{"type":"server"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"project","relations":{"server":{"this":{}},"manager":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"can_edit":{"computedUserset":{"object":"","relation":"manager"}},"can_view":{"computedUserset":{"object":"","relation":"viewer"}},"can_create_images":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_instances":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_networks":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_network_acls":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_network_zones":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_network_forwards":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_network_load_balancers":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_network_peers":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_profiles":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_storage_pool_volumes":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_create_storage_buckets":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"server"},"computedUserset":{"object":"","relation":"operator"}}}]}}},"metadata":{"relations":{"server":{"directly_related_user_types":[{"type":"server"}]},"manager":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"operator":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"can_create_images":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_instances":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_networks":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_network_acls":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_network_zones":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_network_forwards":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_network_load_balancers":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_network_peers":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_profiles":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_storage_pool_volumes":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_create_storage_buckets":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"image","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"instance","relations":{"project":{"this":{}},"manager":{"this":{}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}},"user":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"user"}},{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}},"can_update_state":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_manage_snapshots":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_manage_backups":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_connect_sftp":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_access_files":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_access_console":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_exec":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"manager":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"operator":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"user":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"can_update_state":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_manage_snapshots":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_manage_backups":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_connect_sftp":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_access_files":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_access_console":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_exec":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network_acl","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network_zone","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network_forward","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network_load_balancer","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"network_peer","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"profile","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"storage_pool_volume","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}},{"type":"storage_bucket","relations":{"project":{"this":{}},"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"operator"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"tupleset":{"object":"","relation":"project"},"computedUserset":{"object":"","relation":"viewer"}}}]}}},"metadata":{"relations":{"project":{"directly_related_user_types":[{"type":"project"}]},"can_edit":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"type":"group","relation":"member"}]}}}}]}`
+var authModel = `{"schema_version":"1.1","type_definitions":[{"relations":{},"type":"user"},{"metadata":{"relations":{"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"member":{"this":{}}},"type":"group"},{"metadata":{"relations":{"admin":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_certificate":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_cluster_group":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_cluster_member":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_project":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_storage_pool":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_edit_cluster":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_edit_server":{"directly_related_user_types":[]},"can_view_cluster":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view_metrics":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view_resources":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view_server":{"directly_related_user_types":[]},"operator":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"user":{"directly_related_user_types":[{"type":"user","wildcard":{}}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"admin":{"this":{}},"can_create_certificate":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_create_cluster_group":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_create_cluster_member":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_create_project":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"can_create_storage_pool":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_edit_cluster":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"can_edit_server":{"computedUserset":{"object":"","relation":"admin"}},"can_view_cluster":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}},"can_view_metrics":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}},"can_view_resources":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"viewer"}}]}},"can_view_server":{"computedUserset":{"object":"","relation":"user"}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"admin"}}]}},"user":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}}},"type":"server"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"server":{"directly_related_user_types":[{"type":"server"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"admin"},"tupleset":{"object":"","relation":"server"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"server"}}}]}},"manager":{"this":{}},"server":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"certificate"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_target":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"server":{"directly_related_user_types":[{"type":"server"}]},"target_project":{"directly_related_user_types":[{"type":"project"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"admin"},"tupleset":{"object":"","relation":"server"}}}]}},"can_target":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"can_create_instances"},"tupleset":{"object":"","relation":"target_project"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"server"}}}]}},"manager":{"this":{}},"server":{"this":{}},"target_project":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"cluster_member"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_target":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"server":{"directly_related_user_types":[{"type":"server"}]},"target_project":{"directly_related_user_types":[{"type":"project"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"admin"},"tupleset":{"object":"","relation":"server"}}}]}},"can_target":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"can_create_instances"},"tupleset":{"object":"","relation":"target_project"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"server"}}}]}},"manager":{"this":{}},"server":{"this":{}},"target_project":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"cluster_group"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"server":{"directly_related_user_types":[{"type":"server"}]},"target_project":{"directly_related_user_types":[{"type":"project"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"admin"},"tupleset":{"object":"","relation":"server"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"server"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"can_create_instances"},"tupleset":{"object":"","relation":"target_project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"can_create_storage_pool_volumes"},"tupleset":{"object":"","relation":"target_project"}}}]}},"manager":{"this":{}},"server":{"this":{}},"target_project":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"storage_pool"},{"metadata":{"relations":{"can_create_images":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_instances":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_network_acls":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_network_forwards":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_network_load_balancers":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_network_peers":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_network_zones":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_networks":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_profiles":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_storage_buckets":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_create_storage_pool_volumes":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"image_consumer":{"directly_related_user_types":[{"type":"project"}]},"image_consumer_viewer":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"network_consumer":{"directly_related_user_types":[{"type":"project"}]},"network_consumer_viewer":{"directly_related_user_types":[]},"operator":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"profile_consumer":{"directly_related_user_types":[{"type":"project"}]},"profile_consumer_viewer":{"directly_related_user_types":[]},"server":{"directly_related_user_types":[{"type":"server"}]},"storage_volume_consumer":{"directly_related_user_types":[{"type":"project"}]},"storage_volume_consumer_viewer":{"directly_related_user_types":[]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_create_images":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_instances":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_network_acls":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_network_forwards":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_network_load_balancers":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_network_peers":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_network_zones":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_networks":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_profiles":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_storage_buckets":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_create_storage_pool_volumes":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"can_edit":{"computedUserset":{"object":"","relation":"manager"}},"can_view":{"computedUserset":{"object":"","relation":"viewer"}},"image_consumer":{"this":{}},"image_consumer_viewer":{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"image_consumer"}}},"manager":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"network_consumer":{"this":{}},"network_consumer_viewer":{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"network_consumer"}}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"server"}}}]}},"profile_consumer":{"this":{}},"profile_consumer_viewer":{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"profile_consumer"}}},"server":{"this":{}},"storage_volume_consumer":{"this":{}},"storage_volume_consumer_viewer":{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"storage_volume_consumer"}}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}}},"type":"project"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"image_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"manager":{"this":{}},"project":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"image"},{"metadata":{"relations":{"can_access_console":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_access_files":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_connect_sftp":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_edit":{"directly_related_user_types":[]},"can_exec":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_manage_backups":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_manage_snapshots":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_update_state":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"operator":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]},"user":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_access_console":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_access_files":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_connect_sftp":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_exec":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"user"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_manage_backups":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_manage_snapshots":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_update_state":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"user"}},{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"manager":{"this":{}},"operator":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}},"project":{"this":{}},"user":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"operator"}}]}}},"type":"instance"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[]},"can_view":{"directly_related_user_types":[]},"manager":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]}}},"relations":{"can_edit":{"union":{"child":[{"computedUserset":{"object":"","relation":"manager"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"computedUserset":{"object":"","relation":"viewer"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"network_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"manager":{"this":{}},"project":{"this":{}},"viewer":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"manager"}}]}}},"type":"network"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"network_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"network_acl"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"network_zone"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"network_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"network_forward"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"network_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"network_load_balancer"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"network_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"network_peer"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"profile_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"profile"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"storage_volume_consumer_viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"storage_pool_volume"},{"metadata":{"relations":{"can_edit":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"can_view":{"directly_related_user_types":[{"type":"user"},{"relation":"member","type":"group"}]},"project":{"directly_related_user_types":[{"type":"project"}]}}},"relations":{"can_edit":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"operator"},"tupleset":{"object":"","relation":"project"}}}]}},"can_view":{"union":{"child":[{"this":{}},{"computedUserset":{"object":"","relation":"can_edit"}},{"tupleToUserset":{"computedUserset":{"object":"","relation":"viewer"},"tupleset":{"object":"","relation":"project"}}}]}},"project":{"this":{}}},"type":"storage_bucket"},{"metadata":{"relations":{"applied":{"directly_related_user_types":[{"type":"server"}]}}},"relations":{"applied":{"this":{}}},"type":"migration"}]}`
@@ -0,0 +1,145 @@
+package openfga
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ObjectType is the name of a type defined in lxd.openfga.
+type ObjectType string
+
+// Object types defined in lxd.openfga.
+const (
+	ObjectTypeUser                ObjectType = "user"
+	ObjectTypeGroup               ObjectType = "group"
+	ObjectTypeServer              ObjectType = "server"
+	ObjectTypeCertificate         ObjectType = "certificate"
+	ObjectTypeClusterMember       ObjectType = "cluster_member"
+	ObjectTypeClusterGroup        ObjectType = "cluster_group"
+	ObjectTypeStoragePool         ObjectType = "storage_pool"
+	ObjectTypeProject             ObjectType = "project"
+	ObjectTypeImage               ObjectType = "image"
+	ObjectTypeInstance            ObjectType = "instance"
+	ObjectTypeNetwork             ObjectType = "network"
+	ObjectTypeNetworkACL          ObjectType = "network_acl"
+	ObjectTypeNetworkZone         ObjectType = "network_zone"
+	ObjectTypeNetworkForward      ObjectType = "network_forward"
+	ObjectTypeNetworkLoadBalancer ObjectType = "network_load_balancer"
+	ObjectTypeNetworkPeer         ObjectType = "network_peer"
+	ObjectTypeProfile             ObjectType = "profile"
+	ObjectTypeStoragePoolVolume   ObjectType = "storage_pool_volume"
+	ObjectTypeStorageBucket       ObjectType = "storage_bucket"
+)
+
+// serverObjectName is the name of the server object of a cluster.
+const serverObjectName = "lxd"
+
+// Object identifies an OpenFGA object.
+// Resources that belong to a project are qualified by the project name because LXD only requires their names to be
+// unique within a project. They are encoded as `<type>:<project>/<name>`, all other objects as `<type>:<name>`. When
+// several clusters share a store, the objects of each cluster are also qualified by the cluster, which appends
+// `@<cluster>` to their ID, e.g. `server:lxd@<cluster>` or `instance:<project>/<name>@<cluster>`. Users and groups
+// are shared by all clusters and never qualified.
+type Object struct {
+	Type    ObjectType
+	Cluster Cluster
+	Project string
+	Name    string
+}
+
+// Cluster identifies an LXD cluster in a store shared by several clusters, for example by the cluster UUID. The empty
+// Cluster is the only cluster of a store that is not shared, and its objects are not qualified.
+type Cluster string
+
+// Server returns the server object of the cluster.
+func (c Cluster) Server() Object {
+	return Object{Type: ObjectTypeServer, Cluster: c, Name: serverObjectName}
+}
+
+// Project returns the object for the named project of the cluster.
+func (c Cluster) Project(name string) Object {
+	return Object{Type: ObjectTypeProject, Cluster: c, Name: name}
+}
+
+// Resource returns the object for a resource of the cluster that does not belong to a project, e.g. a storage pool.
+func (c Cluster) Resource(objectType ObjectType, name string) Object {
+	return Object{Type: objectType, Cluster: c, Name: name}
+}
+
+// ProjectResource returns the object for a resource that belongs to a project of the cluster.
+func (c Cluster) ProjectResource(objectType ObjectType, project string, name string) Object {
+	return Object{Type: objectType, Cluster: c, Project: project, Name: name}
+}
+
+// ServerObject returns the server object of a store that is not shared by several clusters.
+func ServerObject() Object {
+	return Cluster("").Server()
+}
+
+// ProjectObject returns the object for the named project, in a store that is not shared by several clusters.
+func ProjectObject(name string) Object {
+	return Cluster("").Project(name)
+}
+
+// ProjectResourceObject returns the object for a resource that belongs to a project, in a store that is not shared by
+// several clusters.
+func ProjectResourceObject(objectType ObjectType, project string, name string) Object {
+	return Cluster("").ProjectResource(objectType, project, name)
+}
+
+// clusterScoped returns true if objects of the type belong to a cluster. Only users and groups do not.
+func clusterScoped(objectType ObjectType) bool {
+	return objectType != ObjectTypeUser && objectType != ObjectTypeGroup
+}
+
+// InCluster returns the object qualified by the cluster. Users and groups are returned unchanged.
+func (o Object) InCluster(cluster Cluster) Object {
+	if clusterScoped(o.Type) {
+		o.Cluster = cluster
+	}
+
+	return o
+}
+
+// String encodes the object in the format used in tuples.
+func (o Object) String() string {
+	id := o.Name
+	if o.Project != "" {
+		id = o.Project + "/" + o.Name
+	}
+
+	if o.Cluster != "" {
+		id += "@" + string(o.Cluster)
+	}
+
+	return fmt.Sprintf("%s:%s", o.Type, id)
+}
+
+// ParseObject parses an object from the format used in tuples.
+func ParseObject(object string) (Object, error) {
+	objectType, id, ok := strings.Cut(object, ":")
+	if !ok || objectType == "" || id == "" {
+		return Object{}, fmt.Errorf("Invalid object %q: Must be of the form <type>:<id>", object)
+	}
+
+	// User IDs may contain `@` (e.g. email addresses), but only objects that belong to a cluster are qualified.
+	var cluster string
+	index := strings.LastIndex(id, "@")
+	if index >= 0 && clusterScoped(ObjectType(objectType)) {
+		id, cluster = id[:index], id[index+1:]
+		if id == "" || cluster == "" {
+			return Object{}, fmt.Errorf("Invalid object %q: ID and cluster must not be empty", object)
+		}
+	}
+
+	project, name, ok := strings.Cut(id, "/")
+	if !ok {
+		return Object{Type: ObjectType(objectType), Cluster: Cluster(cluster), Name: id}, nil
+	}
+
+	if project == "" || name == "" {
+		return Object{}, fmt.Errorf("Invalid object %q: Project and name must not be empty", object)
+	}
+
+	return Object{Type: ObjectType(objectType), Cluster: Cluster(cluster), Project: project, Name: name}, nil
+}
@@ -2,147 +2,174 @@ package openfga
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
 
 	"github.com/openfga/go-sdk/client"
-	"github.com/stretchr/testify/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-type openFGASuite struct {
-	suite.Suite
-	fga         *client.OpenFgaClient
-	authModelID *string
+// openFGABaseTuples is the fixture shared by every test: a server with one object of each type, either below the server
+// or below project01.
+var openFGABaseTuples = client.ClientWriteTuplesBody{
+	{
+		User:     "user:*",
+		Relation: "user",
+		Object:   "server:lxd",
+	},
+	{
+		User:     "server:lxd",
+		Relation: "server",
+		Object:   "certificate:eeef45f0570ce713864c86ec60c8d88f60b4844d3a8849b262c77cb18e88394d",
+	},
+	{
+		User:     "server:lxd",
+		Relation: "server",
+		Object:   "cluster_member:node01",
+	},
+	{
+		User:     "server:lxd",
+		Relation: "server",
+		Object:   "cluster_group:group01",
+	},
+	{
+		User:     "server:lxd",
+		Relation: "server",
+		Object:   "storage_pool:pool01",
+	},
+	{
+		User:     "server:lxd",
+		Relation: "server",
+		Object:   "project:project01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "target_project",
+		Object:   "cluster_member:node01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "target_project",
+		Object:   "cluster_group:group01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "target_project",
+		Object:   "storage_pool:pool01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "image:image01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "instance:instance01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network:network01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network_acl:network_acl01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network_zone:network_zone01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network_forward:network_forward01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network_load_balancer:network_load_balancer01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "network_peer:network_peer01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "profile:profile01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "storage_pool_volume:storage_pool_volume01",
+	},
+	{
+		User:     "project:project01",
+		Relation: "project",
+		Object:   "storage_bucket:storage_bucket01",
+	},
 }
 
-func TestOpenFGASuite(t *testing.T) {
-	suite.Run(t, new(openFGASuite))
+// openFGATestStore is a store on the OpenFGA server at localhost:8080, holding the model and the base fixture. Every test
+// gets its own store so that tests can run in parallel and tuples written by one test are never seen by another.
+type openFGATestStore struct {
+	fga         *client.OpenFgaClient
+	model       *Model
+	authModelID *string
 }
 
-func (s *openFGASuite) SetupTest() {
-	var err error
-	s.fga, err = client.NewSdkClient(&client.ClientConfiguration{
+// newOpenFGATestStore creates a store with the model and the base fixture. The store is deleted when the test finishes.
+func newOpenFGATestStore(t *testing.T) *openFGATestStore {
+	// The store ID is part of the client configuration, so each store needs its own client.
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{
 		ApiScheme: "http",
 		ApiHost:   "localhost:8080",
 	})
-	s.Require().NoError(err)
+	require.NoError(t, err)
 
-	createStoreResponse, err := s.fga.CreateStore(context.Background()).Body(client.ClientCreateStoreRequest{Name: "test"}).Execute()
-	s.Require().NoError(err)
+	createStoreResponse, err := fga.CreateStore(context.Background()).Body(client.ClientCreateStoreRequest{Name: t.Name()}).Execute()
+	require.NoError(t, err)
 
-	s.fga.SetStoreId(*createStoreResponse.Id)
+	fga.SetStoreId(*createStoreResponse.Id)
+	t.Cleanup(func() {
+		_, err := fga.DeleteStore(context.Background()).Execute()
+		require.NoError(t, err)
+	})
 
-	var writeAuthorizationModelRequest client.ClientWriteAuthorizationModelRequest
-	err = json.Unmarshal([]byte(authModel), &writeAuthorizationModelRequest)
-	s.Require().NoError(err)
+	model, err := DefaultModel()
+	require.NoError(t, err)
 
-	writeAuthorizationModelResponse, err := s.fga.WriteAuthorizationModel(context.Background()).Body(writeAuthorizationModelRequest).Execute()
-	s.Require().NoError(err)
+	writeAuthorizationModelResponse, err := fga.WriteAuthorizationModel(context.Background()).Body(model.WriteRequest()).Execute()
+	require.NoError(t, err)
 
-	s.authModelID = writeAuthorizationModelResponse.AuthorizationModelId
+	s := &openFGATestStore{fga: fga, model: model, authModelID: writeAuthorizationModelResponse.AuthorizationModelId}
+	s.writeTuples(t, openFGABaseTuples)
+	return s
+}
 
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
-		{
-			User:     "user:*",
-			Relation: "user",
-			Object:   "server:lxd",
-		},
-		{
-			User:     "server:lxd",
-			Relation: "server",
-			Object:   "certificate:eeef45f0570ce713864c86ec60c8d88f60b4844d3a8849b262c77cb18e88394d",
-		},
-		{
-			User:     "server:lxd",
-			Relation: "server",
-			Object:   "cluster_member:node01",
-		},
-		{
-			User:     "server:lxd",
-			Relation: "server",
-			Object:   "cluster_group:group01",
-		},
-		{
-			User:     "server:lxd",
-			Relation: "server",
-			Object:   "storage_pool:pool01",
-		},
-		{
-			User:     "server:lxd",
-			Relation: "server",
-			Object:   "project:project01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "image:image01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "instance:instance01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network:network01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network_acl:network_acl01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network_zone:network_zone01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network_forward:network_forward01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network_load_balancer:network_load_balancer01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "network_peer:network_peer01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "profile:profile01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "storage_pool_volume:storage_pool_volume01",
-		},
-		{
-			User:     "project:project01",
-			Relation: "project",
-			Object:   "storage_bucket:storage_bucket01",
-		},
-	}).Execute()
-	s.Require().NoError(err)
+// writeTuples writes the tuples to the store. OpenFGA accepts some tuples that the model can never match (see
+// ValidateTuple), so the tuples are validated first to keep such mistakes out of the fixtures.
+func (s *openFGATestStore) writeTuples(t *testing.T, tuples client.ClientWriteTuplesBody) {
+	require.NoError(t, ValidateTuples(s.model, tuples...))
+
+	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(tuples).Execute()
+	require.NoError(t, err)
 
-	s.Require().Len(clientWriteResponse.Deletes, 0)
+	require.Len(t, clientWriteResponse.Deletes, 0)
 	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
+		require.NoError(t, write.Error)
 	}
 }
 
-func (s *openFGASuite) TearDownSuite() {
-	_, err := s.fga.DeleteStore(context.Background()).Execute()
-	s.Require().NoError(err)
-}
+func TestOpenFGAPublic(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
 
-func (s *openFGASuite) TestPublic() {
 	type test struct {
 		description string
 		allowed     bool
@@ -155,7 +182,7 @@ func (s *openFGASuite) TestPublic() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:anyone",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -164,7 +191,7 @@ func (s *openFGASuite) TestPublic() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:anyone",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -285,6 +312,15 @@ func (s *openFGASuite) TestPublic() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "User with no relations should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:anyone",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "User with no relations should not be able to edit a cluster group",
 			allowed:     false,
@@ -303,6 +339,15 @@ func (s *openFGASuite) TestPublic() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "User with no relations should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:anyone",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "User with no relations should not be able to edit a storage_pool",
 			allowed:     false,
@@ -684,16 +729,19 @@ func (s *openFGASuite) TestPublic() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestServerAdmin() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAServerAdmin(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:server_admins#member",
 			Relation: "admin",
@@ -704,13 +752,7 @@ func (s *openFGASuite) TestServerAdmin() {
 			Relation: "member",
 			Object:   "group:server_admins",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	tests := []struct {
 		description string
@@ -722,7 +764,7 @@ func (s *openFGASuite) TestServerAdmin() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:server_admin",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -731,7 +773,7 @@ func (s *openFGASuite) TestServerAdmin() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:server_admin",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -852,6 +894,15 @@ func (s *openFGASuite) TestServerAdmin() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Server admin should be able to target a cluster member",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:server_admin",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Server admin should be able to edit a cluster group",
 			allowed:     true,
@@ -870,6 +921,15 @@ func (s *openFGASuite) TestServerAdmin() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Server admin should be able to target a cluster group",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:server_admin",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Server admin should be able to edit a storage_pool",
 			allowed:     true,
@@ -1251,16 +1311,19 @@ func (s *openFGASuite) TestServerAdmin() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestServerOperator() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAServerOperator(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:server_operators#member",
 			Relation: "operator",
@@ -1271,13 +1334,7 @@ func (s *openFGASuite) TestServerOperator() {
 			Relation: "member",
 			Object:   "group:server_operators",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -1290,7 +1347,7 @@ func (s *openFGASuite) TestServerOperator() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:server_operator",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -1299,7 +1356,7 @@ func (s *openFGASuite) TestServerOperator() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:server_operator",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -1420,6 +1477,15 @@ func (s *openFGASuite) TestServerOperator() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Server operator should be able to target a cluster member",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:server_operator",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Server operator should not be able to edit a cluster group",
 			allowed:     false,
@@ -1438,6 +1504,15 @@ func (s *openFGASuite) TestServerOperator() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Server operator should be able to target a cluster group",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:server_operator",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Server operator should not be able to edit a storage_pool",
 			allowed:     false,
@@ -1819,16 +1894,19 @@ func (s *openFGASuite) TestServerOperator() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestServerViewer() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAServerViewer(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:server_viewers#member",
 			Relation: "viewer",
@@ -1839,13 +1917,7 @@ func (s *openFGASuite) TestServerViewer() {
 			Relation: "member",
 			Object:   "group:server_viewers",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -1859,7 +1931,7 @@ func (s *openFGASuite) TestServerViewer() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:server_viewer",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -1868,7 +1940,7 @@ func (s *openFGASuite) TestServerViewer() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:server_viewer",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -1989,6 +2061,15 @@ func (s *openFGASuite) TestServerViewer() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Server viewer should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:server_viewer",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Server viewer should not be able to edit a cluster group",
 			allowed:     false,
@@ -2007,6 +2088,15 @@ func (s *openFGASuite) TestServerViewer() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Server viewer should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:server_viewer",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Server viewer should not be able to edit a storage_pool",
 			allowed:     false,
@@ -2388,40 +2478,34 @@ func (s *openFGASuite) TestServerViewer() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestProjectManager() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAProjectManager(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
+			// A manager of a project does not need view permissions on the server to target cluster groups and members
+			// or to view the storage pools available to the project, which are granted through target_project.
+			// Unlike server viewers, it cannot view certificates, cluster members and groups, cluster config,
+			// metrics or server resources.
 			User:     "group:project01_managers#member",
 			Relation: "manager",
 			Object:   "project:project01",
 		},
-		{
-			// A manager of a project needs view permissions on the server to allow targeting of cluster groups
-			// or to be able to view available storage pools for creating volumes etc.
-			User:     "group:project01_managers#member",
-			Relation: "viewer",
-			Object:   "server:lxd",
-		},
 		{
 			User:     "user:project01_manager",
 			Relation: "member",
 			Object:   "group:project01_managers",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -2435,7 +2519,7 @@ func (s *openFGASuite) TestProjectManager() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -2444,7 +2528,7 @@ func (s *openFGASuite) TestProjectManager() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -2467,8 +2551,8 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view server resources",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view server resources",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view_resources",
@@ -2494,8 +2578,8 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view cluster config",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view cluster config",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view_cluster",
@@ -2521,8 +2605,8 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view server metrics",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view server metrics",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view_metrics",
@@ -2539,8 +2623,8 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view a certificate",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view a certificate",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view",
@@ -2557,14 +2641,23 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view a cluster member",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view a cluster member",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view",
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Manager of project01 should be able to target a cluster member",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_manager",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Manager of project01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -2575,14 +2668,23 @@ func (s *openFGASuite) TestProjectManager() {
 			},
 		},
 		{
-			description: "Manager of project01 should be able to view a cluster group",
-			allowed:     true,
+			description: "Manager of project01 should not be able to view a cluster group",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_manager",
 				Relation: "can_view",
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Manager of project01 should be able to target a cluster group",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_manager",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Manager of project01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -2964,40 +3066,33 @@ func (s *openFGASuite) TestProjectManager() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestProjectOperator() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAProjectOperator(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
+			// An operator of a project can create instances and volumes in it. That alone lets it target the cluster
+			// groups and members and view the storage pools that the project can use, without server:viewer.
+			// Certificates, cluster config, metrics and server resources stay hidden from it.
 			User:     "group:project01_operators#member",
 			Relation: "operator",
 			Object:   "project:project01",
 		},
-		{
-			// An operator of a project needs view permissions on the server to allow targeting of cluster groups
-			// or to be able to view available storage pools for creating volumes etc.
-			User:     "group:project01_operators#member",
-			Relation: "viewer",
-			Object:   "server:lxd",
-		},
 		{
 			User:     "user:project01_operator",
 			Relation: "member",
 			Object:   "group:project01_operators",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -3011,7 +3106,7 @@ func (s *openFGASuite) TestProjectOperator() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -3020,7 +3115,7 @@ func (s *openFGASuite) TestProjectOperator() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -3043,8 +3138,8 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view server resources",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view server resources",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view_resources",
@@ -3070,8 +3165,8 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view cluster config",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view cluster config",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view_cluster",
@@ -3097,8 +3192,8 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view server metrics",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view server metrics",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view_metrics",
@@ -3115,8 +3210,8 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view a certificate",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view a certificate",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view",
@@ -3133,14 +3228,23 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view a cluster member",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view a cluster member",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view",
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Operator of project01 should be able to target a cluster member",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_operator",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Operator of project01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -3151,14 +3255,23 @@ func (s *openFGASuite) TestProjectOperator() {
 			},
 		},
 		{
-			description: "Operator of project01 should be able to view a cluster group",
-			allowed:     true,
+			description: "Operator of project01 should not be able to view a cluster group",
+			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_operator",
 				Relation: "can_view",
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Operator of project01 should be able to target a cluster group",
+			allowed:     true,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_operator",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Operator of project01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -3540,16 +3653,19 @@ func (s *openFGASuite) TestProjectOperator() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestProjectViewer() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAProjectViewer(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:project01_viewers#member",
 			Relation: "viewer",
@@ -3560,13 +3676,7 @@ func (s *openFGASuite) TestProjectViewer() {
 			Relation: "member",
 			Object:   "group:project01_viewers",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -3580,7 +3690,7 @@ func (s *openFGASuite) TestProjectViewer() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_viewer",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -3589,7 +3699,7 @@ func (s *openFGASuite) TestProjectViewer() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:project01_viewer",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -3710,6 +3820,15 @@ func (s *openFGASuite) TestProjectViewer() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Viewer of project01 should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_viewer",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Viewer of project01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -3728,6 +3847,15 @@ func (s *openFGASuite) TestProjectViewer() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Viewer of project01 should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:project01_viewer",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Viewer of project01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -4109,16 +4237,19 @@ func (s *openFGASuite) TestProjectViewer() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestInstanceManager() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAInstanceManager(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:instance01_managers#member",
 			Relation: "manager",
@@ -4135,13 +4266,7 @@ func (s *openFGASuite) TestInstanceManager() {
 			Relation: "member",
 			Object:   "group:instance01_managers",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -4155,7 +4280,7 @@ func (s *openFGASuite) TestInstanceManager() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_manager",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -4164,7 +4289,7 @@ func (s *openFGASuite) TestInstanceManager() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_manager",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -4285,6 +4410,15 @@ func (s *openFGASuite) TestInstanceManager() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Manager of instance01 should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_manager",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Manager of instance01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -4303,6 +4437,15 @@ func (s *openFGASuite) TestInstanceManager() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Manager of instance01 should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_manager",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Manager of instance01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -4684,16 +4827,19 @@ func (s *openFGASuite) TestInstanceManager() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestInstanceOperator() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAInstanceOperator(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:instance01_operators#member",
 			Relation: "operator",
@@ -4704,13 +4850,7 @@ func (s *openFGASuite) TestInstanceOperator() {
 			Relation: "member",
 			Object:   "group:instance01_operators",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -4724,7 +4864,7 @@ func (s *openFGASuite) TestInstanceOperator() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_operator",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -4733,7 +4873,7 @@ func (s *openFGASuite) TestInstanceOperator() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_operator",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -4854,6 +4994,15 @@ func (s *openFGASuite) TestInstanceOperator() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "Operator of instance01 should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_operator",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "Operator of instance01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -4872,6 +5021,15 @@ func (s *openFGASuite) TestInstanceOperator() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "Operator of instance01 should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_operator",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "Operator of instance01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -5253,16 +5411,19 @@ func (s *openFGASuite) TestInstanceOperator() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) TestInstanceUser() {
-	clientWriteResponse, err := s.fga.WriteTuples(context.Background()).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteTuplesBody{
+func TestOpenFGAInstanceUser(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
+	s.writeTuples(t, client.ClientWriteTuplesBody{
 		{
 			User:     "group:instance01_users#member",
 			Relation: "user",
@@ -5273,13 +5434,7 @@ func (s *openFGASuite) TestInstanceUser() {
 			Relation: "member",
 			Object:   "group:instance01_users",
 		},
-	}).Execute()
-	s.Require().NoError(err)
-
-	s.Require().Len(clientWriteResponse.Deletes, 0)
-	for _, write := range clientWriteResponse.Writes {
-		s.Require().NoError(write.Error)
-	}
+	})
 
 	type test struct {
 		description string
@@ -5293,7 +5448,7 @@ func (s *openFGASuite) TestInstanceUser() {
 			allowed:     false,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_user",
-				Relation: "can_edit",
+				Relation: "can_edit_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -5302,7 +5457,7 @@ func (s *openFGASuite) TestInstanceUser() {
 			allowed:     true,
 			request: client.ClientCheckRequest{
 				User:     "user:instance01_user",
-				Relation: "can_view",
+				Relation: "can_view_server",
 				Object:   "server:lxd",
 			},
 		},
@@ -5423,6 +5578,15 @@ func (s *openFGASuite) TestInstanceUser() {
 				Object:   "cluster_member:node01",
 			},
 		},
+		{
+			description: "User of instance01 should not be able to target a cluster member",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_user",
+				Relation: "can_target",
+				Object:   "cluster_member:node01",
+			},
+		},
 		{
 			description: "User of instance01 should not be able to edit a cluster group",
 			allowed:     false,
@@ -5441,6 +5605,15 @@ func (s *openFGASuite) TestInstanceUser() {
 				Object:   "cluster_group:group01",
 			},
 		},
+		{
+			description: "User of instance01 should not be able to target a cluster group",
+			allowed:     false,
+			request: client.ClientCheckRequest{
+				User:     "user:instance01_user",
+				Relation: "can_target",
+				Object:   "cluster_group:group01",
+			},
+		},
 		{
 			description: "User of instance01 should not be able to edit a storage_pool",
 			allowed:     false,
@@ -5822,18 +5995,21 @@ func (s *openFGASuite) TestInstanceUser() {
 	}
 
 	for i, test := range tests {
-		s.T().Logf("Case %d: %s", i, test.description)
+		t.Logf("Case %d: %s", i, test.description)
 
 		checkResponse, err := s.fga.Check(context.Background()).Options(client.ClientCheckOptions{AuthorizationModelId: s.authModelID}).Body(test.request).Execute()
-		s.Require().NoError(err)
-		s.Equal(test.allowed, checkResponse.GetAllowed())
+		require.NoError(t, err)
+		assert.Equal(t, test.allowed, checkResponse.GetAllowed())
 	}
 }
 
-func (s *openFGASuite) Test_messing_about() {
+func TestOpenFGA_messing_about(t *testing.T) {
+	t.Parallel()
+	s := newOpenFGATestStore(t)
+
 	readAuthorizationModelResponse, err := s.fga.ReadAuthorizationModel(context.Background()).Options(client.ClientReadAuthorizationModelOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientReadAuthorizationModelRequest{}).Execute()
-	s.Require().NoError(err)
-	s.Require().True(readAuthorizationModelResponse.HasAuthorizationModel())
+	require.NoError(t, err)
+	require.True(t, readAuthorizationModelResponse.HasAuthorizationModel())
 
 	authorizationModel := readAuthorizationModelResponse.GetAuthorizationModel()
 	fmt.Println(authorizationModel)
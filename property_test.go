@@ -0,0 +1,280 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// propertySeeds is the number of random tuple sets checked by each property.
+const propertySeeds = 50
+
+// propertyUniverse is a fixed hierarchy of objects over the types of a model, from which random tuples are generated.
+type propertyUniverse struct {
+	model *Model
+
+	// hierarchy links every object to its parent.
+	hierarchy []client.ClientTupleKey
+
+	// objects are all objects, in creation order.
+	objects []string
+
+	// parents maps each object to its parent object.
+	parents map[string]string
+
+	users  []string
+	groups []string
+}
+
+// newPropertyUniverse returns a universe with the server, two projects, two objects of each type below the server and
+// two objects of each type below each project.
+func newPropertyUniverse(t testing.TB) *propertyUniverse {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	u := &propertyUniverse{
+		model:   model,
+		parents: make(map[string]string),
+		users:   []string{"user:u0", "user:u1", "user:u2", "user:u3"},
+		groups:  []string{"group:g0#member", "group:g1#member"},
+	}
+
+	u.objects = append(u.objects, ServerObject().String())
+	for _, project := range []string{"p0", "p1"} {
+		u.add(ProjectObject(project).String(), ServerObject().String())
+	}
+
+	for _, objectType := range model.Types() {
+		_, parentType, ok := model.ParentRelation(objectType)
+		if !ok || ObjectType(objectType) == ObjectTypeProject {
+			continue
+		}
+
+		for i := 0; i < 2; i++ {
+			switch ObjectType(parentType) {
+			case ObjectTypeServer:
+				u.add(fmt.Sprintf("%s:%s%d", objectType, objectType, i), ServerObject().String())
+			case ObjectTypeProject:
+				for _, project := range []string{"p0", "p1"} {
+					u.add(ProjectResourceObject(ObjectType(objectType), project, fmt.Sprintf("%s%d", objectType, i)).String(), ProjectObject(project).String())
+				}
+			}
+		}
+	}
+
+	return u
+}
+
+// add adds an object below the parent.
+func (u *propertyUniverse) add(object string, parent string) {
+	objectType, _, _ := strings.Cut(object, ":")
+	parentRelation, _, _ := u.model.ParentRelation(objectType)
+	u.hierarchy = append(u.hierarchy, client.ClientTupleKey{User: parent, Relation: parentRelation, Object: object})
+	u.objects = append(u.objects, object)
+	u.parents[object] = parent
+}
+
+// isDescendant returns true if the object is below the ancestor.
+func (u *propertyUniverse) isDescendant(object string, ancestor string) bool {
+	for parent, ok := u.parents[object]; ok; parent, ok = u.parents[parent] {
+		if parent == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// project returns the project of an object, or an empty string for objects that are not in a project.
+func (u *propertyUniverse) project(object string) string {
+	for ; object != ""; object = u.parents[object] {
+		if strings.HasPrefix(object, string(ObjectTypeProject)+":") {
+			return object
+		}
+	}
+
+	return ""
+}
+
+// randomTuple returns a random tuple that the model allows, on one of the given objects. Parent relations are not
+// generated so that the hierarchy stays fixed.
+func (u *propertyUniverse) randomTuple(r *rand.Rand, objects []string) client.ClientTupleKey {
+	for {
+		object := objects[r.Intn(len(objects))]
+		objectType, _, _ := strings.Cut(object, ":")
+		parentRelation, _, _ := u.model.ParentRelation(objectType)
+
+		var relations []string
+		for _, relation := range u.model.Relations(objectType) {
+			if relation != parentRelation && len(u.model.DirectlyRelatedUserTypes(objectType, relation)) > 0 {
+				relations = append(relations, relation)
+			}
+		}
+
+		if len(relations) == 0 {
+			continue
+		}
+
+		relation := relations[r.Intn(len(relations))]
+		userTypes := u.model.DirectlyRelatedUserTypes(objectType, relation)
+		userType := userTypes[r.Intn(len(userTypes))]
+		switch {
+		case userType.Wildcard != nil:
+			return client.ClientTupleKey{User: userType.Type + ":*", Relation: relation, Object: object}
+		case userType.Type == string(ObjectTypeGroup) && userType.GetRelation() == "member":
+			return client.ClientTupleKey{User: u.groups[r.Intn(len(u.groups))], Relation: relation, Object: object}
+		case userType.Type == string(ObjectTypeUser) && userType.Relation == nil:
+			return client.ClientTupleKey{User: u.users[r.Intn(len(u.users))], Relation: relation, Object: object}
+		}
+	}
+}
+
+// randomTuples returns the hierarchy, random group memberships and n random tuples on any object.
+func (u *propertyUniverse) randomTuples(r *rand.Rand, n int) []client.ClientTupleKey {
+	tuples := append([]client.ClientTupleKey(nil), u.hierarchy...)
+	for _, user := range u.users {
+		for _, group := range u.groups {
+			if r.Intn(3) == 0 {
+				tuples = append(tuples, client.ClientTupleKey{User: user, Relation: "member", Object: strings.TrimSuffix(group, "#member")})
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		tuples = append(tuples, u.randomTuple(r, u.objects))
+	}
+
+	return tuples
+}
+
+// checkAll returns the result of checking every entitlement of the given objects for every user, keyed by
+// `user relation object`.
+func (u *propertyUniverse) checkAll(t testing.TB, tuples []client.ClientTupleKey, objects []string) map[string]bool {
+	evaluator := NewEvaluator(u.model, NewMemoryStore(tuples...))
+	results := make(map[string]bool)
+	for _, object := range objects {
+		objectType, _, _ := strings.Cut(object, ":")
+		for _, relation := range u.model.Relations(objectType) {
+			if !strings.HasPrefix(relation, "can_") {
+				continue
+			}
+
+			for _, user := range u.users {
+				allowed, err := evaluator.Check(context.Background(), client.ClientCheckRequest{User: user, Relation: relation, Object: object})
+				require.NoError(t, err)
+				results[user+" "+relation+" "+object] = allowed
+			}
+		}
+	}
+
+	return results
+}
+
+func TestPropertyMonotonic(t *testing.T) {
+	u := newPropertyUniverse(t)
+	for seed := int64(0); seed < propertySeeds; seed++ {
+		checkMonotonic(t, u, seed)
+	}
+}
+
+// checkMonotonic checks that adding a random tuple to a random tuple set never removes an allow.
+func checkMonotonic(t testing.TB, u *propertyUniverse, seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	tuples := u.randomTuples(r, 15)
+	extra := u.randomTuple(r, u.objects)
+
+	before := u.checkAll(t, tuples, u.objects)
+	after := u.checkAll(t, append(tuples, extra), u.objects)
+	for check, allowed := range before {
+		if allowed && !after[check] {
+			t.Fatalf("Seed %d: Adding %q removed %q", seed, formatTuple(extra), check)
+		}
+	}
+}
+
+func FuzzPropertyMonotonic(f *testing.F) {
+	u := newPropertyUniverse(f)
+	for seed := int64(0); seed < 5; seed++ {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		checkMonotonic(t, u, seed)
+	})
+}
+
+func TestPropertyServerAdmin(t *testing.T) {
+	u := newPropertyUniverse(t)
+	server := ServerObject().String()
+	for seed := int64(0); seed < propertySeeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		admin := u.users[r.Intn(len(u.users))]
+		tuples := append(u.randomTuples(r, 10), client.ClientTupleKey{User: admin, Relation: "admin", Object: server})
+
+		results := u.checkAll(t, tuples, u.objects)
+		for _, object := range u.objects {
+			objectType, _, _ := strings.Cut(object, ":")
+			if !u.isDescendant(object, server) || !u.model.HasRelation(objectType, "can_edit") {
+				continue
+			}
+
+			require.True(t, results[admin+" can_edit "+object], "Seed %d: %s is not allowed to edit %s", seed, admin, object)
+		}
+	}
+}
+
+func TestPropertyProjectViewer(t *testing.T) {
+	u := newPropertyUniverse(t)
+	for seed := int64(0); seed < propertySeeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		viewer := u.users[r.Intn(len(u.users))]
+		project := ProjectObject(fmt.Sprintf("p%d", r.Intn(2))).String()
+		tuples := append(u.randomTuples(r, 10), client.ClientTupleKey{User: viewer, Relation: "viewer", Object: project})
+
+		results := u.checkAll(t, tuples, u.objects)
+		for _, object := range u.objects {
+			if !u.isDescendant(object, project) {
+				continue
+			}
+
+			require.True(t, results[viewer+" can_view "+object], "Seed %d: %s is not allowed to view %s", seed, viewer, object)
+		}
+	}
+}
+
+func TestPropertyProjectIsolation(t *testing.T) {
+	u := newPropertyUniverse(t)
+
+	objectsByProject := make(map[string][]string)
+	for _, object := range u.objects {
+		project := u.project(object)
+		if project != "" {
+			objectsByProject[project] = append(objectsByProject[project], object)
+		}
+	}
+
+	for seed := int64(0); seed < propertySeeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		project, other := ProjectObject("p0").String(), ProjectObject("p1").String()
+		if r.Intn(2) == 0 {
+			project, other = other, project
+		}
+
+		tuples := u.randomTuples(r, 15)
+		before := u.checkAll(t, tuples, objectsByProject[project])
+
+		// Tuples on the other project and its objects.
+		changed := append([]client.ClientTupleKey(nil), tuples...)
+		for i := 0; i < 10; i++ {
+			changed = append(changed, u.randomTuple(r, objectsByProject[other]))
+		}
+
+		after := u.checkAll(t, changed, objectsByProject[project])
+		require.Equal(t, before, after, "Seed %d: Tuples on %s changed the permissions on %s", seed, other, project)
+	}
+}
@@ -0,0 +1,275 @@
+package openfga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// Inventory is a snapshot of the resources that exist in LXD, keyed by object type. It is decoded from a JSON export
+// such as:
+//
+//	{
+//	  "project": [{"name": "default"}],
+//	  "instance": [{"project": "default", "name": "c1"}],
+//	  "storage_pool": [{"name": "pool01"}]
+//	}
+//
+// The server object always exists and is not listed.
+type Inventory map[ObjectType][]InventoryEntry
+
+// InventoryEntry is a single resource in an Inventory. Project must be set for resources that belong to a project.
+type InventoryEntry struct {
+	Project string `json:"project,omitempty"`
+	Name    string `json:"name"`
+}
+
+// LoadInventory decodes an Inventory from JSON.
+func LoadInventory(r io.Reader) (Inventory, error) {
+	var inventory Inventory
+	err := json.NewDecoder(r).Decode(&inventory)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode inventory: %w", err)
+	}
+
+	return inventory, nil
+}
+
+// ReconcileMode determines whether Reconcile applies the fixes it computes.
+type ReconcileMode int
+
+const (
+	// ReconcileDryRun only reports issues and the tuples that would fix them.
+	ReconcileDryRun ReconcileMode = iota
+
+	// ReconcileApply reports issues and writes the fixes to the store.
+	ReconcileApply
+)
+
+// ReconcileIssueKind is the kind of inconsistency found between an Inventory and the tuples of a store.
+type ReconcileIssueKind string
+
+const (
+	// ReconcileDanglingParentLink is a parent link (e.g. `project:p1 project instance:p1/c1`) for an object that no
+	// longer exists, or that points at the wrong parent. It is fixed by deleting the tuple.
+	ReconcileDanglingParentLink ReconcileIssueKind = "dangling-parent-link"
+
+	// ReconcileMissingParentLink is an object in the inventory without a link to its parent. It is fixed by writing
+	// the tuple.
+	ReconcileMissingParentLink ReconcileIssueKind = "missing-parent-link"
+
+	// ReconcileGrantOnMissingObject is a grant on an object that no longer exists. It is fixed by deleting the tuple.
+	ReconcileGrantOnMissingObject ReconcileIssueKind = "grant-on-missing-object"
+
+	// ReconcileLinkToMissingObject is a link from an object that no longer exists to one that does, such as
+	// `project:p1 target_project storage_pool:pool01` or `project:p1 image_consumer project:default` after `p1` was
+	// deleted. It is fixed by deleting the tuple.
+	ReconcileLinkToMissingObject ReconcileIssueKind = "link-to-missing-object"
+)
+
+// ReconcileIssue is a single inconsistency and the tuple that fixes it.
+type ReconcileIssue struct {
+	Kind  ReconcileIssueKind
+	Tuple client.ClientTupleKey
+}
+
+// Write returns true if the issue is fixed by writing its tuple, and false if it is fixed by deleting it.
+func (i ReconcileIssue) Write() bool {
+	return i.Kind == ReconcileMissingParentLink
+}
+
+// String implements fmt.Stringer.
+func (i ReconcileIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Kind, formatTuple(i.Tuple))
+}
+
+// ReconcileReport is the result of Reconcile.
+type ReconcileReport struct {
+	Issues  []ReconcileIssue
+	Applied bool
+}
+
+// Writes returns the tuples to write to fix the issues in the report.
+func (r ReconcileReport) Writes() []client.ClientTupleKey {
+	var writes []client.ClientTupleKey
+	for _, issue := range r.Issues {
+		if issue.Write() {
+			writes = append(writes, issue.Tuple)
+		}
+	}
+
+	return writes
+}
+
+// Deletes returns the tuples to delete to fix the issues in the report.
+func (r ReconcileReport) Deletes() []client.ClientTupleKey {
+	var deletes []client.ClientTupleKey
+	for _, issue := range r.Issues {
+		if !issue.Write() {
+			deletes = append(deletes, issue.Tuple)
+		}
+	}
+
+	return deletes
+}
+
+// Reconcile compares the tuples in the store with an inventory of LXD resources. It reports parent links and grants
+// on objects that no longer exist, links from objects that no longer exist (such as the `target_project` and
+// `*_consumer` relations of a deleted project), and objects that are missing a link to their parent. This repairs the store after
+// LXD missed a hook, for example when a cluster member was down while a resource was deleted. In ReconcileApply mode
+// the fixes are written to the store. The inventory is that of the given cluster, and the tuples of other clusters
+// sharing the store are left alone.
+func Reconcile(ctx context.Context, store TupleStore, model *Model, cluster Cluster, inventory Inventory, mode ReconcileMode) (*ReconcileReport, error) {
+	existing, expectedLinks, err := inventoryObjects(model, cluster, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := store.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{}
+	stored := make(map[client.ClientTupleKey]struct{}, len(tuples))
+	for _, tuple := range tuples {
+		stored[tuple] = struct{}{}
+
+		object, err := ParseObject(tuple.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		parentRelation, isResource := resourceParentRelation(model, object.Type)
+		if !isResource || object.Cluster != cluster {
+			continue
+		}
+
+		_, objectExists := existing[tuple.Object]
+		_, linkExpected := expectedLinks[tuple]
+		switch {
+		case tuple.Relation == parentRelation && !linkExpected:
+			report.Issues = append(report.Issues, ReconcileIssue{Kind: ReconcileDanglingParentLink, Tuple: tuple})
+		case tuple.Relation != parentRelation && !objectExists:
+			report.Issues = append(report.Issues, ReconcileIssue{Kind: ReconcileGrantOnMissingObject, Tuple: tuple})
+		case tuple.Relation != parentRelation && isMissingResource(model, cluster, existing, tuple.User):
+			report.Issues = append(report.Issues, ReconcileIssue{Kind: ReconcileLinkToMissingObject, Tuple: tuple})
+		}
+	}
+
+	for link := range expectedLinks {
+		_, ok := stored[link]
+		if !ok {
+			report.Issues = append(report.Issues, ReconcileIssue{Kind: ReconcileMissingParentLink, Tuple: link})
+		}
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Kind != report.Issues[j].Kind {
+			return report.Issues[i].Kind < report.Issues[j].Kind
+		}
+
+		return formatTuple(report.Issues[i].Tuple) < formatTuple(report.Issues[j].Tuple)
+	})
+
+	if mode == ReconcileApply && len(report.Issues) > 0 {
+		err = writeTuplesInChunks(ctx, store, model, report.Writes(), report.Deletes())
+		if err != nil {
+			return nil, err
+		}
+
+		report.Applied = true
+	}
+
+	return report, nil
+}
+
+// resourceParentRelation returns the parent relation of the given type, and whether objects of the type are LXD
+// resources (as opposed to users and groups). The server is a resource without a parent.
+func resourceParentRelation(model *Model, objectType ObjectType) (string, bool) {
+	if objectType == ObjectTypeServer {
+		return "", true
+	}
+
+	parentRelation, _, ok := model.ParentRelation(string(objectType))
+	return parentRelation, ok
+}
+
+// isMissingResource returns true if the user of a tuple is an LXD resource of the cluster that is not in the inventory.
+// Users, groups and the resources of other clusters are never missing.
+func isMissingResource(model *Model, cluster Cluster, existing map[string]struct{}, user string) bool {
+	if strings.Contains(user, "#") {
+		return false
+	}
+
+	object, err := ParseObject(user)
+	if err != nil {
+		return false
+	}
+
+	_, isResource := resourceParentRelation(model, object.Type)
+	if !isResource || object.Cluster != cluster {
+		return false
+	}
+
+	_, ok := existing[user]
+	return !ok
+}
+
+// inventoryObjects returns the set of objects in the inventory of the cluster and the parent links that should exist
+// for them.
+func inventoryObjects(model *Model, cluster Cluster, inventory Inventory) (map[string]struct{}, map[client.ClientTupleKey]struct{}, error) {
+	existing := map[string]struct{}{
+		cluster.Server().String(): {},
+	}
+
+	for _, entry := range inventory[ObjectTypeProject] {
+		existing[cluster.Project(entry.Name).String()] = struct{}{}
+	}
+
+	links := make(map[client.ClientTupleKey]struct{})
+	for objectType, entries := range inventory {
+		if !model.HasType(string(objectType)) {
+			return nil, nil, fmt.Errorf("Inventory contains unknown type %q", objectType)
+		}
+
+		parentRelation, parentType, ok := model.ParentRelation(string(objectType))
+		if !ok {
+			return nil, nil, fmt.Errorf("Inventory contains type %q which has no parent relation", objectType)
+		}
+
+		for _, entry := range entries {
+			var object Object
+			var parent Object
+			switch ObjectType(parentType) {
+			case ObjectTypeServer:
+				object = cluster.Resource(objectType, entry.Name)
+				parent = cluster.Server()
+			case ObjectTypeProject:
+				object = cluster.ProjectResource(objectType, entry.Project, entry.Name)
+				parent = cluster.Project(entry.Project)
+				_, ok := existing[parent.String()]
+				if !ok {
+					return nil, nil, fmt.Errorf("Inventory %s %q references unknown project %q", objectType, entry.Name, entry.Project)
+				}
+
+			default:
+				return nil, nil, fmt.Errorf("Inventory contains type %q with unsupported parent type %q", objectType, parentType)
+			}
+
+			existing[object.String()] = struct{}{}
+			links[client.ClientTupleKey{
+				User:     parent.String(),
+				Relation: parentRelation,
+				Object:   object.String(),
+			}] = struct{}{}
+		}
+	}
+
+	return existing, links, nil
+}
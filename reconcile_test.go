@@ -0,0 +1,200 @@
+package openfga
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	inventory, err := LoadInventory(strings.NewReader(`{
+		"project": [{"name": "project01"}],
+		"storage_pool": [{"name": "pool01"}],
+		"instance": [{"project": "project01", "name": "instance01"}, {"project": "project01", "name": "instance02"}]
+	}`))
+	require.NoError(t, err)
+
+	tuples := []client.ClientTupleKey{
+		{User: "user:*", Relation: "user", Object: "server:lxd"},
+		{User: "user:server_admin", Relation: "admin", Object: "server:lxd"},
+		{User: "user:member", Relation: "member", Object: "group:operators"},
+		{User: "server:lxd", Relation: "server", Object: "project:project01"},
+		{User: "server:lxd", Relation: "server", Object: "storage_pool:pool01"},
+		{User: "project:project01", Relation: "target_project", Object: "storage_pool:pool01"},
+		{User: "project:project01", Relation: "project", Object: "instance:project01/instance01"},
+		{User: "user:instance_user", Relation: "user", Object: "instance:project01/instance01"},
+		// instance02 is missing its parent link.
+		// instance03 was deleted while a cluster member was down.
+		{User: "project:project01", Relation: "project", Object: "instance:project01/instance03"},
+		{User: "group:operators#member", Relation: "operator", Object: "instance:project01/instance03"},
+		// project02 was deleted.
+		{User: "server:lxd", Relation: "server", Object: "project:project02"},
+		{User: "user:project_manager", Relation: "manager", Object: "project:project02"},
+		{User: "project:project02", Relation: "target_project", Object: "storage_pool:pool01"},
+		{User: "project:project02", Relation: "image_consumer", Object: "project:project01"},
+		// pool01 is linked to the wrong server.
+		{User: "server:other", Relation: "server", Object: "storage_pool:pool01"},
+		{User: "user:anyone", Relation: "admin", Object: "server:other"},
+	}
+
+	expected := []ReconcileIssue{
+		{Kind: ReconcileDanglingParentLink, Tuple: client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance03"}},
+		{Kind: ReconcileDanglingParentLink, Tuple: client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:project02"}},
+		{Kind: ReconcileDanglingParentLink, Tuple: client.ClientTupleKey{User: "server:other", Relation: "server", Object: "storage_pool:pool01"}},
+		{Kind: ReconcileGrantOnMissingObject, Tuple: client.ClientTupleKey{User: "group:operators#member", Relation: "operator", Object: "instance:project01/instance03"}},
+		{Kind: ReconcileGrantOnMissingObject, Tuple: client.ClientTupleKey{User: "user:anyone", Relation: "admin", Object: "server:other"}},
+		{Kind: ReconcileGrantOnMissingObject, Tuple: client.ClientTupleKey{User: "user:project_manager", Relation: "manager", Object: "project:project02"}},
+		{Kind: ReconcileLinkToMissingObject, Tuple: client.ClientTupleKey{User: "project:project02", Relation: "image_consumer", Object: "project:project01"}},
+		{Kind: ReconcileLinkToMissingObject, Tuple: client.ClientTupleKey{User: "project:project02", Relation: "target_project", Object: "storage_pool:pool01"}},
+		{Kind: ReconcileMissingParentLink, Tuple: client.ClientTupleKey{User: "project:project01", Relation: "project", Object: "instance:project01/instance02"}},
+	}
+
+	store := NewMemoryStore(tuples...)
+	report, err := Reconcile(context.Background(), store, model, "", inventory, ReconcileDryRun)
+	require.NoError(t, err)
+	require.Equal(t, expected, report.Issues)
+	require.False(t, report.Applied)
+	require.Len(t, store.Tuples(), len(tuples), "Dry run must not modify the store")
+
+	report, err = Reconcile(context.Background(), store, model, "", inventory, ReconcileApply)
+	require.NoError(t, err)
+	require.Equal(t, expected, report.Issues)
+	require.True(t, report.Applied)
+	require.Len(t, store.Tuples(), len(tuples)-8+1)
+
+	report, err = Reconcile(context.Background(), store, model, "", inventory, ReconcileApply)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues, "Reconciling a reconciled store must be a no-op")
+	require.False(t, report.Applied)
+}
+
+func TestReconcileInvalidInventory(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		inventory   string
+		err         string
+	}{
+		{
+			description: "Unknown type",
+			inventory:   `{"container": [{"name": "c1"}]}`,
+			err:         `Inventory contains unknown type "container"`,
+		},
+		{
+			description: "Type without a parent",
+			inventory:   `{"group": [{"name": "admins"}]}`,
+			err:         `Inventory contains type "group" which has no parent relation`,
+		},
+		{
+			description: "Unknown project",
+			inventory:   `{"project": [{"name": "project01"}], "network": [{"project": "project02", "name": "lxdbr0"}]}`,
+			err:         `Inventory network "lxdbr0" references unknown project "project02"`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		inventory, err := LoadInventory(strings.NewReader(test.inventory))
+		require.NoError(t, err)
+
+		_, err = Reconcile(context.Background(), NewMemoryStore(), model, "", inventory, ReconcileDryRun)
+		require.EqualError(t, err, test.err)
+	}
+}
+
+func TestReconcileSharedStore(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	inventory, err := LoadInventory(strings.NewReader(`{
+		"project": [{"name": "p"}],
+		"instance": [{"project": "p", "name": "c1"}]
+	}`))
+	require.NoError(t, err)
+
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd@a", Relation: "server", Object: "project:p@a"},
+		client.ClientTupleKey{User: "project:p@a", Relation: "project", Object: "instance:p/c1@a"},
+		// Cluster b has resources that are not in the inventory of cluster a.
+		client.ClientTupleKey{User: "server:lxd@b", Relation: "server", Object: "project:q@b"},
+		client.ClientTupleKey{User: "project:q@b", Relation: "project", Object: "instance:q/c2@b"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:q/c2@b"},
+	)
+
+	report, err := Reconcile(context.Background(), store, model, "a", inventory, ReconcileDryRun)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+
+	report, err = Reconcile(context.Background(), store, model, "b", inventory, ReconcileDryRun)
+	require.NoError(t, err)
+	require.Equal(t, []ReconcileIssue{
+		{Kind: ReconcileDanglingParentLink, Tuple: client.ClientTupleKey{User: "project:q@b", Relation: "project", Object: "instance:q/c2@b"}},
+		{Kind: ReconcileDanglingParentLink, Tuple: client.ClientTupleKey{User: "server:lxd@b", Relation: "server", Object: "project:q@b"}},
+		{Kind: ReconcileGrantOnMissingObject, Tuple: client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "instance:q/c2@b"}},
+		{Kind: ReconcileMissingParentLink, Tuple: client.ClientTupleKey{User: "project:p@b", Relation: "project", Object: "instance:p/c1@b"}},
+		{Kind: ReconcileMissingParentLink, Tuple: client.ClientTupleKey{User: "server:lxd@b", Relation: "server", Object: "project:p@b"}},
+	}, report.Issues)
+}
+
+func TestParentRelation(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description    string
+		objectType     ObjectType
+		expectedParent string
+		expectedOK     bool
+	}{
+		{
+			description:    "Resources of a project",
+			objectType:     ObjectTypeInstance,
+			expectedParent: "project",
+			expectedOK:     true,
+		},
+		{
+			description:    "Projects",
+			objectType:     ObjectTypeProject,
+			expectedParent: "server",
+			expectedOK:     true,
+		},
+		{
+			description:    "Resources of the server that a project can target",
+			objectType:     ObjectTypeStoragePool,
+			expectedParent: "server",
+			expectedOK:     true,
+		},
+		{
+			description:    "Cluster members that a project can target",
+			objectType:     ObjectTypeClusterMember,
+			expectedParent: "server",
+			expectedOK:     true,
+		},
+		{
+			description: "The server has no parent",
+			objectType:  ObjectTypeServer,
+		},
+		{
+			description: "Groups have no parent",
+			objectType:  ObjectTypeGroup,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		relation, parentType, ok := model.ParentRelation(string(test.objectType))
+		require.Equal(t, test.expectedOK, ok)
+		require.Equal(t, test.expectedParent, relation)
+		require.Equal(t, test.expectedParent, parentType)
+	}
+}
@@ -0,0 +1,313 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+)
+
+// ErrExpiredToken is returned by a ChangeFeed when the continuation token is no longer valid, for example because the
+// changelog has been pruned.
+var ErrExpiredToken = errors.New("Continuation token has expired")
+
+// TupleChange is a write or delete of a tuple, as read from the changelog of a store.
+type TupleChange struct {
+	Tuple     client.ClientTupleKey
+	Deleted   bool
+	Timestamp time.Time
+}
+
+// ChangeFeed reads the changelog of a store.
+type ChangeFeed interface {
+	// ReadChanges returns the changes made after the continuation token, or from the start of the changelog if the
+	// token is empty, and the token to read the next changes from. Changes are returned in order, one page at a time.
+	// It fails with ErrExpiredToken if the token is no longer valid.
+	ReadChanges(ctx context.Context, continuationToken string) ([]TupleChange, string, error)
+}
+
+// openFGAChangeFeed is a ChangeFeed that reads the changelog of an OpenFGA store.
+type openFGAChangeFeed struct {
+	fga *client.OpenFgaClient
+}
+
+// NewOpenFGAChangeFeed returns a ChangeFeed that calls the ReadChanges API of the store of the client.
+func NewOpenFGAChangeFeed(fga *client.OpenFgaClient) ChangeFeed {
+	return &openFGAChangeFeed{fga: fga}
+}
+
+// ReadChanges implements ChangeFeed. The API is called directly, because `client.OpenFgaClient.ReadChanges` drops the
+// continuation token.
+func (f *openFGAChangeFeed) ReadChanges(ctx context.Context, continuationToken string) ([]TupleChange, string, error) {
+	request := f.fga.OpenFgaApi.ReadChanges(ctx)
+	if continuationToken != "" {
+		request = request.ContinuationToken(continuationToken)
+	}
+
+	response, _, err := request.Execute()
+	if err != nil {
+		var validationErr openfgaSDK.FgaApiValidationError
+		if errors.As(err, &validationErr) && validationErr.ResponseCode() == openfgaSDK.INVALID_CONTINUATION_TOKEN {
+			return nil, "", fmt.Errorf("Failed to read changes: %w", ErrExpiredToken)
+		}
+
+		return nil, "", fmt.Errorf("Failed to read changes: %w", err)
+	}
+
+	changes := make([]TupleChange, 0, len(response.GetChanges()))
+	for _, change := range response.GetChanges() {
+		key := change.GetTupleKey()
+		changes = append(changes, TupleChange{
+			Tuple: client.ClientTupleKey{
+				User:     key.GetUser(),
+				Relation: key.GetRelation(),
+				Object:   key.GetObject(),
+			},
+			Deleted:   change.GetOperation() == openfgaSDK.DELETE,
+			Timestamp: change.GetTimestamp(),
+		})
+	}
+
+	return changes, response.GetContinuationToken(), nil
+}
+
+// ReplicaOptions configures a Replica.
+type ReplicaOptions struct {
+	// Interval is the time between syncs in Run. Defaults to 1 second.
+	Interval time.Duration
+
+	// MaxStaleness is the maximum time since the last successful sync for which queries are answered. Queries fail
+	// once the replica is staler, so that callers can fall back or fail closed. Zero means no limit.
+	MaxStaleness time.Duration
+
+	// Metrics records syncs, lag and the size of the replica, and its queries as lookups in the `replica` cache. A
+	// query misses if the replica has never been synced or is staler than MaxStaleness. Optional.
+	Metrics *Metrics
+
+	// OnError is called with the errors of syncs in Run. Optional.
+	OnError func(err error)
+}
+
+// Replica is a local copy of the tuples of a store that answers queries with an in-process Evaluator. It is seeded by
+// reading all tuples and kept current by polling the changelog. If the continuation token expires, the replica is
+// seeded again.
+type Replica struct {
+	source  TupleStore
+	feed    ChangeFeed
+	options ReplicaOptions
+
+	store     *MemoryStore
+	evaluator *Evaluator
+
+	// syncMu serialises syncs. The token and seeded flag are only used by syncs.
+	syncMu sync.Mutex
+	token  string
+	seeded bool
+
+	mu       sync.RWMutex
+	lastSync time.Time
+}
+
+// NewReplica returns an empty replica of the store read by source, kept current by the changes of feed. Sync or Run
+// must be called before it can answer queries.
+func NewReplica(source TupleStore, feed ChangeFeed, model *Model, options ReplicaOptions) *Replica {
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+
+	store := NewMemoryStore()
+	return &Replica{
+		source:    source,
+		feed:      feed,
+		options:   options,
+		store:     store,
+		evaluator: NewEvaluator(model, store),
+	}
+}
+
+// Sync brings the replica up to date. The first sync reads all tuples of the source, and later syncs apply the changes
+// made since the previous one. If the continuation token has expired, all tuples are read again.
+func (r *Replica) Sync(ctx context.Context) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	if !r.seeded {
+		return r.seed(ctx, "initial")
+	}
+
+	var changes []TupleChange
+	token, err := r.readChanges(ctx, r.token, func(page []TupleChange) {
+		changes = append(changes, page...)
+	})
+	if errors.Is(err, ErrExpiredToken) {
+		return r.seed(ctx, "resync")
+	}
+
+	if err != nil {
+		r.observeSync("changes", err, nil)
+		return err
+	}
+
+	// Changes are applied at once, so that queries never see some of the changes of a sync but not others.
+	r.store.applyChanges(changes)
+	r.token = token
+	r.observeSync("changes", nil, changes)
+	return nil
+}
+
+// seed replaces the tuples of the replica with all tuples of the source. The end of the changelog is found before the
+// tuples are read, so that no change made during the read is missed. Changes that are already in the tuples read are
+// replayed harmlessly by the next sync.
+func (r *Replica) seed(ctx context.Context, kind string) error {
+	token, err := r.readChanges(ctx, "", nil)
+	if err != nil {
+		r.observeSync(kind, err, nil)
+		return err
+	}
+
+	tuples, err := r.source.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		err = fmt.Errorf("Failed to read tuples: %w", err)
+		r.observeSync(kind, err, nil)
+		return err
+	}
+
+	r.store.replace(tuples)
+	r.token = token
+	r.seeded = true
+	r.observeSync(kind, nil, nil)
+	return nil
+}
+
+// readChanges passes the pages of changes made after the token to f, if not nil, until the end of the changelog. It
+// returns the token to read further changes from.
+func (r *Replica) readChanges(ctx context.Context, token string, f func(changes []TupleChange)) (string, error) {
+	for {
+		changes, next, err := r.feed.ReadChanges(ctx, token)
+		if err != nil {
+			return "", err
+		}
+
+		if f != nil && len(changes) > 0 {
+			f(changes)
+		}
+
+		if len(changes) == 0 || next == "" || next == token {
+			if next != "" {
+				token = next
+			}
+
+			return token, nil
+		}
+
+		token = next
+	}
+}
+
+// observeSync records the time of a successful sync and the metrics of a sync.
+func (r *Replica) observeSync(kind string, err error, changes []TupleChange) {
+	if err == nil {
+		r.mu.Lock()
+		r.lastSync = time.Now()
+		r.mu.Unlock()
+	}
+
+	if r.options.Metrics != nil {
+		r.options.Metrics.observeSync(kind, err, changes, r.store.size())
+	}
+}
+
+// LastSync returns the time of the last successful sync, or the zero time.
+func (r *Replica) LastSync() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastSync
+}
+
+// Run syncs the replica every interval until the context is done. Failed syncs are passed to OnError, and queries are
+// answered from the last successful sync in the meantime.
+func (r *Replica) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		err := r.Sync(ctx)
+		if err != nil && ctx.Err() == nil && r.options.OnError != nil {
+			r.options.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ready returns an error if the replica cannot answer queries because it has never been synced or is too stale, and
+// records the query as a cache hit or miss.
+func (r *Replica) ready() error {
+	err := r.staleness()
+	if r.options.Metrics != nil {
+		if err == nil {
+			r.options.Metrics.CacheHit("replica")
+		} else {
+			r.options.Metrics.CacheMiss("replica")
+		}
+	}
+
+	return err
+}
+
+// staleness returns an error if the replica has never been synced or was last synced more than MaxStaleness ago.
+func (r *Replica) staleness() error {
+	lastSync := r.LastSync()
+	if lastSync.IsZero() {
+		return fmt.Errorf("Replica has not been synced")
+	}
+
+	if r.options.MaxStaleness > 0 && time.Since(lastSync) > r.options.MaxStaleness {
+		return fmt.Errorf("Replica was last synced %s ago", time.Since(lastSync).Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// Check implements Checker.
+func (r *Replica) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	err := r.ready()
+	if err != nil {
+		return false, err
+	}
+
+	return r.evaluator.Check(ctx, client.ClientCheckRequest{User: user, Relation: string(entitlement), Object: object.String()})
+}
+
+// ListObjects implements Querier.
+func (r *Replica) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	err := r.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := r.evaluator.ListObjects(ctx, client.ClientListObjectsRequest{User: user, Relation: string(entitlement), Type: string(objectType)})
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, 0, len(names))
+	for _, name := range names {
+		object, err := ParseObject(name)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,68 @@
+package openfga_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+
+	openfga "github.com/markylaing/lxd-openfga"
+	"github.com/markylaing/lxd-openfga/internal/openfgatest"
+)
+
+func TestReplicaOpenFGAChangeFeed(t *testing.T) {
+	model, err := openfga.DefaultModel()
+	require.NoError(t, err)
+
+	fake := openfgatest.NewServer(t)
+	fga := fake.NewClient(t)
+	result, err := openfga.BootstrapStore(context.Background(), fga, "lxd", model, "", nil)
+	require.NoError(t, err)
+
+	store := openfga.NewOpenFGAStore(fga, model, &result.AuthorizationModelID)
+	ctx := context.Background()
+	require.NoError(t, store.WriteTuples(ctx, []client.ClientTupleKey{
+		{User: "server:lxd", Relation: "server", Object: "project:p"},
+		{User: "project:p", Relation: "project", Object: "instance:p/c1"},
+		{User: "user:alice", Relation: "operator", Object: "instance:p/c1"},
+	}, nil))
+
+	replica := openfga.NewReplica(store, openfga.NewOpenFGAChangeFeed(fga), model, openfga.ReplicaOptions{})
+	require.NoError(t, replica.Sync(ctx))
+
+	c1 := openfga.ProjectResourceObject(openfga.ObjectTypeInstance, "p", "c1")
+	allowed, err := replica.Check(ctx, "user:alice", openfga.EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Changes are read from the changelog of the store, across pages.
+	require.NoError(t, store.WriteTuples(ctx, []client.ClientTupleKey{
+		{User: "user:bob", Relation: "operator", Object: "instance:p/c1"},
+		{User: "user:carol", Relation: "operator", Object: "instance:p/c1"},
+	}, []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:p/c1"}}))
+
+	require.NoError(t, replica.Sync(ctx))
+	for user, expected := range map[string]bool{"user:alice": false, "user:bob": true, "user:carol": true} {
+		allowed, err = replica.Check(ctx, user, openfga.EntitlementCanExec, c1)
+		require.NoError(t, err)
+		require.Equal(t, expected, allowed, user)
+	}
+
+	// Changes can be filtered by the type of their object.
+	changes, _, err := fga.OpenFgaApi.ReadChanges(ctx).Type_("project").Execute()
+	require.NoError(t, err)
+	require.Len(t, changes.GetChanges(), 1)
+	require.Equal(t, "project:p", changes.GetChanges()[0].TupleKey.GetObject())
+
+	// A pruned changelog expires the token, and the replica reads all tuples again.
+	require.NoError(t, store.WriteTuples(ctx, nil, []client.ClientTupleKey{{User: "user:bob", Relation: "operator", Object: "instance:p/c1"}}))
+	fake.PruneChanges(result.StoreID)
+	_, _, err = openfga.NewOpenFGAChangeFeed(fga).ReadChanges(ctx, "1")
+	require.ErrorIs(t, err, openfga.ErrExpiredToken)
+
+	require.NoError(t, replica.Sync(ctx))
+	allowed, err = replica.Check(ctx, "user:bob", openfga.EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
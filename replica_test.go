@@ -0,0 +1,266 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChangeFeed is a ChangeFeed that logs the writes to an in-memory store. Tokens are offsets in the log, and the
+// log can be pruned to expire them.
+type fakeChangeFeed struct {
+	source   *MemoryStore
+	pageSize int
+
+	mu      sync.Mutex
+	changes []TupleChange
+	pruned  int
+	fail    bool
+	reads   int
+}
+
+func newFakeChangeFeed() *fakeChangeFeed {
+	return &fakeChangeFeed{source: NewMemoryStore(), pageSize: 2}
+}
+
+// write writes and deletes tuples in the source and logs the changes.
+func (f *fakeChangeFeed) write(t *testing.T, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) {
+	require.NoError(t, f.source.WriteTuples(context.Background(), writes, deletes))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, tuple := range deletes {
+		f.changes = append(f.changes, TupleChange{Tuple: tuple, Deleted: true, Timestamp: time.Now()})
+	}
+
+	for _, tuple := range writes {
+		f.changes = append(f.changes, TupleChange{Tuple: tuple, Timestamp: time.Now()})
+	}
+}
+
+// prune removes all changes from the log, expiring all tokens.
+func (f *fakeChangeFeed) prune() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pruned = len(f.changes)
+}
+
+func (f *fakeChangeFeed) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+// ReadChanges implements ChangeFeed.
+func (f *fakeChangeFeed) ReadChanges(ctx context.Context, continuationToken string) ([]TupleChange, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	if f.fail {
+		return nil, "", errors.New("Changes feed is unavailable")
+	}
+
+	start := f.pruned
+	if continuationToken != "" {
+		start, _ = strconv.Atoi(continuationToken)
+		if start < f.pruned {
+			return nil, "", ErrExpiredToken
+		}
+	}
+
+	end := start + f.pageSize
+	if end > len(f.changes) {
+		end = len(f.changes)
+	}
+
+	return append([]TupleChange(nil), f.changes[start:end]...), strconv.Itoa(end), nil
+}
+
+func TestReplica(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	feed := newFakeChangeFeed()
+	feed.write(t, []client.ClientTupleKey{
+		{User: "server:lxd", Relation: "server", Object: "project:p"},
+		{User: "project:p", Relation: "project", Object: "instance:p/c1"},
+		{User: "user:alice", Relation: "operator", Object: "instance:p/c1"},
+	}, nil)
+
+	metrics := NewMetrics()
+	replica := NewReplica(feed.source, feed, model, ReplicaOptions{Metrics: metrics})
+	ctx := context.Background()
+	c1 := ProjectResourceObject(ObjectTypeInstance, "p", "c1")
+
+	// Queries fail until the replica is seeded.
+	_, err = replica.Check(ctx, "user:alice", EntitlementCanExec, c1)
+	require.ErrorContains(t, err, "Replica has not been synced")
+
+	require.NoError(t, replica.Sync(ctx))
+	require.Equal(t, feed.source.Tuples(), replica.store.Tuples())
+	require.False(t, replica.LastSync().IsZero())
+
+	allowed, err := replica.Check(ctx, "user:alice", EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Changes are applied by the next sync, across pages.
+	feed.write(t, []client.ClientTupleKey{
+		{User: "user:bob", Relation: "operator", Object: "instance:p/c1"},
+		{User: "project:p", Relation: "project", Object: "instance:p/c2"},
+		{User: "user:bob", Relation: "operator", Object: "instance:p/c2"},
+	}, []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:p/c1"}})
+
+	allowed, err = replica.Check(ctx, "user:bob", EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	require.NoError(t, replica.Sync(ctx))
+	require.Equal(t, feed.source.Tuples(), replica.store.Tuples())
+
+	allowed, err = replica.Check(ctx, "user:alice", EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	objects, err := replica.ListObjects(ctx, "user:bob", EntitlementCanExec, ObjectTypeInstance)
+	require.NoError(t, err)
+	require.Equal(t, []Object{c1, ProjectResourceObject(ObjectTypeInstance, "p", "c2")}, objects)
+
+	// Replaying changes that are already in the replica is harmless.
+	replica.token = ""
+	require.NoError(t, replica.Sync(ctx))
+	require.Equal(t, feed.source.Tuples(), replica.store.Tuples())
+
+	// An expired token makes the replica read all tuples again.
+	feed.write(t, []client.ClientTupleKey{{User: "user:carol", Relation: "operator", Object: "instance:p/c1"}}, nil)
+	feed.prune()
+	require.NoError(t, replica.Sync(ctx))
+	require.Equal(t, feed.source.Tuples(), replica.store.Tuples())
+
+	allowed, err = replica.Check(ctx, "user:carol", EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Failed syncs leave the replica answering from its last state.
+	feed.setFail(true)
+	require.Error(t, replica.Sync(ctx))
+	allowed, err = replica.Check(ctx, "user:carol", EntitlementCanExec, c1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.replicaSyncs.WithLabelValues("initial", "ok")))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.replicaSyncs.WithLabelValues("changes", "ok")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.replicaSyncs.WithLabelValues("resync", "ok")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.replicaSyncs.WithLabelValues("changes", "error")))
+	require.Equal(t, float64(len(feed.source.Tuples())), testutil.ToFloat64(metrics.replicaTuples))
+	require.Greater(t, testutil.ToFloat64(metrics.replicaLastSync), float64(0))
+	require.Equal(t, float64(6), testutil.ToFloat64(metrics.cache.WithLabelValues("replica", "hit")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.cache.WithLabelValues("replica", "miss")))
+
+	// The lag is recorded for the four changes of the first poll and the seven replayed ones.
+	lag := &dto.Metric{}
+	require.NoError(t, metrics.replicaLag.Write(lag))
+	require.Equal(t, uint64(11), lag.GetHistogram().GetSampleCount())
+}
+
+func TestReplicaRun(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	feed := newFakeChangeFeed()
+	feed.write(t, []client.ClientTupleKey{{User: "user:alice", Relation: "admin", Object: "server:lxd"}}, nil)
+
+	errs := make(chan error, 10)
+	replica := NewReplica(feed.source, feed, model, ReplicaOptions{Interval: time.Millisecond, MaxStaleness: time.Minute, OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		replica.Run(ctx)
+		close(done)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	isAdmin := func(user string) bool {
+		allowed, err := replica.Check(context.Background(), user, EntitlementCanEditServer, ServerObject())
+		return err == nil && allowed
+	}
+
+	require.Eventually(t, func() bool { return isAdmin("user:alice") }, time.Second, time.Millisecond)
+
+	feed.write(t, []client.ClientTupleKey{{User: "user:bob", Relation: "admin", Object: "server:lxd"}}, nil)
+	require.Eventually(t, func() bool { return isAdmin("user:bob") }, time.Second, time.Millisecond)
+
+	feed.setFail(true)
+	require.Error(t, <-errs)
+	cancel()
+	<-done
+
+	// Queries fail once the replica is too stale.
+	replica.options.MaxStaleness = time.Nanosecond
+	_, err = replica.Check(context.Background(), "user:alice", EntitlementCanEditServer, ServerObject())
+	require.ErrorContains(t, err, "Replica was last synced")
+}
+
+func TestChangeFeed(t *testing.T) {
+	const storeID = "01HVMMBCMGZNT3SED4Z17ECXCA"
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/stores/"+storeID+"/changes", r.URL.Path)
+		token := r.URL.Query().Get("continuation_token")
+		tokens = append(tokens, token)
+		w.Header().Set("Content-Type", "application/json")
+		switch token {
+		case "":
+			_, _ = w.Write([]byte(`{"changes": [
+				{"tuple_key": {"user": "user:alice", "relation": "operator", "object": "project:p"}, "operation": "TUPLE_OPERATION_WRITE", "timestamp": "2024-04-01T12:00:00Z"},
+				{"tuple_key": {"user": "user:bob", "relation": "viewer", "object": "project:p"}, "operation": "TUPLE_OPERATION_DELETE", "timestamp": "2024-04-01T12:00:01Z"}
+			], "continuation_token": "next"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code": "invalid_continuation_token", "message": "Invalid continuation token"}`))
+		}
+	}))
+	defer server.Close()
+
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{
+		ApiScheme: "http",
+		ApiHost:   strings.TrimPrefix(server.URL, "http://"),
+		StoreId:   storeID,
+	})
+	require.NoError(t, err)
+
+	feed := NewOpenFGAChangeFeed(fga)
+	changes, token, err := feed.ReadChanges(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, "next", token)
+	require.Equal(t, []TupleChange{
+		{Tuple: client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "project:p"}, Timestamp: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{Tuple: client.ClientTupleKey{User: "user:bob", Relation: "viewer", Object: "project:p"}, Deleted: true, Timestamp: time.Date(2024, 4, 1, 12, 0, 1, 0, time.UTC)},
+	}, changes)
+
+	_, _, err = feed.ReadChanges(context.Background(), token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Equal(t, []string{"", "next"}, tokens)
+}
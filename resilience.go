@@ -0,0 +1,415 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+)
+
+// ErrCircuitOpen is returned without calling OpenFGA while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("Circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState string
+
+// Circuit breaker states.
+const (
+	// CircuitClosed passes all calls.
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen rejects all calls with ErrCircuitOpen.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen passes a single probe call, whose result closes or reopens the circuit.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreaker stops calling a backend after a number of consecutive failures, so that a struggling OpenFGA server
+// is not overloaded and callers fail fast. After the open duration a single call is let through as a probe.
+type CircuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns a circuit breaker that opens after threshold consecutive failures and stays open for the
+// given duration.
+func NewCircuitBreaker(threshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, openDuration: openDuration, now: time.Now, state: CircuitClosed}
+}
+
+// State returns the state of the circuit breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openDuration {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if a call must not be made. Once the open duration has passed, the first caller is let
+// through as a probe and the circuit is half-open until its result is recorded.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return ErrCircuitOpen
+		}
+
+		b.state = CircuitHalfOpen
+		return nil
+	case CircuitHalfOpen:
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
+
+// record records the result of a call that was allowed.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release gives up a call that was allowed without recording its result. If it was the probe of a half-open circuit,
+// the next call becomes the probe.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitHalfOpen {
+		b.state = CircuitOpen
+	}
+}
+
+// FailurePolicy decides the result of a check that fails because OpenFGA cannot be reached.
+type FailurePolicy string
+
+// Failure policies.
+const (
+	// FailClosed denies every check that fails. This is the default.
+	FailClosed FailurePolicy = "closed"
+
+	// FailClosedExceptAdmins denies every check that fails, unless the user is a server admin according to the tuples
+	// in the AdminCache. This keeps the server manageable by its admins while OpenFGA is down.
+	FailClosedExceptAdmins FailurePolicy = "closed-except-admins"
+)
+
+// AdminCache is a local copy of the tuples that make users server admins: the `admin` tuples of the server and the
+// members of the groups in them.
+type AdminCache struct {
+	store   TupleStore
+	model   *Model
+	cluster Cluster
+
+	mu        sync.RWMutex
+	evaluator *Evaluator
+	refreshed time.Time
+}
+
+// NewAdminCache returns an empty cache of the admins of the server of the cluster. Refresh must be called to fill it,
+// and should be called periodically while OpenFGA is available.
+func NewAdminCache(store TupleStore, model *Model, cluster Cluster) *AdminCache {
+	return &AdminCache{store: store, model: model, cluster: cluster}
+}
+
+// Refresh reads the admin tuples of the server and the members of the groups in them. The cache is left unchanged if
+// the store cannot be read.
+func (c *AdminCache) Refresh(ctx context.Context) error {
+	server := c.cluster.Server().String()
+	tuples, err := c.store.ReadTuples(ctx, client.ClientTupleKey{Relation: string(RoleAdmin), Object: server})
+	if err != nil {
+		return fmt.Errorf("Failed to read server admins: %w", err)
+	}
+
+	for _, tuple := range tuples {
+		group, relation, isUserset := strings.Cut(tuple.User, "#")
+		if !isUserset {
+			continue
+		}
+
+		members, err := c.store.ReadTuples(ctx, client.ClientTupleKey{Relation: relation, Object: group})
+		if err != nil {
+			return fmt.Errorf("Failed to read members of %q: %w", group, err)
+		}
+
+		tuples = append(tuples, members...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evaluator = NewEvaluator(c.model, NewMemoryStore(tuples...))
+	c.refreshed = time.Now()
+	return nil
+}
+
+// Refreshed returns the time of the last successful refresh, or the zero time.
+func (c *AdminCache) Refreshed() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshed
+}
+
+// IsAdmin returns true if the user is a server admin according to the cached tuples.
+func (c *AdminCache) IsAdmin(ctx context.Context, user string) bool {
+	admin, _ := c.lookup(ctx, user)
+	return admin
+}
+
+// lookup returns true if the user is a server admin according to the cached tuples, and whether the cache has been
+// filled. An empty cache knows no admins.
+func (c *AdminCache) lookup(ctx context.Context, user string) (admin bool, filled bool) {
+	c.mu.RLock()
+	evaluator := c.evaluator
+	c.mu.RUnlock()
+	if evaluator == nil {
+		return false, false
+	}
+
+	allowed, err := evaluator.Check(ctx, client.ClientCheckRequest{User: user, Relation: string(RoleAdmin), Object: c.cluster.Server().String()})
+	return err == nil && allowed, true
+}
+
+// ResilienceOptions configures NewResilientQuerier and NewResilientStore. Zero values are replaced by the defaults.
+type ResilienceOptions struct {
+	// Timeout is the maximum duration of a single call to the backend. Defaults to 2 seconds.
+	Timeout time.Duration
+
+	// MaxAttempts is the maximum number of attempts of a read (Check, ListObjects and ReadTuples). Writes are made only
+	// once, as they are not idempotent. Defaults to 3.
+	MaxAttempts int
+
+	// Backoff is the maximum delay before the first retry. It doubles with every retry, and the actual delay is chosen
+	// at random up to the maximum (full jitter). Defaults to 50 milliseconds.
+	Backoff time.Duration
+
+	// Breaker is shared by all calls. Defaults to a breaker that opens after 5 consecutive failures for 10 seconds. To
+	// share one breaker between a Querier and a TupleStore for the same backend, pass the same breaker to both.
+	Breaker *CircuitBreaker
+
+	// FailurePolicy decides failed checks. Defaults to FailClosed.
+	FailurePolicy FailurePolicy
+
+	// Admins is required by FailClosedExceptAdmins.
+	Admins *AdminCache
+
+	// Metrics records the lookups in Admins, in the `admins` cache. A lookup misses if Admins has never been refreshed.
+	// Optional.
+	Metrics *Metrics
+}
+
+// withDefaults returns the options with defaults for zero values.
+func (o ResilienceOptions) withDefaults() ResilienceOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = 2 * time.Second
+	}
+
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+
+	if o.Backoff <= 0 {
+		o.Backoff = 50 * time.Millisecond
+	}
+
+	if o.Breaker == nil {
+		o.Breaker = NewCircuitBreaker(5, 10*time.Second)
+	}
+
+	if o.FailurePolicy == "" {
+		o.FailurePolicy = FailClosed
+	}
+
+	return o
+}
+
+// call makes a single call to the backend through the circuit breaker, with the per-call timeout.
+func (o ResilienceOptions) call(ctx context.Context, f func(ctx context.Context) error) error {
+	err := o.Breaker.allow()
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	err = f(callCtx)
+
+	// A call abandoned by the caller says nothing about the health of the backend.
+	if ctx.Err() != nil {
+		o.Breaker.release()
+	} else {
+		o.Breaker.record(isTransient(err))
+	}
+
+	return err
+}
+
+// isTransient returns true if the error means that the backend is unavailable or overloaded, rather than that the
+// request is invalid. Only transient errors are retried and counted by the circuit breaker.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	var internalErr openfgaSDK.FgaApiInternalError
+	var rateLimitErr openfgaSDK.FgaApiRateLimitExceededError
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) || errors.As(err, &internalErr) || errors.As(err, &rateLimitErr)
+}
+
+// isUnavailable returns true if the error means that the backend cannot be reached, either because the call failed
+// with a transient error or because the circuit breaker is open. The failure policy applies to these errors.
+func isUnavailable(err error) bool {
+	return isTransient(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// retry makes up to MaxAttempts calls until one succeeds or fails with an error that is not transient, sleeping for a jittered exponential backoff in between. It
+// stops early if the circuit breaker opens or the context is done.
+func (o ResilienceOptions) retry(ctx context.Context, f func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < o.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(rand.Int63n(int64(o.Backoff<<(attempt-1)) + 1))
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("Gave up after %d attempts: %w", attempt, err)
+			case <-time.After(delay):
+			}
+		}
+
+		err = o.call(ctx, f)
+		if !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("Gave up after %d attempts: %w", o.MaxAttempts, err)
+}
+
+// resilientQuerier is a Querier that makes the queries of the wrapped Querier resilient to backend failures.
+type resilientQuerier struct {
+	querier Querier
+	options ResilienceOptions
+}
+
+// NewResilientQuerier returns a Querier that calls the given Querier with a timeout, retries failed queries, stops
+// calling it while the circuit breaker is open, and applies the failure policy to checks that fail. Configure the
+// OpenFGA SDK client without RetryParams so that failures are not retried twice.
+func NewResilientQuerier(querier Querier, options ResilienceOptions) Querier {
+	return &resilientQuerier{querier: querier, options: options.withDefaults()}
+}
+
+// Check implements Checker. A check that fails is denied with the error, unless OpenFGA is unavailable and the failure
+// policy allows the user.
+func (q *resilientQuerier) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	var allowed bool
+	err := q.options.retry(ctx, func(ctx context.Context) error {
+		var err error
+		allowed, err = q.querier.Check(ctx, user, entitlement, object)
+		return err
+	})
+	if err == nil {
+		return allowed, nil
+	}
+
+	if isUnavailable(err) && q.options.FailurePolicy == FailClosedExceptAdmins && q.options.isAdmin(ctx, user) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// isAdmin returns true if the user is a server admin according to the admin cache, and records the lookup.
+func (o ResilienceOptions) isAdmin(ctx context.Context, user string) bool {
+	if o.Admins == nil {
+		return false
+	}
+
+	admin, filled := o.Admins.lookup(ctx, user)
+	if o.Metrics != nil {
+		if filled {
+			o.Metrics.CacheHit("admins")
+		} else {
+			o.Metrics.CacheMiss("admins")
+		}
+	}
+
+	return admin
+}
+
+// ListObjects implements Querier. Listings that fail return the error, regardless of the failure policy.
+func (q *resilientQuerier) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	var objects []Object
+	err := q.options.retry(ctx, func(ctx context.Context) error {
+		var err error
+		objects, err = q.querier.ListObjects(ctx, user, entitlement, objectType)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// resilientStore is a TupleStore that makes the reads and writes of the wrapped TupleStore resilient to backend
+// failures.
+type resilientStore struct {
+	store   TupleStore
+	options ResilienceOptions
+}
+
+// NewResilientStore returns a TupleStore that calls the given store with a timeout, retries failed reads and stops
+// calling it while the circuit breaker is open. The failure policy does not apply to the store.
+func NewResilientStore(store TupleStore, options ResilienceOptions) TupleStore {
+	return &resilientStore{store: store, options: options.withDefaults()}
+}
+
+// ReadTuples implements TupleStore.
+func (s *resilientStore) ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	var tuples []client.ClientTupleKey
+	err := s.options.retry(ctx, func(ctx context.Context) error {
+		var err error
+		tuples, err = s.store.ReadTuples(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tuples, nil
+}
+
+// WriteTuples implements TupleStore. Writes are not retried.
+func (s *resilientStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	return s.options.call(ctx, func(ctx context.Context) error {
+		return s.store.WriteTuples(ctx, writes, deletes)
+	})
+}
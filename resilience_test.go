@@ -0,0 +1,359 @@
+package openfga
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	openfgaSDK "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// faultServer is a local stand-in for OpenFGA that answers checks and reads from in-memory tuples, and fails or delays
+// requests on demand.
+type faultServer struct {
+	server    *httptest.Server
+	store     *MemoryStore
+	evaluator *Evaluator
+
+	mu       sync.Mutex
+	down     bool
+	failures int
+	status   int
+	delay    time.Duration
+	requests map[string]int
+}
+
+// newFaultServer starts a fault server with the given tuples and returns an Authorizer that calls it.
+func newFaultServer(t *testing.T, tuples ...client.ClientTupleKey) (*faultServer, *Authorizer) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewMemoryStore(tuples...)
+	s := &faultServer{store: store, evaluator: NewEvaluator(model, store), requests: make(map[string]int)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.server.Close)
+
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{
+		ApiScheme: "http",
+		ApiHost:   strings.TrimPrefix(s.server.URL, "http://"),
+		StoreId:   "01HVMMBCMGZNT3SED4Z17ECXCA",
+	})
+	require.NoError(t, err)
+
+	modelID := "01HVMMBD3M9QRJ8D5E7NBKX6Y2"
+	return s, NewAuthorizer(fga, model, &modelID)
+}
+
+// fail makes the next n requests fail with the given status.
+func (s *faultServer) fail(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+	s.status = status
+}
+
+// setDown makes all requests fail with a 500 until it is called with false.
+func (s *faultServer) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+	s.status = http.StatusInternalServerError
+}
+
+// setDelay delays all responses.
+func (s *faultServer) setDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// count returns the number of requests made to the endpoint, e.g. "check".
+func (s *faultServer) count(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func (s *faultServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	s.mu.Lock()
+	s.requests[endpoint]++
+	delay := s.delay
+	status := 0
+	if s.down || s.failures > 0 {
+		s.failures--
+		status = s.status
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status != 0 {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code": "internal_error", "message": "Injected fault"}`))
+		return
+	}
+
+	var request struct {
+		TupleKey client.ClientTupleKey `json:"tuple_key"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch endpoint {
+	case "check":
+		allowed, err := s.evaluator.Check(r.Context(), client.ClientCheckRequest{User: request.TupleKey.User, Relation: request.TupleKey.Relation, Object: request.TupleKey.Object})
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"allowed": allowed})
+	case "read":
+		tuples, _ := s.store.ReadTuples(r.Context(), request.TupleKey)
+		keys := make([]map[string]any, 0, len(tuples))
+		for _, tuple := range tuples {
+			keys = append(keys, map[string]any{"key": tuple})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"tuples": keys, "continuation_token": ""})
+	case "write":
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// resilienceTestTuples make alice a server admin, bob a server admin through the admins group, and carol an operator
+// of instance p/c1.
+var resilienceTestTuples = []client.ClientTupleKey{
+	{User: "user:alice", Relation: "admin", Object: "server:lxd"},
+	{User: "group:admins#member", Relation: "admin", Object: "server:lxd"},
+	{User: "user:bob", Relation: "member", Object: "group:admins"},
+	{User: "server:lxd", Relation: "server", Object: "project:p"},
+	{User: "project:p", Relation: "project", Object: "instance:p/c1"},
+	{User: "user:carol", Relation: "operator", Object: "instance:p/c1"},
+}
+
+// fakeClock is a clock for circuit breakers that only moves when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestResilientQuerierRetries(t *testing.T) {
+	fault, authorizer := newFaultServer(t, resilienceTestTuples...)
+	querier := NewResilientQuerier(authorizer, ResilienceOptions{Backoff: time.Millisecond})
+	object := ProjectResourceObject(ObjectTypeInstance, "p", "c1")
+
+	// Transient failures are retried.
+	fault.fail(2, http.StatusInternalServerError)
+	allowed, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Equal(t, 3, fault.count("check"))
+
+	// Retries stop after the maximum number of attempts.
+	fault.fail(3, http.StatusInternalServerError)
+	_, err = querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.ErrorContains(t, err, "Gave up after 3 attempts")
+	require.ErrorAs(t, err, &openfgaSDK.FgaApiInternalError{})
+	require.Equal(t, 6, fault.count("check"))
+
+	// Invalid requests are not retried.
+	fault.fail(1, http.StatusBadRequest)
+	_, err = querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.ErrorAs(t, err, &openfgaSDK.FgaApiValidationError{})
+	require.Equal(t, 7, fault.count("check"))
+
+	_, err = querier.Check(context.Background(), "user:carol", "can_fly", object)
+	require.Error(t, err)
+	require.Equal(t, 7, fault.count("check"))
+}
+
+func TestResilientQuerierTimeout(t *testing.T) {
+	fault, authorizer := newFaultServer(t, resilienceTestTuples...)
+	querier := NewResilientQuerier(authorizer, ResilienceOptions{Timeout: 20 * time.Millisecond, MaxAttempts: 2, Backoff: time.Millisecond})
+
+	fault.setDelay(200 * time.Millisecond)
+	start := time.Now()
+	allowed, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, allowed)
+	require.Less(t, time.Since(start), 200*time.Millisecond)
+	require.Equal(t, 2, fault.count("check"))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	fault, authorizer := newFaultServer(t, resilienceTestTuples...)
+	clock := &fakeClock{now: time.Now()}
+	breaker := NewCircuitBreaker(3, 10*time.Second)
+	breaker.now = clock.Now
+	querier := NewResilientQuerier(authorizer, ResilienceOptions{MaxAttempts: 1, Breaker: breaker})
+	object := ProjectResourceObject(ObjectTypeInstance, "p", "c1")
+
+	// The circuit opens after three consecutive failures.
+	fault.setDown(true)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, CircuitClosed, breaker.State())
+		_, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+		require.ErrorAs(t, err, &openfgaSDK.FgaApiInternalError{})
+	}
+
+	require.Equal(t, CircuitOpen, breaker.State())
+
+	// While open, calls fail fast without reaching the server.
+	_, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, 3, fault.count("check"))
+
+	// A failed probe reopens the circuit.
+	clock.now = clock.now.Add(10 * time.Second)
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+	_, err = querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.ErrorAs(t, err, &openfgaSDK.FgaApiInternalError{})
+	require.Equal(t, CircuitOpen, breaker.State())
+	require.Equal(t, 4, fault.count("check"))
+
+	// A successful probe closes it.
+	fault.setDown(false)
+	clock.now = clock.now.Add(10 * time.Second)
+	allowed, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Equal(t, CircuitClosed, breaker.State())
+
+	// Invalid requests do not count as failures.
+	for i := 0; i < 3; i++ {
+		fault.fail(1, http.StatusBadRequest)
+		_, err = querier.Check(context.Background(), "user:carol", EntitlementCanExec, object)
+		require.ErrorAs(t, err, &openfgaSDK.FgaApiValidationError{})
+	}
+
+	require.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestResilientQuerierFailurePolicy(t *testing.T) {
+	fault, authorizer := newFaultServer(t, resilienceTestTuples...)
+	admins := NewAdminCache(NewResilientStore(authorizer.Store(), ResilienceOptions{}), authorizer.model, "")
+	require.True(t, admins.Refreshed().IsZero())
+	require.NoError(t, admins.Refresh(context.Background()))
+	require.False(t, admins.Refreshed().IsZero())
+
+	fault.setDown(true)
+	require.Error(t, admins.Refresh(context.Background()))
+	require.True(t, admins.IsAdmin(context.Background(), "user:alice"))
+
+	tests := []struct {
+		description string
+		policy      FailurePolicy
+		user        string
+		allowed     bool
+	}{
+		{
+			description: "Fail closed denies admins",
+			policy:      FailClosed,
+			user:        "user:alice",
+		},
+		{
+			description: "Fail closed except admins allows a direct admin",
+			policy:      FailClosedExceptAdmins,
+			user:        "user:alice",
+			allowed:     true,
+		},
+		{
+			description: "Fail closed except admins allows an admin through a group",
+			policy:      FailClosedExceptAdmins,
+			user:        "user:bob",
+			allowed:     true,
+		},
+		{
+			description: "Fail closed except admins denies other users",
+			policy:      FailClosedExceptAdmins,
+			user:        "user:carol",
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		querier := NewResilientQuerier(authorizer, ResilienceOptions{MaxAttempts: 1, FailurePolicy: test.policy, Admins: admins})
+		allowed, err := querier.Check(context.Background(), test.user, EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+		require.Equal(t, test.allowed, allowed)
+		if test.allowed {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+
+	// The failure policy still applies once enough checks have failed to open the circuit breaker.
+	breaker := NewCircuitBreaker(2, time.Minute)
+	metrics := NewMetrics()
+	querier := NewResilientQuerier(authorizer, ResilienceOptions{MaxAttempts: 1, Breaker: breaker, FailurePolicy: FailClosedExceptAdmins, Admins: admins, Metrics: metrics})
+	for i := 0; i < 5; i++ {
+		allowed, err := querier.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		allowed, err = querier.Check(context.Background(), "user:carol", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+		require.Error(t, err)
+		require.False(t, allowed)
+	}
+
+	require.Equal(t, CircuitOpen, breaker.State())
+	_, err := querier.Check(context.Background(), "user:carol", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, float64(11), testutil.ToFloat64(metrics.cache.WithLabelValues("admins", "hit")))
+
+	// Without a refresh, nobody is an admin.
+	querier = NewResilientQuerier(authorizer, ResilienceOptions{MaxAttempts: 1, FailurePolicy: FailClosedExceptAdmins, Admins: NewAdminCache(authorizer.Store(), authorizer.model, ""), Metrics: metrics})
+	allowed, err := querier.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.Error(t, err)
+	require.False(t, allowed)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.cache.WithLabelValues("admins", "miss")))
+}
+
+func TestResilientStore(t *testing.T) {
+	fault, authorizer := newFaultServer(t, resilienceTestTuples...)
+	store := NewResilientStore(authorizer.Store(), ResilienceOptions{Backoff: time.Millisecond})
+
+	// Reads are retried.
+	fault.fail(1, http.StatusInternalServerError)
+	tuples, err := store.ReadTuples(context.Background(), client.ClientTupleKey{Relation: "member", Object: "group:admins"})
+	require.NoError(t, err)
+	require.Equal(t, []client.ClientTupleKey{{User: "user:bob", Relation: "member", Object: "group:admins"}}, tuples)
+	require.Equal(t, 2, fault.count("read"))
+
+	// Writes are not.
+	fault.fail(1, http.StatusInternalServerError)
+	err = store.WriteTuples(context.Background(), []client.ClientTupleKey{{User: "user:dave", Relation: "member", Object: "group:admins"}}, nil)
+	require.ErrorAs(t, err, &openfgaSDK.FgaApiInternalError{})
+	require.Equal(t, 1, fault.count("write"))
+
+	err = store.WriteTuples(context.Background(), []client.ClientTupleKey{{User: "user:dave", Relation: "member", Object: "group:admins"}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, fault.count("write"))
+}
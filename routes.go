@@ -0,0 +1,130 @@
+package openfga
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// routeManifest is the manifest of LXD API routes.
+//
+//go:embed routes.yaml
+var routeManifest []byte
+
+// ManifestRoute is an entry of the LXD API route manifest in routes.yaml.
+type ManifestRoute struct {
+	Method      string `yaml:"method"`
+	Path        string `yaml:"path"`
+	Description string `yaml:"description"`
+
+	// Type and Entitlement are the relation that is checked for the route.
+	Type        ObjectType  `yaml:"type"`
+	Entitlement Entitlement `yaml:"entitlement"`
+
+	// Object is the template of the object ID. See ExtractTemplate.
+	Object string `yaml:"object"`
+}
+
+// String returns the method and path of the route.
+func (r ManifestRoute) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Route returns the route for the manifest entry.
+func (r ManifestRoute) Route() Route {
+	return Route{Method: r.Method, Pattern: r.Path, Type: r.Type, Entitlement: r.Entitlement, Object: ExtractTemplate(r.Object)}
+}
+
+// LXDRouteManifest returns the embedded manifest of LXD API routes.
+func LXDRouteManifest() ([]ManifestRoute, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(routeManifest))
+	decoder.KnownFields(true)
+
+	var manifest []ManifestRoute
+	err := decoder.Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse route manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// ValidateRouteManifest returns an error for every route of the manifest that is not mapped to a relation, that is
+// mapped to a relation that is not defined on its type in the model, whose object template uses a parameter that is not
+// in its path, or that is listed more than once.
+func ValidateRouteManifest(model *Model, manifest []ManifestRoute) []error {
+	var errs []error
+	seen := make(map[string]struct{})
+	for _, route := range manifest {
+		invalid := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("Invalid route %q: %s", route, fmt.Sprintf(format, args...)))
+		}
+
+		_, ok := seen[route.String()]
+		if ok {
+			invalid("Duplicate route")
+		}
+
+		seen[route.String()] = struct{}{}
+		if route.Type == "" || route.Entitlement == "" || route.Object == "" {
+			invalid("Missing type, entitlement or object")
+			continue
+		}
+
+		if !model.HasType(string(route.Type)) {
+			invalid("Type %q is not defined", route.Type)
+			continue
+		}
+
+		if !model.HasRelation(string(route.Type), string(route.Entitlement)) {
+			invalid("Entitlement %q is not defined on type %q", route.Entitlement, route.Type)
+		}
+
+		for _, param := range templateParams(route.Object) {
+			if param != "project" && !strings.Contains(route.Path, "{"+param+"}") {
+				invalid("Object parameter %q is not in the path", param)
+			}
+		}
+	}
+
+	return errs
+}
+
+// templateParams returns the names of the parameters of an object template.
+func templateParams(template string) []string {
+	var params []string
+	for {
+		_, after, ok := strings.Cut(template, "{")
+		if !ok {
+			return params
+		}
+
+		param, after, _ := strings.Cut(after, "}")
+		params = append(params, param)
+		template = after
+	}
+}
+
+// LXDRoutes returns a route table for the embedded manifest of LXD API routes. It returns an error if the manifest is
+// not valid for the model.
+func LXDRoutes(model *Model) (*RouteTable, error) {
+	manifest, err := LXDRouteManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	errs := ValidateRouteManifest(model, manifest)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	routes := make([]Route, 0, len(manifest))
+	for _, route := range manifest {
+		routes = append(routes, route.Route())
+	}
+
+	return NewRouteTable(model, routes)
+}
@@ -0,0 +1,131 @@
+package openfga
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLXDRouteManifest(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	manifest, err := LXDRouteManifest()
+	require.NoError(t, err)
+	require.NotEmpty(t, manifest)
+
+	for _, err := range ValidateRouteManifest(model, manifest) {
+		t.Error(err)
+	}
+
+	for _, route := range manifest {
+		if route.Description == "" {
+			t.Errorf("Route %q has no description", route)
+		}
+	}
+
+	_, err = LXDRoutes(model)
+	require.NoError(t, err)
+}
+
+func TestValidateRouteManifest(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		manifest    []ManifestRoute
+		errs        []string
+	}{
+		{
+			description: "Mapped routes are valid",
+			manifest: []ManifestRoute{
+				{Method: "GET", Path: "/1.0/instances/{name}", Type: ObjectTypeInstance, Entitlement: EntitlementCanView, Object: "{project}/{name}"},
+				{Method: "PUT", Path: "/1.0/instances/{name}", Type: ObjectTypeInstance, Entitlement: EntitlementCanEdit, Object: "{project}/{name}"},
+			},
+		},
+		{
+			description: "Unmapped routes are invalid",
+			manifest: []ManifestRoute{
+				{Method: "GET", Path: "/1.0/instances/{name}/access", Description: "Get who has access to an instance"},
+			},
+			errs: []string{`Invalid route "GET /1.0/instances/{name}/access": Missing type, entitlement or object`},
+		},
+		{
+			description: "Relations must be defined on the type in the model",
+			manifest: []ManifestRoute{
+				{Method: "PUT", Path: "/1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanEdit, Object: "lxd"},
+				{Method: "GET", Path: "/1.0/widgets", Type: "widget", Entitlement: EntitlementCanView, Object: "{project}"},
+			},
+			errs: []string{
+				`Invalid route "PUT /1.0": Entitlement "can_edit" is not defined on type "server"`,
+				`Invalid route "GET /1.0/widgets": Type "widget" is not defined`,
+			},
+		},
+		{
+			description: "Object parameters must be in the path",
+			manifest: []ManifestRoute{
+				{Method: "GET", Path: "/1.0/profiles/{name}", Type: ObjectTypeProfile, Entitlement: EntitlementCanView, Object: "{project}/{profile}"},
+			},
+			errs: []string{`Invalid route "GET /1.0/profiles/{name}": Object parameter "profile" is not in the path`},
+		},
+		{
+			description: "Routes must be unique",
+			manifest: []ManifestRoute{
+				{Method: "GET", Path: "/1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanViewServer, Object: "lxd"},
+				{Method: "GET", Path: "/1.0", Type: ObjectTypeServer, Entitlement: EntitlementCanViewServer, Object: "lxd"},
+			},
+			errs: []string{`Invalid route "GET /1.0": Duplicate route`},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		var errs []string
+		for _, err := range ValidateRouteManifest(model, test.manifest) {
+			errs = append(errs, err.Error())
+		}
+
+		require.Equal(t, test.errs, errs)
+	}
+}
+
+func TestLXDRoutesMatch(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	routes, err := LXDRoutes(model)
+	require.NoError(t, err)
+
+	tests := []struct {
+		method      string
+		target      string
+		entitlement Entitlement
+		object      string
+	}{
+		{method: http.MethodGet, target: "/", entitlement: EntitlementCanViewServer, object: "server:lxd"},
+		{method: http.MethodPut, target: "/1.0/instances/c1/state?project=p", entitlement: EntitlementCanUpdateState, object: "instance:p/c1"},
+		{method: http.MethodPost, target: "/1.0/instances", entitlement: EntitlementCanCreateInstances, object: "project:default"},
+		{method: http.MethodGet, target: "/1.0/images/aliases?project=p", entitlement: EntitlementCanView, object: "project:p"},
+		{method: http.MethodGet, target: "/1.0/images/abc?project=p", entitlement: EntitlementCanView, object: "image:p/abc"},
+		{method: http.MethodGet, target: "/1.0/projects/p", entitlement: EntitlementCanView, object: "project:p"},
+		{method: http.MethodDelete, target: "/1.0/storage-pools/default/volumes/custom/v1/snapshots/s1?project=p", entitlement: EntitlementCanEdit, object: "storage_pool_volume:p/default/custom/v1"},
+		{method: http.MethodPut, target: "/1.0/networks/lxdbr0/forwards/192.0.2.1", entitlement: EntitlementCanEdit, object: "network_forward:default/lxdbr0/192.0.2.1"},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s %s", i, test.method, test.target)
+
+		request := httptest.NewRequest(test.method, test.target, nil)
+		route, params, ok := routes.Match(request)
+		require.True(t, ok)
+		require.Equal(t, test.entitlement, route.Entitlement)
+
+		object, err := route.Object(route.Type, request, params)
+		require.NoError(t, err)
+		require.Equal(t, test.object, object.String())
+	}
+}
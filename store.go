@@ -0,0 +1,337 @@
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// TupleStore reads and writes the relationship tuples of an OpenFGA store.
+type TupleStore interface {
+	// ReadTuples returns all tuples matching the filter. Empty fields in the filter match any value and an object of
+	// the form `<type>:` matches all objects of that type.
+	ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error)
+
+	// WriteTuples writes and deletes the given tuples in a single transaction.
+	WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error
+}
+
+// openFGAStore is a TupleStore backed by an OpenFGA server.
+type openFGAStore struct {
+	fga         *client.OpenFgaClient
+	authModelID *string
+}
+
+// NewOpenFGAStore returns a TupleStore that reads and writes tuples in the store configured on the given client. Tuples
+// are validated against the model before they are written.
+func NewOpenFGAStore(fga *client.OpenFgaClient, model *Model, authModelID *string) TupleStore {
+	return NewValidatingStore(&openFGAStore{
+		fga:         fga,
+		authModelID: authModelID,
+	}, model)
+}
+
+// ReadTuples implements TupleStore.
+func (s *openFGAStore) ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	body := client.ClientReadRequest{}
+	if filter.User != "" {
+		body.User = &filter.User
+	}
+
+	if filter.Relation != "" {
+		body.Relation = &filter.Relation
+	}
+
+	if filter.Object != "" {
+		body.Object = &filter.Object
+	}
+
+	var tuples []client.ClientTupleKey
+	var continuationToken *string
+	for {
+		readResponse, err := s.fga.Read(ctx).Options(client.ClientReadOptions{ContinuationToken: continuationToken}).Body(body).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read tuples: %w", err)
+		}
+
+		for _, tuple := range readResponse.GetTuples() {
+			key := tuple.GetKey()
+			tuples = append(tuples, client.ClientTupleKey{
+				User:     key.GetUser(),
+				Relation: key.GetRelation(),
+				Object:   key.GetObject(),
+			})
+		}
+
+		if readResponse.GetContinuationToken() == "" {
+			break
+		}
+
+		continuationToken = readResponse.ContinuationToken
+	}
+
+	return tuples, nil
+}
+
+// WriteTuples implements TupleStore.
+func (s *openFGAStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	if len(writes) == 0 && len(deletes) == 0 {
+		return nil
+	}
+
+	_, err := s.fga.Write(ctx).Options(client.ClientWriteOptions{AuthorizationModelId: s.authModelID}).Body(client.ClientWriteRequest{
+		Writes:  &writes,
+		Deletes: &deletes,
+	}).Execute()
+	if err != nil {
+		return fmt.Errorf("Failed to write tuples: %w", err)
+	}
+
+	return nil
+}
+
+// MemoryStore is an in-memory TupleStore. It is used in tests and wherever a copy of a store's tuples is needed.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tuples map[client.ClientTupleKey]struct{}
+
+	// users indexes the users of the tuples by object and relation.
+	users map[objectRelation]map[string]struct{}
+}
+
+// objectRelation is an object and relation pair, e.g. `project:default#operator`.
+type objectRelation struct {
+	object   string
+	relation string
+}
+
+// NewMemoryStore returns a MemoryStore containing the given tuples.
+func NewMemoryStore(tuples ...client.ClientTupleKey) *MemoryStore {
+	s := &MemoryStore{
+		tuples: make(map[client.ClientTupleKey]struct{}, len(tuples)),
+		users:  make(map[objectRelation]map[string]struct{}),
+	}
+
+	for _, tuple := range tuples {
+		s.add(tuple)
+	}
+
+	return s
+}
+
+// add adds a tuple to the store. The caller must hold the write lock.
+func (s *MemoryStore) add(tuple client.ClientTupleKey) {
+	s.tuples[tuple] = struct{}{}
+
+	key := objectRelation{object: tuple.Object, relation: tuple.Relation}
+	users, ok := s.users[key]
+	if !ok {
+		users = make(map[string]struct{})
+		s.users[key] = users
+	}
+
+	users[tuple.User] = struct{}{}
+}
+
+// remove removes a tuple from the store. The caller must hold the write lock.
+func (s *MemoryStore) remove(tuple client.ClientTupleKey) {
+	delete(s.tuples, tuple)
+
+	key := objectRelation{object: tuple.Object, relation: tuple.Relation}
+	delete(s.users[key], tuple.User)
+	if len(s.users[key]) == 0 {
+		delete(s.users, key)
+	}
+}
+
+// applyChanges applies the changes in order. Unlike WriteTuples, writing a tuple that exists or deleting one that does
+// not is not an error, so that changes can be replayed over tuples that already contain some of them.
+func (s *MemoryStore) applyChanges(changes []TupleChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, change := range changes {
+		if change.Deleted {
+			s.remove(change.Tuple)
+		} else {
+			s.add(change.Tuple)
+		}
+	}
+}
+
+// replace replaces all tuples in the store.
+func (s *MemoryStore) replace(tuples []client.ClientTupleKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tuples = make(map[client.ClientTupleKey]struct{}, len(tuples))
+	s.users = make(map[objectRelation]map[string]struct{})
+	for _, tuple := range tuples {
+		s.add(tuple)
+	}
+}
+
+// size returns the number of tuples in the store.
+func (s *MemoryStore) size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tuples)
+}
+
+// directUsers returns the users of all tuples with the given object and relation.
+func (s *MemoryStore) directUsers(object string, relation string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := objectRelation{object: object, relation: relation}
+	users := make([]string, 0, len(s.users[key]))
+	for user := range s.users[key] {
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// objectsOfType returns every object of the given type that appears in a tuple, either as the object or as the user.
+func (s *MemoryStore) objectsOfType(objectType string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefix := objectType + ":"
+	objects := make(map[string]struct{})
+	for tuple := range s.tuples {
+		if strings.HasPrefix(tuple.Object, prefix) {
+			objects[tuple.Object] = struct{}{}
+		}
+
+		user, _, _ := strings.Cut(tuple.User, "#")
+		if strings.HasPrefix(user, prefix) && user != prefix+"*" {
+			objects[user] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(objects))
+	for object := range objects {
+		result = append(result, object)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Tuples returns all tuples in the store, sorted.
+func (s *MemoryStore) Tuples() []client.ClientTupleKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tuples := make([]client.ClientTupleKey, 0, len(s.tuples))
+	for tuple := range s.tuples {
+		tuples = append(tuples, tuple)
+	}
+
+	sortTuples(tuples)
+	return tuples
+}
+
+// ReadTuples implements TupleStore.
+func (s *MemoryStore) ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tuples []client.ClientTupleKey
+	for tuple := range s.tuples {
+		if tupleMatches(tuple, filter) {
+			tuples = append(tuples, tuple)
+		}
+	}
+
+	sortTuples(tuples)
+	return tuples, nil
+}
+
+// WriteTuples implements TupleStore. Like OpenFGA, it fails without applying any change if a written tuple already
+// exists or a deleted tuple does not.
+func (s *MemoryStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[client.ClientTupleKey]struct{}, len(writes)+len(deletes))
+	for _, tuple := range writes {
+		_, ok := s.tuples[tuple]
+		if ok {
+			return fmt.Errorf("Cannot write tuple %s: Tuple already exists", formatTuple(tuple))
+		}
+
+		_, ok = seen[tuple]
+		if ok {
+			return fmt.Errorf("Cannot write tuple %s: Duplicate tuple in request", formatTuple(tuple))
+		}
+
+		seen[tuple] = struct{}{}
+	}
+
+	for _, tuple := range deletes {
+		_, ok := s.tuples[tuple]
+		if !ok {
+			return fmt.Errorf("Cannot delete tuple %s: Tuple does not exist", formatTuple(tuple))
+		}
+
+		_, ok = seen[tuple]
+		if ok {
+			return fmt.Errorf("Cannot delete tuple %s: Duplicate tuple in request", formatTuple(tuple))
+		}
+
+		seen[tuple] = struct{}{}
+	}
+
+	for _, tuple := range deletes {
+		s.remove(tuple)
+	}
+
+	for _, tuple := range writes {
+		s.add(tuple)
+	}
+
+	return nil
+}
+
+// tupleMatches returns true if the tuple matches a ReadTuples filter.
+func tupleMatches(tuple client.ClientTupleKey, filter client.ClientTupleKey) bool {
+	if filter.User != "" && tuple.User != filter.User {
+		return false
+	}
+
+	if filter.Relation != "" && tuple.Relation != filter.Relation {
+		return false
+	}
+
+	if strings.HasSuffix(filter.Object, ":") {
+		return strings.HasPrefix(tuple.Object, filter.Object)
+	}
+
+	return filter.Object == "" || tuple.Object == filter.Object
+}
+
+// sortTuples sorts tuples by object, relation, then user.
+func sortTuples(tuples []client.ClientTupleKey) {
+	sort.Slice(tuples, func(i, j int) bool {
+		if tuples[i].Object != tuples[j].Object {
+			return tuples[i].Object < tuples[j].Object
+		}
+
+		if tuples[i].Relation != tuples[j].Relation {
+			return tuples[i].Relation < tuples[j].Relation
+		}
+
+		return tuples[i].User < tuples[j].User
+	})
+}
+
+// formatTuple formats a tuple as `<user> <relation> <object>` for use in errors and reports.
+func formatTuple(tuple client.ClientTupleKey) string {
+	return fmt.Sprintf("%s %s %s", tuple.User, tuple.Relation, tuple.Object)
+}
@@ -0,0 +1,94 @@
+package openfga
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// ProjectTargetTuples returns the tuples that let the users who can create instances in a project of the cluster
+// target cluster groups and members, given the groups of each cluster member. They follow the restrictions of the
+// project config:
+//
+//   - If the project is not `restricted`, every group and member can be targeted.
+//   - `restricted.cluster.groups` limits the groups that can be targeted, and the members to those in these groups.
+//   - Members can only be targeted if `restricted.cluster.target` is `allow`.
+//
+// The tuples must be written when the project is created and replaced when its config or the cluster groups change.
+func ProjectTargetTuples(cluster Cluster, project string, config map[string]string, memberGroups map[string][]string) []client.ClientTupleKey {
+	restricted := isTrue(config["restricted"])
+
+	// allowedGroups is nil if all groups can be targeted.
+	var allowedGroups map[string]bool
+	if restricted && config["restricted.cluster.groups"] != "" {
+		allowedGroups = make(map[string]bool)
+		for _, group := range strings.Split(config["restricted.cluster.groups"], ",") {
+			allowedGroups[strings.TrimSpace(group)] = true
+		}
+	}
+
+	targetMembers := !restricted || config["restricted.cluster.target"] == "allow"
+
+	groups := make(map[string]struct{})
+	var members []string
+	for member, memberGroupNames := range memberGroups {
+		allowed := allowedGroups == nil
+		for _, group := range memberGroupNames {
+			groups[group] = struct{}{}
+			if allowedGroups[group] {
+				allowed = true
+			}
+		}
+
+		if targetMembers && allowed {
+			members = append(members, member)
+		}
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		if allowedGroups == nil || allowedGroups[group] {
+			groupNames = append(groupNames, group)
+		}
+	}
+
+	sort.Strings(groupNames)
+	sort.Strings(members)
+
+	projectObject := cluster.Project(project).String()
+	tuples := make([]client.ClientTupleKey, 0, len(groupNames)+len(members))
+	for _, group := range groupNames {
+		tuples = append(tuples, client.ClientTupleKey{User: projectObject, Relation: "target_project", Object: cluster.Resource(ObjectTypeClusterGroup, group).String()})
+	}
+
+	for _, member := range members {
+		tuples = append(tuples, client.ClientTupleKey{User: projectObject, Relation: "target_project", Object: cluster.Resource(ObjectTypeClusterMember, member).String()})
+	}
+
+	return tuples
+}
+
+// ProjectStoragePoolTuples returns the tuples that let the users who can create instances or custom volumes in a
+// project of the cluster view the storage pools they can create them on. A pool is excluded if the project config sets
+// its `limits.disk.pool.<pool>` to `0`, as LXD does not let the project use it.
+//
+// The tuples must be written when the project is created and replaced when its config or the storage pools change.
+func ProjectStoragePoolTuples(cluster Cluster, project string, config map[string]string, pools []string) []client.ClientTupleKey {
+	poolNames := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		if strings.TrimSpace(config["limits.disk.pool."+pool]) != "0" {
+			poolNames = append(poolNames, pool)
+		}
+	}
+
+	sort.Strings(poolNames)
+
+	projectObject := cluster.Project(project).String()
+	tuples := make([]client.ClientTupleKey, 0, len(poolNames))
+	for _, pool := range poolNames {
+		tuples = append(tuples, client.ClientTupleKey{User: projectObject, Relation: "target_project", Object: cluster.Resource(ObjectTypeStoragePool, pool).String()})
+	}
+
+	return tuples
+}
@@ -0,0 +1,254 @@
+package openfga
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTargetTuples(t *testing.T) {
+	memberGroups := map[string][]string{
+		"node01": {"default", "gpu"},
+		"node02": {"default"},
+		"node03": {"default", "arm"},
+	}
+
+	target := func(objectType ObjectType, name string) client.ClientTupleKey {
+		return client.ClientTupleKey{User: "project:p", Relation: "target_project", Object: Cluster("").Resource(objectType, name).String()}
+	}
+
+	tests := []struct {
+		description string
+		config      map[string]string
+		expected    []client.ClientTupleKey
+	}{
+		{
+			description: "Unrestricted projects can target every group and member",
+			config:      map[string]string{"restricted.cluster.groups": "gpu"},
+			expected: []client.ClientTupleKey{
+				target(ObjectTypeClusterGroup, "arm"),
+				target(ObjectTypeClusterGroup, "default"),
+				target(ObjectTypeClusterGroup, "gpu"),
+				target(ObjectTypeClusterMember, "node01"),
+				target(ObjectTypeClusterMember, "node02"),
+				target(ObjectTypeClusterMember, "node03"),
+			},
+		},
+		{
+			description: "Restricted projects can target every group but no member by default",
+			config:      map[string]string{"restricted": "true"},
+			expected: []client.ClientTupleKey{
+				target(ObjectTypeClusterGroup, "arm"),
+				target(ObjectTypeClusterGroup, "default"),
+				target(ObjectTypeClusterGroup, "gpu"),
+			},
+		},
+		{
+			description: "Restricted projects can only target the groups of restricted.cluster.groups",
+			config:      map[string]string{"restricted": "true", "restricted.cluster.groups": "gpu, arm"},
+			expected: []client.ClientTupleKey{
+				target(ObjectTypeClusterGroup, "arm"),
+				target(ObjectTypeClusterGroup, "gpu"),
+			},
+		},
+		{
+			description: "Restricted projects can target the members of their groups if restricted.cluster.target is allow",
+			config:      map[string]string{"restricted": "true", "restricted.cluster.groups": "gpu", "restricted.cluster.target": "allow"},
+			expected: []client.ClientTupleKey{
+				target(ObjectTypeClusterGroup, "gpu"),
+				target(ObjectTypeClusterMember, "node01"),
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+		require.Equal(t, test.expected, ProjectTargetTuples("", "p", test.config, memberGroups))
+	}
+}
+
+func TestCanTarget(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:p"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "cluster_group:gpu"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "cluster_group:arm"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "cluster_member:node01"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "project:p"},
+		client.ClientTupleKey{User: "user:bob", Relation: "viewer", Object: "project:p"},
+		client.ClientTupleKey{User: "user:carol", Relation: "viewer", Object: "server:lxd"},
+		client.ClientTupleKey{User: "user:dave", Relation: "operator", Object: "server:lxd"},
+		client.ClientTupleKey{User: "user:erin", Relation: "can_target", Object: "cluster_group:arm"},
+	)
+
+	config := map[string]string{"restricted": "true", "restricted.cluster.groups": "gpu"}
+	require.NoError(t, store.WriteTuples(context.Background(), ProjectTargetTuples("", "p", config, map[string][]string{"node01": {"gpu"}}), nil))
+	querier := evaluatorChecker{evaluator: NewEvaluator(model, store)}
+
+	tests := []struct {
+		description string
+		user        string
+		entitlement Entitlement
+		object      Object
+		allowed     bool
+	}{
+		{
+			description: "An operator of the project can target a group allowed by the project",
+			user:        "user:alice",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "gpu"},
+			allowed:     true,
+		},
+		{
+			description: "An operator of the project cannot target a group restricted by the project",
+			user:        "user:alice",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "arm"},
+		},
+		{
+			description: "An operator of the project cannot target a member unless the project allows it",
+			user:        "user:alice",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterMember, Name: "node01"},
+		},
+		{
+			description: "An operator of the project cannot view the group it can target",
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "gpu"},
+		},
+		{
+			description: "A viewer of the project cannot target a group allowed by the project",
+			user:        "user:bob",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "gpu"},
+		},
+		{
+			description: "A viewer of the server cannot target a group",
+			user:        "user:carol",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "gpu"},
+		},
+		{
+			description: "An operator of the server can target any group",
+			user:        "user:dave",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "arm"},
+			allowed:     true,
+		},
+		{
+			description: "can_target can be granted directly",
+			user:        "user:erin",
+			entitlement: EntitlementCanTarget,
+			object:      Object{Type: ObjectTypeClusterGroup, Name: "arm"},
+			allowed:     true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		allowed, err := querier.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.Equal(t, test.allowed, allowed)
+	}
+}
+
+func TestProjectStoragePoolTuples(t *testing.T) {
+	pool := func(name string) client.ClientTupleKey {
+		return client.ClientTupleKey{User: "project:p", Relation: "target_project", Object: Cluster("").Resource(ObjectTypeStoragePool, name).String()}
+	}
+
+	tests := []struct {
+		description string
+		config      map[string]string
+		expected    []client.ClientTupleKey
+	}{
+		{
+			description: "Projects can use every pool by default",
+			config:      map[string]string{"limits.disk.pool.fast": "10GiB"},
+			expected:    []client.ClientTupleKey{pool("default"), pool("fast"), pool("slow")},
+		},
+		{
+			description: "Projects cannot use a pool limited to 0",
+			config:      map[string]string{"limits.disk.pool.fast": "0", "limits.disk.pool.slow": "0"},
+			expected:    []client.ClientTupleKey{pool("default")},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+		require.Equal(t, test.expected, ProjectStoragePoolTuples("", "p", test.config, []string{"slow", "default", "fast"}))
+	}
+}
+
+func TestStoragePoolTargetProject(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := NewMemoryStore(
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "project:p"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "storage_pool:default"},
+		client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: "storage_pool:fast"},
+		client.ClientTupleKey{User: "user:alice", Relation: "operator", Object: "project:p"},
+		client.ClientTupleKey{User: "user:bob", Relation: "viewer", Object: "project:p"},
+		client.ClientTupleKey{User: "user:carol", Relation: "can_create_storage_pool_volumes", Object: "project:p"},
+	)
+
+	config := map[string]string{"limits.disk.pool.fast": "0"}
+	require.NoError(t, store.WriteTuples(context.Background(), ProjectStoragePoolTuples("", "p", config, []string{"default", "fast"}), nil))
+	querier := evaluatorChecker{evaluator: NewEvaluator(model, store)}
+
+	tests := []struct {
+		description string
+		user        string
+		entitlement Entitlement
+		object      Object
+		allowed     bool
+	}{
+		{
+			description: "An operator of the project can view a pool the project can use",
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "default"},
+			allowed:     true,
+		},
+		{
+			description: "An operator of the project cannot view a pool the project cannot use",
+			user:        "user:alice",
+			entitlement: EntitlementCanView,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "fast"},
+		},
+		{
+			description: "An operator of the project cannot edit a pool the project can use",
+			user:        "user:alice",
+			entitlement: EntitlementCanEdit,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "default"},
+		},
+		{
+			description: "A user who can only create custom volumes in the project can view a pool the project can use",
+			user:        "user:carol",
+			entitlement: EntitlementCanView,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "default"},
+			allowed:     true,
+		},
+		{
+			description: "A viewer of the project cannot view a pool the project can use",
+			user:        "user:bob",
+			entitlement: EntitlementCanView,
+			object:      Object{Type: ObjectTypeStoragePool, Name: "default"},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		allowed, err := querier.Check(context.Background(), test.user, test.entitlement, test.object)
+		require.NoError(t, err)
+		require.Equal(t, test.allowed, allowed)
+	}
+}
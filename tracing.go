@@ -0,0 +1,196 @@
+package openfga
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of the spans created by this package.
+const tracerName = "github.com/markylaing/lxd-openfga"
+
+// Span attributes of OpenFGA calls.
+const (
+	attributeType     = attribute.Key("openfga.type")
+	attributeRelation = attribute.Key("openfga.relation")
+	attributeStoreID  = attribute.Key("openfga.store_id")
+	attributeModelID  = attribute.Key("openfga.model_id")
+	attributeAllowed  = attribute.Key("openfga.allowed")
+	attributeObjects  = attribute.Key("openfga.objects")
+	attributeWrites   = attribute.Key("openfga.writes")
+	attributeDeletes  = attribute.Key("openfga.deletes")
+)
+
+// TracingOptions configures the tracing of OpenFGA calls. Tracing is optional: without a tracer provider, the global
+// one is used, which does nothing unless the application sets it.
+type TracingOptions struct {
+	// TracerProvider creates the spans. Defaults to the global tracer provider.
+	TracerProvider trace.TracerProvider
+
+	// Propagator injects the span context into the headers of HTTP requests. Defaults to the global propagator.
+	Propagator propagation.TextMapPropagator
+
+	// StoreID and ModelID are recorded on every span.
+	StoreID string
+	ModelID string
+}
+
+// tracer returns the tracer of the options.
+func (o TracingOptions) tracer() trace.Tracer {
+	provider := o.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(tracerName)
+}
+
+// start starts a span with the store and model IDs and the given attributes.
+func (o TracingOptions) start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if o.StoreID != "" {
+		attributes = append(attributes, attributeStoreID.String(o.StoreID))
+	}
+
+	if o.ModelID != "" {
+		attributes = append(attributes, attributeModelID.String(o.ModelID))
+	}
+
+	return o.tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// tracedQuerier is a Querier that creates a span for every query of the wrapped Querier.
+type tracedQuerier struct {
+	querier Querier
+	options TracingOptions
+}
+
+// NewTracedQuerier returns a Querier that creates a span for every query of the given Querier. The context of the
+// span is passed on, so that the HTTP requests to OpenFGA are traced by a client configured with NewTracingTransport.
+func NewTracedQuerier(querier Querier, options TracingOptions) Querier {
+	return &tracedQuerier{querier: querier, options: options}
+}
+
+// Check implements Checker.
+func (q *tracedQuerier) Check(ctx context.Context, user string, entitlement Entitlement, object Object) (bool, error) {
+	ctx, span := q.options.start(ctx, "openfga.Check", attributeType.String(string(object.Type)), attributeRelation.String(string(entitlement)))
+	allowed, err := q.querier.Check(ctx, user, entitlement, object)
+	span.SetAttributes(attributeAllowed.Bool(allowed))
+	endSpan(span, err)
+	return allowed, err
+}
+
+// ListObjects implements Querier.
+func (q *tracedQuerier) ListObjects(ctx context.Context, user string, entitlement Entitlement, objectType ObjectType) ([]Object, error) {
+	ctx, span := q.options.start(ctx, "openfga.ListObjects", attributeType.String(string(objectType)), attributeRelation.String(string(entitlement)))
+	objects, err := q.querier.ListObjects(ctx, user, entitlement, objectType)
+	span.SetAttributes(attributeObjects.Int(len(objects)))
+	endSpan(span, err)
+	return objects, err
+}
+
+// tracedStore is a TupleStore that creates a span for every read and write of the wrapped TupleStore.
+type tracedStore struct {
+	store   TupleStore
+	options TracingOptions
+}
+
+// NewTracedStore returns a TupleStore that creates a span for every read and write of the given store.
+func NewTracedStore(store TupleStore, options TracingOptions) TupleStore {
+	return &tracedStore{store: store, options: options}
+}
+
+// ReadTuples implements TupleStore.
+func (s *tracedStore) ReadTuples(ctx context.Context, filter client.ClientTupleKey) ([]client.ClientTupleKey, error) {
+	objectType, _, _ := strings.Cut(filter.Object, ":")
+	ctx, span := s.options.start(ctx, "openfga.Read", attributeType.String(objectType), attributeRelation.String(filter.Relation))
+	tuples, err := s.store.ReadTuples(ctx, filter)
+	endSpan(span, err)
+	return tuples, err
+}
+
+// WriteTuples implements TupleStore.
+func (s *tracedStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	ctx, span := s.options.start(ctx, "openfga.Write", attributeWrites.Int(len(writes)), attributeDeletes.Int(len(deletes)))
+	err := s.store.WriteTuples(ctx, writes, deletes)
+	endSpan(span, err)
+	return err
+}
+
+// tracingTransport is an http.RoundTripper that creates a client span for every request and propagates its context
+// in the request headers.
+type tracingTransport struct {
+	base    http.RoundTripper
+	options TracingOptions
+}
+
+// NewTracingTransport returns an http.RoundTripper that creates a span for every request, as a child of the span in the
+// request context, and injects the span context into the request headers so that the trace continues in OpenFGA. If
+// base is nil, http.DefaultTransport is used. See TraceClient to use it with the OpenFGA SDK.
+func NewTracingTransport(base http.RoundTripper, options TracingOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &tracingTransport{base: base, options: options}
+}
+
+// TraceClient makes the OpenFGA client trace its HTTP requests with NewTracingTransport. The SDK ignores
+// `client.ClientConfiguration.HTTPClient`, so the transport is set on the client after it is created. The HTTP client
+// in use (by default http.DefaultClient) is copied rather than modified.
+func TraceClient(fga *client.OpenFgaClient, options TracingOptions) {
+	config := fga.GetConfig()
+	httpClient := http.Client{}
+	if config.HTTPClient != nil {
+		httpClient = *config.HTTPClient
+	}
+
+	httpClient.Transport = NewTracingTransport(httpClient.Transport, options)
+	config.HTTPClient = &httpClient
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := t.options.tracer().Start(r.Context(), "HTTP "+r.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+	))
+
+	propagator := t.options.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	r = r.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	response, err := t.base.RoundTrip(r)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, response.Status)
+	}
+
+	span.End()
+	return response, nil
+}
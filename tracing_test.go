@@ -0,0 +1,171 @@
+package openfga
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestTracerProvider returns a tracer provider that records spans in memory.
+func newTestTracerProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return provider, exporter
+}
+
+// spanAttributes returns the attributes of a span as a map.
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+
+	return attributes
+}
+
+func TestTracedQuerier(t *testing.T) {
+	provider, exporter := newTestTracerProvider(t)
+	querier := NewTracedQuerier(newAuditTestQuerier(t), TracingOptions{TracerProvider: provider, StoreID: "01HSTORE", ModelID: "01HMODEL"})
+
+	allowed, err := querier.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	_, err = querier.Check(context.Background(), "user:alice", "can_fly", ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.Error(t, err)
+
+	_, err = querier.ListObjects(context.Background(), "user:alice", EntitlementCanView, ObjectTypeInstance)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	require.Equal(t, "openfga.Check", spans[0].Name)
+	require.Equal(t, map[attribute.Key]attribute.Value{
+		"openfga.type":     attribute.StringValue("instance"),
+		"openfga.relation": attribute.StringValue("can_exec"),
+		"openfga.store_id": attribute.StringValue("01HSTORE"),
+		"openfga.model_id": attribute.StringValue("01HMODEL"),
+		"openfga.allowed":  attribute.BoolValue(true),
+	}, spanAttributes(spans[0]))
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+	require.Len(t, spans[1].Events, 1)
+
+	require.Equal(t, "openfga.ListObjects", spans[2].Name)
+	require.Equal(t, attribute.IntValue(1), spanAttributes(spans[2])["openfga.objects"])
+}
+
+func TestTracedStore(t *testing.T) {
+	provider, exporter := newTestTracerProvider(t)
+	store := NewTracedStore(NewMemoryStore(), TracingOptions{TracerProvider: provider, StoreID: "01HSTORE"})
+
+	err := store.WriteTuples(context.Background(), []client.ClientTupleKey{{User: "user:alice", Relation: "operator", Object: "instance:p/c1"}}, nil)
+	require.NoError(t, err)
+
+	_, err = store.ReadTuples(context.Background(), client.ClientTupleKey{Relation: "operator", Object: "instance:"})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "openfga.Write", spans[0].Name)
+	require.Equal(t, attribute.IntValue(1), spanAttributes(spans[0])["openfga.writes"])
+	require.Equal(t, attribute.IntValue(0), spanAttributes(spans[0])["openfga.deletes"])
+	require.Equal(t, "openfga.Read", spans[1].Name)
+	require.Equal(t, attribute.StringValue("instance"), spanAttributes(spans[1])["openfga.type"])
+	require.Equal(t, attribute.StringValue("01HSTORE"), spanAttributes(spans[1])["openfga.store_id"])
+}
+
+func TestTracingTransport(t *testing.T) {
+	const storeID = "01HVMMBCMGZNT3SED4Z17ECXCA"
+	const modelID = "01HVMMBD3M9QRJ8D5E7NBKX6Y2"
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/check"):
+			_, _ = w.Write([]byte(`{"allowed": true}`))
+		case strings.HasSuffix(r.URL.Path, "/authorization-models"):
+			_, _ = w.Write([]byte(`{"authorization_models": [{"id": "` + modelID + `", "schema_version": "1.1", "type_definitions": []}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, exporter := newTestTracerProvider(t)
+	options := TracingOptions{TracerProvider: provider, Propagator: propagation.TraceContext{}, StoreID: storeID, ModelID: modelID}
+
+	fga, err := client.NewSdkClient(&client.ClientConfiguration{
+		ApiScheme: "http",
+		ApiHost:   strings.TrimPrefix(server.URL, "http://"),
+		StoreId:   storeID,
+	})
+	require.NoError(t, err)
+
+	TraceClient(fga, options)
+	require.Nil(t, http.DefaultClient.Transport)
+
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	modelIDPtr := modelID
+	querier := NewTracedQuerier(NewAuthorizer(fga, model, &modelIDPtr), options)
+	allowed, err := querier.Check(context.Background(), "user:alice", EntitlementCanExec, ProjectResourceObject(ObjectTypeInstance, "p", "c1"))
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	httpSpan, checkSpan := spans[0], spans[1]
+	require.Equal(t, "HTTP POST", httpSpan.Name)
+	require.Equal(t, trace.SpanKindClient, httpSpan.SpanKind)
+	require.Equal(t, attribute.IntValue(http.StatusOK), spanAttributes(httpSpan)["http.status_code"])
+	require.Equal(t, "openfga.Check", checkSpan.Name)
+	require.Equal(t, checkSpan.SpanContext.SpanID(), httpSpan.Parent.SpanID())
+
+	// The request carries the context of the HTTP span, so the trace continues in OpenFGA.
+	require.Len(t, traceparents, 1)
+	require.Equal(t, "00-"+httpSpan.SpanContext.TraceID().String()+"-"+httpSpan.SpanContext.SpanID().String()+"-01", traceparents[0])
+
+	// Model reads are traced as children of the caller's span.
+	exporter.Reset()
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "bootstrap")
+	models, err := readModels(ctx, fga)
+	parent.End()
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "openfga.ReadAuthorizationModels", spans[1].Name)
+	require.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent.SpanID())
+	require.Equal(t, attribute.StringValue(storeID), spanAttributes(spans[1])["openfga.store_id"])
+	require.Equal(t, attribute.StringValue(modelID), spanAttributes(spans[1])["openfga.model_id"])
+
+	// Without a span in the context, model reads are not traced.
+	exporter.Reset()
+	_, err = readModels(context.Background(), fga)
+	require.NoError(t, err)
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "HTTP GET", spans[0].Name)
+}
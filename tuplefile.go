@@ -0,0 +1,286 @@
+package openfga
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxTuplesPerWrite is the maximum number of tuples (writes and deletes combined) that OpenFGA accepts in a single
+// Write request.
+const MaxTuplesPerWrite = 100
+
+// TupleFileFormat is a file format for tuples.
+type TupleFileFormat string
+
+const (
+	// TupleFileYAML is a YAML document with a `tuples` list of `user`, `relation` and `object` mappings.
+	TupleFileYAML TupleFileFormat = "yaml"
+
+	// TupleFileCSV is a CSV file with a `user,relation,object` header.
+	TupleFileCSV TupleFileFormat = "csv"
+)
+
+// TupleFileFormatFromPath returns the format of a tuple file from its extension.
+func TupleFileFormatFromPath(path string) (TupleFileFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return TupleFileYAML, nil
+	case ".csv":
+		return TupleFileCSV, nil
+	}
+
+	return "", fmt.Errorf("Cannot determine tuple file format of %q: Extension must be .yaml, .yml or .csv", path)
+}
+
+// tupleFile is the YAML representation of a list of tuples.
+type tupleFile struct {
+	Tuples []tupleFileEntry `yaml:"tuples"`
+}
+
+// tupleFileEntry is a single tuple in a YAML tuple file.
+type tupleFileEntry struct {
+	User     string `yaml:"user"`
+	Relation string `yaml:"relation"`
+	Object   string `yaml:"object"`
+}
+
+// csvHeader is the header row of a CSV tuple file.
+var csvHeader = []string{"user", "relation", "object"}
+
+// EncodeTuples writes the tuples to w in the given format. Tuples are sorted by object, relation and user so that the
+// output of two exports of the same store is identical and changes are easy to review.
+func EncodeTuples(w io.Writer, format TupleFileFormat, tuples []client.ClientTupleKey) error {
+	sorted := append([]client.ClientTupleKey(nil), tuples...)
+	sortTuples(sorted)
+
+	switch format {
+	case TupleFileYAML:
+		file := tupleFile{Tuples: make([]tupleFileEntry, 0, len(sorted))}
+		for _, tuple := range sorted {
+			file.Tuples = append(file.Tuples, tupleFileEntry{User: tuple.User, Relation: tuple.Relation, Object: tuple.Object})
+		}
+
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		err := encoder.Encode(file)
+		if err != nil {
+			return fmt.Errorf("Failed to encode tuples: %w", err)
+		}
+
+		return encoder.Close()
+	case TupleFileCSV:
+		writer := csv.NewWriter(w)
+		err := writer.Write(csvHeader)
+		if err != nil {
+			return fmt.Errorf("Failed to encode tuples: %w", err)
+		}
+
+		for _, tuple := range sorted {
+			err = writer.Write([]string{tuple.User, tuple.Relation, tuple.Object})
+			if err != nil {
+				return fmt.Errorf("Failed to encode tuples: %w", err)
+			}
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return fmt.Errorf("Unknown tuple file format %q", format)
+}
+
+// DecodeTuples reads tuples in the given format from r. Duplicate and incomplete tuples are rejected.
+func DecodeTuples(r io.Reader, format TupleFileFormat) ([]client.ClientTupleKey, error) {
+	var tuples []client.ClientTupleKey
+	switch format {
+	case TupleFileYAML:
+		var file tupleFile
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		err := decoder.Decode(&file)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("Failed to decode tuples: %w", err)
+		}
+
+		for _, entry := range file.Tuples {
+			tuples = append(tuples, client.ClientTupleKey{User: entry.User, Relation: entry.Relation, Object: entry.Object})
+		}
+
+	case TupleFileCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = len(csvHeader)
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode tuples: %w", err)
+		}
+
+		if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+			return nil, fmt.Errorf("Failed to decode tuples: First row must be %q", strings.Join(csvHeader, ","))
+		}
+
+		for _, record := range records[1:] {
+			tuples = append(tuples, client.ClientTupleKey{User: record[0], Relation: record[1], Object: record[2]})
+		}
+
+	default:
+		return nil, fmt.Errorf("Unknown tuple file format %q", format)
+	}
+
+	seen := make(map[client.ClientTupleKey]struct{}, len(tuples))
+	for _, tuple := range tuples {
+		if tuple.User == "" || tuple.Relation == "" || tuple.Object == "" {
+			return nil, fmt.Errorf("Invalid tuple %q: User, relation and object are required", formatTuple(tuple))
+		}
+
+		_, ok := seen[tuple]
+		if ok {
+			return nil, fmt.Errorf("Duplicate tuple %q", formatTuple(tuple))
+		}
+
+		seen[tuple] = struct{}{}
+	}
+
+	return tuples, nil
+}
+
+// ExportTuples writes all tuples of the store to w in the given format.
+func ExportTuples(ctx context.Context, store TupleStore, w io.Writer, format TupleFileFormat) error {
+	tuples, err := store.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		return err
+	}
+
+	return EncodeTuples(w, format, tuples)
+}
+
+// TupleDiff is the set of changes needed to bring a store in line with a list of tuples.
+type TupleDiff struct {
+	Writes  []client.ClientTupleKey
+	Deletes []client.ClientTupleKey
+}
+
+// Empty returns true if there are no changes.
+func (d TupleDiff) Empty() bool {
+	return len(d.Writes) == 0 && len(d.Deletes) == 0
+}
+
+// String formats the diff with one tuple per line, prefixed by `+` for writes and `-` for deletes.
+func (d TupleDiff) String() string {
+	var b strings.Builder
+	for _, tuple := range d.Deletes {
+		fmt.Fprintf(&b, "- %s\n", formatTuple(tuple))
+	}
+
+	for _, tuple := range d.Writes {
+		fmt.Fprintf(&b, "+ %s\n", formatTuple(tuple))
+	}
+
+	return b.String()
+}
+
+// DiffTuples returns the writes and deletes that turn the current tuples into the desired tuples. Deletes are only
+// computed if withDeletes is true.
+func DiffTuples(current []client.ClientTupleKey, desired []client.ClientTupleKey, withDeletes bool) TupleDiff {
+	currentSet := make(map[client.ClientTupleKey]struct{}, len(current))
+	for _, tuple := range current {
+		currentSet[tuple] = struct{}{}
+	}
+
+	desiredSet := make(map[client.ClientTupleKey]struct{}, len(desired))
+	var diff TupleDiff
+	for _, tuple := range desired {
+		desiredSet[tuple] = struct{}{}
+		_, ok := currentSet[tuple]
+		if !ok {
+			diff.Writes = append(diff.Writes, tuple)
+		}
+	}
+
+	if withDeletes {
+		for _, tuple := range current {
+			_, ok := desiredSet[tuple]
+			if !ok {
+				diff.Deletes = append(diff.Deletes, tuple)
+			}
+		}
+	}
+
+	sortTuples(diff.Writes)
+	sortTuples(diff.Deletes)
+	return diff
+}
+
+// ImportOptions configures ImportTuples.
+type ImportOptions struct {
+	// DryRun computes the diff without writing it.
+	DryRun bool
+
+	// Delete removes tuples that are in the store but not in the imported file.
+	Delete bool
+}
+
+// ImportTuples brings the store in line with the given tuples and returns the diff that was (or, in dry run mode,
+// would be) applied. Every tuple in the file is validated against the model before anything is written.
+func ImportTuples(ctx context.Context, store TupleStore, model *Model, tuples []client.ClientTupleKey, options ImportOptions) (*TupleDiff, error) {
+	err := ValidateTuples(model, tuples...)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := store.ReadTuples(ctx, client.ClientTupleKey{})
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffTuples(current, tuples, options.Delete)
+	if options.DryRun {
+		return &diff, nil
+	}
+
+	err = writeTuplesInChunks(ctx, store, model, diff.Writes, diff.Deletes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diff, nil
+}
+
+// writeTuplesInChunks writes and deletes tuples in transactions of at most MaxTuplesPerWrite tuples. All writes are
+// validated before the first transaction because the chunks are not applied atomically. Writes are applied before
+// deletes so that a failure part way through never removes access without granting its replacement.
+func writeTuplesInChunks(ctx context.Context, store TupleStore, model *Model, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	err := ValidateTuples(model, writes...)
+	if err != nil {
+		return err
+	}
+
+	for len(writes) > 0 || len(deletes) > 0 {
+		writeCount := len(writes)
+		if writeCount > MaxTuplesPerWrite {
+			writeCount = MaxTuplesPerWrite
+		}
+
+		deleteCount := len(deletes)
+		if deleteCount > MaxTuplesPerWrite-writeCount {
+			deleteCount = MaxTuplesPerWrite - writeCount
+		}
+
+		err = store.WriteTuples(ctx, writes[:writeCount], deletes[:deleteCount])
+		if err != nil {
+			return err
+		}
+
+		writes = writes[writeCount:]
+		deletes = deletes[deleteCount:]
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package openfga
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkRecordingStore records the size of each write transaction and rejects those OpenFGA would reject.
+type chunkRecordingStore struct {
+	*MemoryStore
+	chunks []int
+}
+
+func (s *chunkRecordingStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	if len(writes)+len(deletes) > MaxTuplesPerWrite {
+		return fmt.Errorf("Too many tuples in write: %d", len(writes)+len(deletes))
+	}
+
+	s.chunks = append(s.chunks, len(writes)+len(deletes))
+	return s.MemoryStore.WriteTuples(ctx, writes, deletes)
+}
+
+func TestEncodeDecodeTuples(t *testing.T) {
+	tuples := []client.ClientTupleKey{
+		{User: "user:*", Relation: "user", Object: "server:lxd"},
+		{User: "group:operators#member", Relation: "operator", Object: "project:default"},
+		{User: "server:lxd", Relation: "server", Object: "project:default"},
+	}
+
+	var yamlOutput bytes.Buffer
+	err := EncodeTuples(&yamlOutput, TupleFileYAML, tuples)
+	require.NoError(t, err)
+	require.Equal(t, `tuples:
+  - user: group:operators#member
+    relation: operator
+    object: project:default
+  - user: server:lxd
+    relation: server
+    object: project:default
+  - user: user:*
+    relation: user
+    object: server:lxd
+`, yamlOutput.String())
+
+	var csvOutput bytes.Buffer
+	err = EncodeTuples(&csvOutput, TupleFileCSV, tuples)
+	require.NoError(t, err)
+	require.Equal(t, `user,relation,object
+group:operators#member,operator,project:default
+server:lxd,server,project:default
+user:*,user,server:lxd
+`, csvOutput.String())
+
+	for format, output := range map[TupleFileFormat]string{TupleFileYAML: yamlOutput.String(), TupleFileCSV: csvOutput.String()} {
+		decoded, err := DecodeTuples(strings.NewReader(output), format)
+		require.NoError(t, err)
+		require.ElementsMatch(t, tuples, decoded)
+	}
+
+	_, err = DecodeTuples(strings.NewReader("user,relation,object\nuser:a,admin,server:lxd\nuser:a,admin,server:lxd\n"), TupleFileCSV)
+	require.EqualError(t, err, `Duplicate tuple "user:a admin server:lxd"`)
+
+	_, err = DecodeTuples(strings.NewReader("tuples:\n  - user: user:a\n    object: server:lxd\n"), TupleFileYAML)
+	require.EqualError(t, err, `Invalid tuple "user:a  server:lxd": User, relation and object are required`)
+
+	_, err = DecodeTuples(strings.NewReader("object,relation,user\n"), TupleFileCSV)
+	require.EqualError(t, err, `Failed to decode tuples: First row must be "user,relation,object"`)
+}
+
+func TestImportTuples(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	store := &chunkRecordingStore{MemoryStore: NewMemoryStore(
+		client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		client.ClientTupleKey{User: "user:old_admin", Relation: "admin", Object: "server:lxd"},
+	)}
+
+	desired := []client.ClientTupleKey{{User: "user:*", Relation: "user", Object: "server:lxd"}}
+	for i := 0; i < 150; i++ {
+		desired = append(desired, client.ClientTupleKey{User: "server:lxd", Relation: "server", Object: fmt.Sprintf("project:project%03d", i)})
+	}
+
+	diff, err := ImportTuples(context.Background(), store, model, desired, ImportOptions{DryRun: true, Delete: true})
+	require.NoError(t, err)
+	require.Len(t, diff.Writes, 150)
+	require.Equal(t, []client.ClientTupleKey{{User: "user:old_admin", Relation: "admin", Object: "server:lxd"}}, diff.Deletes)
+	require.True(t, strings.HasPrefix(diff.String(), "- user:old_admin admin server:lxd\n+ server:lxd server project:project000\n"))
+	require.Empty(t, store.chunks, "Dry run must not write")
+
+	diff, err = ImportTuples(context.Background(), store, model, desired, ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, diff.Writes, 150)
+	require.Empty(t, diff.Deletes, "Deletes must only be applied when requested")
+	require.Equal(t, []int{100, 50}, store.chunks)
+	require.Len(t, store.Tuples(), 152)
+
+	diff, err = ImportTuples(context.Background(), store, model, desired, ImportOptions{Delete: true})
+	require.NoError(t, err)
+	require.Empty(t, diff.Writes)
+	require.Len(t, diff.Deletes, 1)
+	require.ElementsMatch(t, desired, store.Tuples())
+
+	_, err = ImportTuples(context.Background(), store, model, []client.ClientTupleKey{
+		{User: "user:bob", Relation: "admin", Object: "instance:default/c1"},
+		{User: "group:admins", Relation: "admin", Object: "server:lxd"},
+		{User: "user:bob", Relation: "member", Object: "group:admins"},
+	}, ImportOptions{})
+	require.EqualError(t, err, `Invalid tuple "user:bob admin instance:default/c1": Relation "admin" is not defined on type "instance"
+Invalid tuple "group:admins admin server:lxd": group is not allowed on relation "admin" of type "server" (allowed: user, group#member)`)
+}
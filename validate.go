@@ -0,0 +1,136 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openfga/go-sdk/client"
+)
+
+// TupleValidationError is returned for a tuple that the model does not allow.
+type TupleValidationError struct {
+	Tuple  client.ClientTupleKey
+	Reason string
+}
+
+// Error implements error.
+func (e *TupleValidationError) Error() string {
+	return fmt.Sprintf("Invalid tuple %q: %s", formatTuple(e.Tuple), e.Reason)
+}
+
+// ValidateTuple checks that a tuple is allowed by the model. The relation must be defined on the type of the object,
+// and the user must match one of the relation's directly related user types. That is, it must be of an allowed type,
+// be a userset (e.g. `group:foo#member`) only where a userset is allowed, and be a wildcard (e.g. `user:*`) only where
+// public access is allowed.
+func ValidateTuple(model *Model, tuple client.ClientTupleKey) error {
+	invalid := func(format string, args ...any) error {
+		return &TupleValidationError{Tuple: tuple, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	objectType, objectID, ok := strings.Cut(tuple.Object, ":")
+	if !ok || objectType == "" || objectID == "" {
+		return invalid("Object must be of the form <type>:<id>")
+	}
+
+	if objectID == "*" {
+		return invalid("Object cannot be a wildcard")
+	}
+
+	if !model.HasType(objectType) {
+		return invalid("Type %q is not defined", objectType)
+	}
+
+	if !model.HasRelation(objectType, tuple.Relation) {
+		return invalid("Relation %q is not defined on type %q", tuple.Relation, objectType)
+	}
+
+	userObject, userRelation, isUserset := strings.Cut(tuple.User, "#")
+	userType, userID, ok := strings.Cut(userObject, ":")
+	if !ok || userType == "" || userID == "" || (isUserset && userRelation == "") {
+		return invalid("User must be of the form <type>:<id>, <type>:<id>#<relation> or <type>:*")
+	}
+
+	isWildcard := userID == "*"
+	if isWildcard && isUserset {
+		return invalid("User cannot be both a wildcard and a userset")
+	}
+
+	if !model.HasType(userType) {
+		return invalid("User type %q is not defined", userType)
+	}
+
+	if isUserset && !model.HasRelation(userType, userRelation) {
+		return invalid("Relation %q is not defined on user type %q", userRelation, userType)
+	}
+
+	allowed := model.DirectlyRelatedUserTypes(objectType, tuple.Relation)
+	for _, reference := range allowed {
+		if reference.Type == userType && reference.GetRelation() == userRelation && reference.HasWildcard() == isWildcard {
+			return nil
+		}
+	}
+
+	allowedNames := make([]string, 0, len(allowed))
+	for _, reference := range allowed {
+		allowedNames = append(allowedNames, formatRelationReference(reference.Type, reference.GetRelation(), reference.HasWildcard()))
+	}
+
+	if len(allowedNames) == 0 {
+		return invalid("Relation %q of type %q cannot be written directly", tuple.Relation, objectType)
+	}
+
+	return invalid("%s is not allowed on relation %q of type %q (allowed: %s)", formatRelationReference(userType, userRelation, isWildcard), tuple.Relation, objectType, strings.Join(allowedNames, ", "))
+}
+
+// ValidateTuples validates each tuple and returns all validation errors joined.
+func ValidateTuples(model *Model, tuples ...client.ClientTupleKey) error {
+	var errs []error
+	for _, tuple := range tuples {
+		err := ValidateTuple(model, tuple)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// formatRelationReference formats a directly related user type as in the DSL, e.g. `user`, `group#member` or `user:*`.
+func formatRelationReference(userType string, relation string, wildcard bool) string {
+	switch {
+	case wildcard:
+		return userType + ":*"
+	case relation != "":
+		return userType + "#" + relation
+	}
+
+	return userType
+}
+
+// validatingStore is a TupleStore that validates every tuple before it is written.
+type validatingStore struct {
+	TupleStore
+
+	model *Model
+}
+
+// NewValidatingStore returns a TupleStore that rejects writes of tuples that the model does not allow. Deletes are not
+// validated so that tuples made invalid by a model change can still be removed.
+func NewValidatingStore(store TupleStore, model *Model) TupleStore {
+	return &validatingStore{
+		TupleStore: store,
+		model:      model,
+	}
+}
+
+// WriteTuples implements TupleStore.
+func (s *validatingStore) WriteTuples(ctx context.Context, writes []client.ClientTupleKey, deletes []client.ClientTupleKey) error {
+	err := ValidateTuples(s.model, writes...)
+	if err != nil {
+		return err
+	}
+
+	return s.TupleStore.WriteTuples(ctx, writes, deletes)
+}
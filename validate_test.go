@@ -0,0 +1,130 @@
+package openfga
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openfga/go-sdk/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTuple(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		tuple       client.ClientTupleKey
+		err         string
+	}{
+		{
+			description: "User granted a role",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "admin", Object: "server:lxd"},
+		},
+		{
+			description: "Group members granted an entitlement",
+			tuple:       client.ClientTupleKey{User: "group:operators#member", Relation: "can_exec", Object: "instance:default/c1"},
+		},
+		{
+			description: "Public access to the server",
+			tuple:       client.ClientTupleKey{User: "user:*", Relation: "user", Object: "server:lxd"},
+		},
+		{
+			description: "Parent link",
+			tuple:       client.ClientTupleKey{User: "project:default", Relation: "project", Object: "instance:default/c1"},
+		},
+		{
+			description: "Relation that does not exist on the type",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "admin", Object: "instance:default/c1"},
+			err:         `Invalid tuple "user:bob admin instance:default/c1": Relation "admin" is not defined on type "instance"`,
+		},
+		{
+			description: "Group without #member",
+			tuple:       client.ClientTupleKey{User: "group:foo", Relation: "operator", Object: "project:default"},
+			err:         `Invalid tuple "group:foo operator project:default": group is not allowed on relation "operator" of type "project" (allowed: user, group#member)`,
+		},
+		{
+			description: "Userset with a relation that does not exist on the group type",
+			tuple:       client.ClientTupleKey{User: "group:foo#owner", Relation: "operator", Object: "project:default"},
+			err:         `Invalid tuple "group:foo#owner operator project:default": Relation "owner" is not defined on user type "group"`,
+		},
+		{
+			description: "Wildcard where public access is not allowed",
+			tuple:       client.ClientTupleKey{User: "user:*", Relation: "admin", Object: "server:lxd"},
+			err:         `Invalid tuple "user:* admin server:lxd": user:* is not allowed on relation "admin" of type "server" (allowed: user, group#member)`,
+		},
+		{
+			description: "Single user where only public access is allowed",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "user", Object: "server:lxd"},
+			err:         `Invalid tuple "user:bob user server:lxd": user is not allowed on relation "user" of type "server" (allowed: user:*)`,
+		},
+		{
+			description: "Wildcard object",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "operator", Object: "project:*"},
+			err:         `Invalid tuple "user:bob operator project:*": Object cannot be a wildcard`,
+		},
+		{
+			description: "Computed relation",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "can_edit_server", Object: "server:lxd"},
+			err:         `Invalid tuple "user:bob can_edit_server server:lxd": Relation "can_edit_server" of type "server" cannot be written directly`,
+		},
+		{
+			description: "Parent link to the wrong type",
+			tuple:       client.ClientTupleKey{User: "server:lxd", Relation: "project", Object: "instance:default/c1"},
+			err:         `Invalid tuple "server:lxd project instance:default/c1": server is not allowed on relation "project" of type "instance" (allowed: project)`,
+		},
+		{
+			description: "Unknown user type",
+			tuple:       client.ClientTupleKey{User: "robot:r2d2", Relation: "admin", Object: "server:lxd"},
+			err:         `Invalid tuple "robot:r2d2 admin server:lxd": User type "robot" is not defined`,
+		},
+		{
+			description: "Malformed user",
+			tuple:       client.ClientTupleKey{User: "bob", Relation: "admin", Object: "server:lxd"},
+			err:         `Invalid tuple "bob admin server:lxd": User must be of the form <type>:<id>, <type>:<id>#<relation> or <type>:*`,
+		},
+		{
+			description: "Malformed object",
+			tuple:       client.ClientTupleKey{User: "user:bob", Relation: "admin", Object: "lxd"},
+			err:         `Invalid tuple "user:bob admin lxd": Object must be of the form <type>:<id>`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Case %d: %s", i, test.description)
+
+		err := ValidateTuple(model, test.tuple)
+		if test.err == "" {
+			require.NoError(t, err)
+			continue
+		}
+
+		require.EqualError(t, err, test.err)
+
+		var validationErr *TupleValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, test.tuple, validationErr.Tuple)
+	}
+}
+
+func TestValidatingStore(t *testing.T) {
+	model, err := DefaultModel()
+	require.NoError(t, err)
+
+	invalid := client.ClientTupleKey{User: "user:bob", Relation: "admin", Object: "instance:default/c1"}
+	memoryStore := NewMemoryStore(invalid)
+	store := NewValidatingStore(memoryStore, model)
+
+	err = store.WriteTuples(context.Background(), []client.ClientTupleKey{
+		{User: "user:alice", Relation: "admin", Object: "server:lxd"},
+		invalid,
+	}, nil)
+	require.Error(t, err)
+	require.Equal(t, []client.ClientTupleKey{invalid}, memoryStore.Tuples(), "No tuple may be written if any is invalid")
+
+	// Tuples that are no longer valid can still be deleted.
+	err = store.WriteTuples(context.Background(), nil, []client.ClientTupleKey{invalid})
+	require.NoError(t, err)
+	require.Empty(t, memoryStore.Tuples())
+}